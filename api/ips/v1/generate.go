@@ -0,0 +1,5 @@
+// Package ipsv1 holds the protobuf definitions of the gRPC API and the code
+// generated from them.
+package ipsv1
+
+//go:generate protoc --proto_path=../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/ips/v1/ips.proto
@@ -0,0 +1,318 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: api/ips/v1/ips.proto
+
+package ipsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	IPAddresses_Lookup_FullMethodName                 = "/ips.v1.IPAddresses/Lookup"
+	IPAddresses_BatchLookup_FullMethodName            = "/ips.v1.IPAddresses/BatchLookup"
+	IPAddresses_List_FullMethodName                   = "/ips.v1.IPAddresses/List"
+	IPAddresses_GetIPQuantityByCountry_FullMethodName = "/ips.v1.IPAddresses/GetIPQuantityByCountry"
+	IPAddresses_GetTop10ISPByCountry_FullMethodName   = "/ips.v1.IPAddresses/GetTop10ISPByCountry"
+)
+
+// IPAddressesClient is the client API for IPAddresses service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// IPAddresses mirrors the /v1/ips REST endpoints. Credentials are sent in the
+// x-api-key or authorization ("Bearer <token>") metadata and every RPC
+// requires the same scope as its REST counterpart.
+type IPAddressesClient interface {
+	// Lookup returns the range holding ip, NOT_FOUND when it is not in the
+	// dataset. Requires the lookup scope.
+	Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*IP, error)
+	// BatchLookup resolves up to 100 addresses in a single call. Requires the
+	// lookup scope.
+	BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error)
+	// List streams the ranges of a country until limit addresses are covered.
+	// Requires the export scope.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (IPAddresses_ListClient, error)
+	// GetIPQuantityByCountry counts the addresses of a country. Requires the
+	// aggregate scope.
+	GetIPQuantityByCountry(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*CountryQuantity, error)
+	// GetTop10ISPByCountry returns the ISPs with the most addresses in a
+	// country. Requires the aggregate scope.
+	GetTop10ISPByCountry(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*TopISPs, error)
+}
+
+type iPAddressesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIPAddressesClient(cc grpc.ClientConnInterface) IPAddressesClient {
+	return &iPAddressesClient{cc}
+}
+
+func (c *iPAddressesClient) Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*IP, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IP)
+	err := c.cc.Invoke(ctx, IPAddresses_Lookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPAddressesClient) BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchLookupResponse)
+	err := c.cc.Invoke(ctx, IPAddresses_BatchLookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPAddressesClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (IPAddresses_ListClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IPAddresses_ServiceDesc.Streams[0], IPAddresses_List_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &iPAddressesListClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type IPAddresses_ListClient interface {
+	Recv() (*IP, error)
+	grpc.ClientStream
+}
+
+type iPAddressesListClient struct {
+	grpc.ClientStream
+}
+
+func (x *iPAddressesListClient) Recv() (*IP, error) {
+	m := new(IP)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *iPAddressesClient) GetIPQuantityByCountry(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*CountryQuantity, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountryQuantity)
+	err := c.cc.Invoke(ctx, IPAddresses_GetIPQuantityByCountry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPAddressesClient) GetTop10ISPByCountry(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*TopISPs, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TopISPs)
+	err := c.cc.Invoke(ctx, IPAddresses_GetTop10ISPByCountry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IPAddressesServer is the server API for IPAddresses service.
+// All implementations must embed UnimplementedIPAddressesServer
+// for forward compatibility
+//
+// IPAddresses mirrors the /v1/ips REST endpoints. Credentials are sent in the
+// x-api-key or authorization ("Bearer <token>") metadata and every RPC
+// requires the same scope as its REST counterpart.
+type IPAddressesServer interface {
+	// Lookup returns the range holding ip, NOT_FOUND when it is not in the
+	// dataset. Requires the lookup scope.
+	Lookup(context.Context, *LookupRequest) (*IP, error)
+	// BatchLookup resolves up to 100 addresses in a single call. Requires the
+	// lookup scope.
+	BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error)
+	// List streams the ranges of a country until limit addresses are covered.
+	// Requires the export scope.
+	List(*ListRequest, IPAddresses_ListServer) error
+	// GetIPQuantityByCountry counts the addresses of a country. Requires the
+	// aggregate scope.
+	GetIPQuantityByCountry(context.Context, *CountryRequest) (*CountryQuantity, error)
+	// GetTop10ISPByCountry returns the ISPs with the most addresses in a
+	// country. Requires the aggregate scope.
+	GetTop10ISPByCountry(context.Context, *CountryRequest) (*TopISPs, error)
+	mustEmbedUnimplementedIPAddressesServer()
+}
+
+// UnimplementedIPAddressesServer must be embedded to have forward compatible implementations.
+type UnimplementedIPAddressesServer struct {
+}
+
+func (UnimplementedIPAddressesServer) Lookup(context.Context, *LookupRequest) (*IP, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lookup not implemented")
+}
+func (UnimplementedIPAddressesServer) BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchLookup not implemented")
+}
+func (UnimplementedIPAddressesServer) List(*ListRequest, IPAddresses_ListServer) error {
+	return status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedIPAddressesServer) GetIPQuantityByCountry(context.Context, *CountryRequest) (*CountryQuantity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIPQuantityByCountry not implemented")
+}
+func (UnimplementedIPAddressesServer) GetTop10ISPByCountry(context.Context, *CountryRequest) (*TopISPs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTop10ISPByCountry not implemented")
+}
+func (UnimplementedIPAddressesServer) mustEmbedUnimplementedIPAddressesServer() {}
+
+// UnsafeIPAddressesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IPAddressesServer will
+// result in compilation errors.
+type UnsafeIPAddressesServer interface {
+	mustEmbedUnimplementedIPAddressesServer()
+}
+
+func RegisterIPAddressesServer(s grpc.ServiceRegistrar, srv IPAddressesServer) {
+	s.RegisterService(&IPAddresses_ServiceDesc, srv)
+}
+
+func _IPAddresses_Lookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPAddressesServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IPAddresses_Lookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPAddressesServer).Lookup(ctx, req.(*LookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPAddresses_BatchLookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchLookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPAddressesServer).BatchLookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IPAddresses_BatchLookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPAddressesServer).BatchLookup(ctx, req.(*BatchLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPAddresses_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IPAddressesServer).List(m, &iPAddressesListServer{ServerStream: stream})
+}
+
+type IPAddresses_ListServer interface {
+	Send(*IP) error
+	grpc.ServerStream
+}
+
+type iPAddressesListServer struct {
+	grpc.ServerStream
+}
+
+func (x *iPAddressesListServer) Send(m *IP) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _IPAddresses_GetIPQuantityByCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPAddressesServer).GetIPQuantityByCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IPAddresses_GetIPQuantityByCountry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPAddressesServer).GetIPQuantityByCountry(ctx, req.(*CountryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPAddresses_GetTop10ISPByCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPAddressesServer).GetTop10ISPByCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IPAddresses_GetTop10ISPByCountry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPAddressesServer).GetTop10ISPByCountry(ctx, req.(*CountryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IPAddresses_ServiceDesc is the grpc.ServiceDesc for IPAddresses service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IPAddresses_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ips.v1.IPAddresses",
+	HandlerType: (*IPAddressesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Lookup",
+			Handler:    _IPAddresses_Lookup_Handler,
+		},
+		{
+			MethodName: "BatchLookup",
+			Handler:    _IPAddresses_BatchLookup_Handler,
+		},
+		{
+			MethodName: "GetIPQuantityByCountry",
+			Handler:    _IPAddresses_GetIPQuantityByCountry_Handler,
+		},
+		{
+			MethodName: "GetTop10ISPByCountry",
+			Handler:    _IPAddresses_GetTop10ISPByCountry_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "List",
+			Handler:       _IPAddresses_List_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/ips/v1/ips.proto",
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/application"
+	"os"
+	"path/filepath"
+)
+
+const exportUsage = "usage: api export-mmdb <file>"
+
+// exportMMDB writes ip2location_px7 as a MaxMind DB file. It writes to a
+// temporary file next to the target and renames it at the end, so readers
+// watching the file never see a partial database.
+func exportMMDB(args []string) error {
+	if len(args) != 1 {
+		return errors.New(exportUsage)
+	}
+	exporter, err := application.BuildExporter()
+	if err != nil {
+		return err
+	}
+	target := args[0]
+	file, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	written, err := exporter.Export(context.Background(), file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), target); err != nil {
+		return err
+	}
+	fmt.Printf("exported %d range(s) to %s\n", written, target)
+	return nil
+}
@@ -0,0 +1,114 @@
+package gql
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound what a single query may ask for, checked before executing it.
+type Limits struct {
+	// MaxComplexity is the highest cost accepted, each field costs 1 and
+	// list fields multiply the cost of their selection by the items they
+	// may return.
+	MaxComplexity int
+	MaxDepth      int
+	// AggregateCost is what the aggregate fields cost instead of 1, so a
+	// query asking for several countries pays for each scan like REST does.
+	AggregateCost int
+}
+
+// aggregates are the fields computed over every range of a country.
+var aggregates = map[string]bool{"ipQuantity": true, "topISPs": true}
+
+// multipliers estimates how many items list fields return.
+var multipliers = map[string]func(*ast.Field, map[string]interface{}) int{
+	"ips": func(field *ast.Field, variables map[string]interface{}) int {
+		return listLength(field, "addresses", variables)
+	},
+	"topISPs": func(*ast.Field, map[string]interface{}) int { return 10 },
+}
+
+// complexity returns the cost of the operation to execute.
+func complexity(document *ast.Document, operationName string, variables map[string]interface{}, limits Limits) (int, error) {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return 0, fmt.Errorf("unknown operation %q", operationName)
+	}
+	c := &calculator{fragments: fragments, variables: variables, limits: limits}
+	return c.selectionSet(operation.SelectionSet, 1)
+}
+
+type calculator struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	limits    Limits
+}
+
+func (c *calculator) selectionSet(set *ast.SelectionSet, depth int) (int, error) {
+	if set == nil {
+		return 0, nil
+	}
+	if depth > c.limits.MaxDepth {
+		return 0, fmt.Errorf("query is nested deeper than %d levels", c.limits.MaxDepth)
+	}
+	total := 0
+	for _, selection := range set.Selections {
+		var cost int
+		var err error
+		switch selection := selection.(type) {
+		case *ast.Field:
+			cost, err = c.selectionSet(selection.SelectionSet, depth+1)
+			if multiplier, ok := multipliers[selection.Name.Value]; ok {
+				cost *= multiplier(selection, c.variables)
+			}
+			if aggregates[selection.Name.Value] {
+				cost += c.limits.AggregateCost
+			} else {
+				cost++
+			}
+		case *ast.InlineFragment:
+			cost, err = c.selectionSet(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if fragment, ok := c.fragments[selection.Name.Value]; ok {
+				cost, err = c.selectionSet(fragment.SelectionSet, depth)
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+		if total > c.limits.MaxComplexity {
+			return 0, fmt.Errorf("query complexity exceeds the limit of %d", c.limits.MaxComplexity)
+		}
+	}
+	return total, nil
+}
+
+// listLength counts the items of a list argument, given inline or as a variable.
+func listLength(field *ast.Field, name string, variables map[string]interface{}) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != name {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.ListValue:
+			return len(value.Values)
+		case *ast.Variable:
+			if list, ok := variables[value.Name.Value].([]interface{}); ok {
+				return len(list)
+			}
+		}
+	}
+	return 1
+}
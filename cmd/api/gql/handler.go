@@ -0,0 +1,129 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/handlers"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+)
+
+//go:generate mockgen -source=handler.go -destination=handler_mock.go -package=gql
+
+var tracer = tracing.Tracer("github.com/mborroni/dreamlab-challenge/cmd/api/gql")
+
+type service interface {
+	GetMany(context.Context, []string) (map[string]*ips.IP, error)
+	GetTop10ISPByCountry(context.Context, string) ([]string, error)
+	GetIPQuantityByCountry(context.Context, string) (int, error)
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Charge returns the middleware a query of the given complexity runs
+// through once validated, so it can take rate limit tokens in proportion to
+// what the query costs.
+type Charge func(complexity int) func(http.Handler) http.Handler
+
+// Handler serves GraphQL queries over HTTP, as a POST JSON body or the query,
+// operationName and variables query parameters of a GET.
+type Handler struct {
+	schema  graphql.Schema
+	service service
+	limits  Limits
+	// charge is nil when queries are free.
+	charge Charge
+}
+
+func NewHandler(service service, limits Limits, charge Charge) (*Handler, error) {
+	schema, err := newSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		schema:  schema,
+		service: service,
+		limits:  limits,
+		charge:  charge,
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "GraphQLHandler.ServeHTTP")
+	defer span.End()
+
+	input, ok := decode(r)
+	if !ok {
+		respondErrors(w, http.StatusBadRequest, gqlerrors.NewFormattedError("invalid request"))
+		return
+	}
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(input.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		respondErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err)...)
+		return
+	}
+	validation := graphql.ValidateDocument(&h.schema, document, nil)
+	if !validation.IsValid {
+		respondErrors(w, http.StatusBadRequest, validation.Errors...)
+		return
+	}
+	cost, err := complexity(document, input.OperationName, input.Variables, h.limits)
+	if err != nil {
+		respondErrors(w, http.StatusBadRequest, gqlerrors.NewFormattedError(err.Error()))
+		return
+	}
+	span.SetAttributes(attribute.Int("graphql.complexity", cost))
+
+	var execute http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        h.schema,
+			AST:           document,
+			OperationName: input.OperationName,
+			Args:          input.Variables,
+			Context:       withLoaders(r.Context(), h.service),
+		})
+		_ = handlers.RespondJSON(w, result, http.StatusOK)
+	})
+	if h.charge != nil {
+		execute = h.charge(cost)(execute)
+	}
+	execute.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func decode(r *http.Request) (*request, bool) {
+	input := &request{}
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		input.Query = query.Get("query")
+		input.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &input.Variables); err != nil {
+				return nil, false
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	return input, input.Query != ""
+}
+
+func respondErrors(w http.ResponseWriter, code int, errs ...gqlerrors.FormattedError) {
+	_ = handlers.RespondJSON(w, &graphql.Result{Errors: errs}, code)
+}
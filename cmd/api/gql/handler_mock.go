@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: handler.go
+
+// Package gql is a generated GoMock package.
+package gql
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	reflect "reflect"
+)
+
+// Mockservice is a mock of service interface
+type Mockservice struct {
+	ctrl     *gomock.Controller
+	recorder *MockserviceMockRecorder
+}
+
+// MockserviceMockRecorder is the mock recorder for Mockservice
+type MockserviceMockRecorder struct {
+	mock *Mockservice
+}
+
+// NewMockservice creates a new mock instance
+func NewMockservice(ctrl *gomock.Controller) *Mockservice {
+	mock := &Mockservice{ctrl: ctrl}
+	mock.recorder = &MockserviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *Mockservice) EXPECT() *MockserviceMockRecorder {
+	return m.recorder
+}
+
+// GetMany mocks base method
+func (m *Mockservice) GetMany(arg0 context.Context, arg1 []string) (map[string]*ips.IP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMany", arg0, arg1)
+	ret0, _ := ret[0].(map[string]*ips.IP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMany indicates an expected call of GetMany
+func (mr *MockserviceMockRecorder) GetMany(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*Mockservice)(nil).GetMany), arg0, arg1)
+}
+
+// GetTop10ISPByCountry mocks base method
+func (m *Mockservice) GetTop10ISPByCountry(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTop10ISPByCountry", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTop10ISPByCountry indicates an expected call of GetTop10ISPByCountry
+func (mr *MockserviceMockRecorder) GetTop10ISPByCountry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTop10ISPByCountry", reflect.TypeOf((*Mockservice)(nil).GetTop10ISPByCountry), arg0, arg1)
+}
+
+// GetIPQuantityByCountry mocks base method
+func (m *Mockservice) GetIPQuantityByCountry(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIPQuantityByCountry", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIPQuantityByCountry indicates an expected call of GetIPQuantityByCountry
+func (mr *MockserviceMockRecorder) GetIPQuantityByCountry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPQuantityByCountry", reflect.TypeOf((*Mockservice)(nil).GetIPQuantityByCountry), arg0, arg1)
+}
@@ -0,0 +1,270 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var (
+	buenosAires = &ips.IP{
+		ProxyType: "PUB",
+		Country:   ips.Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"},
+		ISP:       "CTL LATAM",
+		ASN:       3549,
+		AS:        "Level 3 Parent LLC",
+	}
+	cordoba = &ips.IP{
+		Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"},
+		ISP:     "Telecom Argentina S.A.",
+	}
+)
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+var limits = Limits{MaxComplexity: 50, MaxDepth: 4}
+
+func serve(t *testing.T, service service, limits Limits, principal *auth.Principal, tier *auth.Tier, r *http.Request) (int, *response) {
+	handler, err := NewHandler(service, limits, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := auth.WithPrincipal(r.Context(), principal)
+	if tier != nil {
+		ctx = auth.WithTier(ctx, tier)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r.WithContext(ctx))
+	output := &response{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), output))
+	return w.Code, output
+}
+
+func post(query string) *http.Request {
+	body, _ := json.Marshal(map[string]interface{}{"query": query})
+	return httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+}
+
+var lookupAndAggregate = &auth.Principal{ID: "key:1", Scopes: []string{auth.ScopeLookup, auth.ScopeAggregate}}
+
+func TestHandler_Batching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewMockservice(ctrl)
+	service.EXPECT().
+		GetMany(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, addresses []string) (map[string]*ips.IP, error) {
+			assert.ElementsMatch(t, []string{"181.192.10.182", "190.2.3.4", "10.0.0.1"}, addresses)
+			return map[string]*ips.IP{"181.192.10.182": buenosAires, "190.2.3.4": cordoba}, nil
+		}).
+		Times(1)
+	service.EXPECT().GetIPQuantityByCountry(gomock.Any(), "Argentina").Return(4000, nil).Times(1)
+	service.EXPECT().GetTop10ISPByCountry(gomock.Any(), "Argentina").Return([]string{"Telecom Argentina S.A."}, nil).Times(1)
+
+	code, output := serve(t, service, limits, lookupAndAggregate, nil, post(`{
+		first: ip(address: "181.192.10.182") { city isp { name } asn { number name } country { ipQuantity } }
+		others: ips(addresses: ["190.2.3.4", "10.0.0.1"]) { city country { name ipQuantity topISPs { name } } }
+	}`))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, output.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"first": map[string]interface{}{
+			"city":    "Buenos Aires",
+			"isp":     map[string]interface{}{"name": "CTL LATAM"},
+			"asn":     map[string]interface{}{"number": float64(3549), "name": "Level 3 Parent LLC"},
+			"country": map[string]interface{}{"ipQuantity": float64(4000)},
+		},
+		"others": []interface{}{
+			map[string]interface{}{
+				"city": "Cordoba",
+				"country": map[string]interface{}{
+					"name":       "Argentina",
+					"ipQuantity": float64(4000),
+					"topISPs":    []interface{}{map[string]interface{}{"name": "Telecom Argentina S.A."}},
+				},
+			},
+			nil,
+		},
+	}, output.Data)
+}
+
+func TestHandler_Authorization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewMockservice(ctrl)
+	service.EXPECT().
+		GetMany(gomock.Any(), []string{"181.192.10.182"}).
+		Return(map[string]*ips.IP{"181.192.10.182": buenosAires}, nil)
+	tier, _ := auth.NewTier("city", []string{auth.FieldRegion, auth.FieldCity})
+	principal := &auth.Principal{ID: "key:1", Scopes: []string{auth.ScopeLookup}}
+
+	code, output := serve(t, service, limits, principal, tier, post(`{
+		ip(address: "181.192.10.182") { city isp { name } country { code ipQuantity } }
+	}`))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{
+		"ip": map[string]interface{}{
+			"city":    "Buenos Aires",
+			"isp":     nil,
+			"country": map[string]interface{}{"code": "AR", "ipQuantity": nil},
+		},
+	}, output.Data)
+	if assert.Len(t, output.Errors, 1) {
+		assert.Equal(t, "insufficient scope: aggregate required", output.Errors[0].Message)
+	}
+}
+
+func TestHandler_Rejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	addresses := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		addresses = append(addresses, `"1.1.1.1"`)
+	}
+
+	tests := []struct {
+		name    string
+		limits  Limits
+		request *http.Request
+		message string
+	}{
+		{
+			name:    "complexity",
+			limits:  limits,
+			request: post(`{ ips(addresses: [` + strings.Join(addresses, ",") + `]) { city country { name } } }`),
+			message: "query complexity exceeds the limit of 50",
+		},
+		{
+			name:    "depth",
+			limits:  Limits{MaxComplexity: 50, MaxDepth: 3},
+			request: post(`{ ip(address: "1.1.1.1") { country { topISPs { name } } } }`),
+			message: "query is nested deeper than 3 levels",
+		},
+		{
+			name:    "invalid field",
+			limits:  limits,
+			request: post(`{ ip(address: "1.1.1.1") { elevation } }`),
+			message: `Cannot query field "elevation" on type "IP".`,
+		},
+		{
+			name:    "empty",
+			limits:  limits,
+			request: httptest.NewRequest(http.MethodGet, "/graphql", nil),
+			message: "invalid request",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, output := serve(t, NewMockservice(ctrl), tc.limits, lookupAndAggregate, nil, tc.request)
+
+			assert.Equal(t, http.StatusBadRequest, code)
+			if assert.Len(t, output.Errors, 1) {
+				assert.Equal(t, tc.message, output.Errors[0].Message)
+			}
+		})
+	}
+}
+
+func TestHandler_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewMockservice(ctrl)
+	service.EXPECT().GetIPQuantityByCountry(gomock.Any(), "Argentina").Return(4000, nil)
+	query := url.Values{
+		"query":     {`query Quantity($name: String!) { country(name: $name) { ipQuantity } }`},
+		"variables": {`{"name": "argentina"}`},
+	}
+
+	code, output := serve(t, service, limits, lookupAndAggregate, nil,
+		httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"country": map[string]interface{}{"ipQuantity": float64(4000)}}, output.Data)
+}
+
+func TestHandler_Charge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	charged := make([]int, 0)
+	charge := func(complexity int) func(http.Handler) http.Handler {
+		charged = append(charged, complexity)
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if complexity > 5 {
+					http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	service := NewMockservice(ctrl)
+	service.EXPECT().GetMany(gomock.Any(), []string{"181.192.10.182"}).
+		Return(map[string]*ips.IP{"181.192.10.182": buenosAires}, nil).
+		Times(1)
+	handler, err := NewHandler(service, limits, charge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := post(`{ ip(address: "181.192.10.182") { city } }`)
+	handler.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), lookupAndAggregate)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r = post(`{ ips(addresses: ["181.192.10.182", "190.2.3.4"]) { city region country { name } } }`)
+	handler.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), lookupAndAggregate)))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	assert.Equal(t, []int{2, 9}, charged)
+}
+
+func TestHandler_ChargeAggregates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	charged := 0
+	charge := func(complexity int) func(http.Handler) http.Handler {
+		charged = complexity
+		return func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			})
+		}
+	}
+	handler, err := NewHandler(NewMockservice(ctrl), Limits{MaxComplexity: 500, MaxDepth: 6, AggregateCost: 100}, charge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := post(`{
+		ar: country(name: "Argentina") { ipQuantity }
+		br: country(name: "Brazil") { ipQuantity topISPs { name } }
+	}`)
+	handler.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), lookupAndAggregate)))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, 1+100+1+100+(100+10), charged, "each aliased aggregate costs as much as its REST call")
+}
@@ -0,0 +1,108 @@
+package gql
+
+import (
+	"context"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"sync"
+)
+
+// ipLoader batches the addresses requested at the same depth of a query,
+// which the executor resolves breadth first, into a single GetMany call.
+type ipLoader struct {
+	ctx     context.Context
+	service service
+
+	mu      sync.Mutex
+	pending []string
+	loaded  map[string]*ips.IP
+	errs    map[string]error
+}
+
+func newIPLoader(ctx context.Context, service service) *ipLoader {
+	return &ipLoader{
+		ctx:     ctx,
+		service: service,
+		loaded:  make(map[string]*ips.IP),
+		errs:    make(map[string]error),
+	}
+}
+
+// load queues address and returns a thunk that resolves it, flushing every
+// address queued so far on the first call.
+func (l *ipLoader) load(address string) func() (*ips.IP, error) {
+	l.mu.Lock()
+	if _, ok := l.loaded[address]; !ok {
+		l.pending = append(l.pending, address)
+	}
+	l.mu.Unlock()
+	return func() (*ips.IP, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			l.flush()
+		}
+		return l.loaded[address], l.errs[address]
+	}
+}
+
+func (l *ipLoader) flush() {
+	batch := l.pending
+	l.pending = nil
+	found, err := l.service.GetMany(l.ctx, batch)
+	for _, address := range batch {
+		l.loaded[address] = found[address]
+		if err != nil {
+			l.errs[address] = err
+		}
+	}
+}
+
+// countryLoader memoizes the aggregates of each country, so a query asking
+// for the same country from several IPs runs each aggregate once.
+type countryLoader struct {
+	ctx     context.Context
+	service service
+
+	mu         sync.Mutex
+	quantities map[string]*result
+	isps       map[string]*result
+}
+
+type result struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+func newCountryLoader(ctx context.Context, service service) *countryLoader {
+	return &countryLoader{
+		ctx:        ctx,
+		service:    service,
+		quantities: make(map[string]*result),
+		isps:       make(map[string]*result),
+	}
+}
+
+func (l *countryLoader) quantity(country string) (interface{}, error) {
+	return l.memo(l.quantities, country, func() (interface{}, error) {
+		return l.service.GetIPQuantityByCountry(l.ctx, country)
+	})
+}
+
+func (l *countryLoader) topISPs(country string) (interface{}, error) {
+	return l.memo(l.isps, country, func() (interface{}, error) {
+		return l.service.GetTop10ISPByCountry(l.ctx, country)
+	})
+}
+
+func (l *countryLoader) memo(results map[string]*result, country string, fetch func() (interface{}, error)) (interface{}, error) {
+	l.mu.Lock()
+	r, ok := results[country]
+	if !ok {
+		r = &result{}
+		results[country] = r
+	}
+	l.mu.Unlock()
+	r.once.Do(func() { r.value, r.err = fetch() })
+	return r.value, r.err
+}
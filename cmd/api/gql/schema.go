@@ -0,0 +1,301 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	"github.com/mborroni/dreamlab-challenge/internal/metrics"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	"strings"
+)
+
+// maxAddresses bounds the ips field, like BatchLookup in the gRPC API.
+const maxAddresses = 100
+
+type loadersKey struct{}
+
+type loaders struct {
+	ips       *ipLoader
+	countries *countryLoader
+}
+
+func withLoaders(ctx context.Context, service service) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		ips:       newIPLoader(ctx, service),
+		countries: newCountryLoader(ctx, service),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// country is what Country fields resolve from, either an IP's country or
+// one asked for by name.
+type country struct {
+	Code string
+	Name string
+}
+
+type isp struct {
+	Name string
+}
+
+type asn struct {
+	Number int
+	Name   string
+}
+
+func newSchema() (graphql.Schema, error) {
+	ispType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ISP",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	asnType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "ASN",
+		Description: "Autonomous system announcing the range",
+		Fields: graphql.Fields{
+			"number": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":   &graphql.Field{Type: graphql.String},
+		},
+	})
+	countryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Country",
+		Fields: graphql.Fields{
+			"code": &graphql.Field{Type: graphql.String, Resolve: nullable(func(c *country) string { return c.Code })},
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"ipQuantity": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Addresses in the country, requires the aggregate scope",
+				Resolve:     resolveIPQuantity,
+			},
+			"topISPs": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(ispType)),
+				Description: "ISPs with the most addresses in the country, requires the aggregate scope",
+				Resolve:     resolveTopISPs,
+			},
+		},
+	})
+	ipType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IP",
+		Description: "Range holding an address. Fields the client's tier is not licensed for, " +
+			"or the served product lacks, resolve to null",
+		Fields: graphql.Fields{
+			"address": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: ipField(func(ip *models.IP) interface{} {
+				return ip.IP
+			})},
+			"country": &graphql.Field{Type: countryType, Resolve: ipField(func(ip *models.IP) interface{} {
+				return &country{Code: ip.Country.Code, Name: ip.Country.Name}
+			})},
+			"region": &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.Country.Region })},
+			"city":   &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.Country.City })},
+			"proxyType": &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string {
+				return ip.ProxyType
+			})},
+			"isp": &graphql.Field{Type: ispType, Resolve: ipField(func(ip *models.IP) interface{} {
+				if ip.ISP == "" {
+					return nil
+				}
+				return &isp{Name: ip.ISP}
+			})},
+			"domain": &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.Domain })},
+			"usage":  &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.Usage })},
+			"asn": &graphql.Field{Type: asnType, Resolve: ipField(func(ip *models.IP) interface{} {
+				if ip.ASN == 0 {
+					return nil
+				}
+				return &asn{Number: ip.ASN, Name: ip.AS}
+			})},
+			"latitude": &graphql.Field{Type: graphql.Float, Resolve: ipField(func(ip *models.IP) interface{} {
+				if ip.Latitude == nil {
+					return nil
+				}
+				return *ip.Latitude
+			})},
+			"longitude": &graphql.Field{Type: graphql.Float, Resolve: ipField(func(ip *models.IP) interface{} {
+				if ip.Longitude == nil {
+					return nil
+				}
+				return *ip.Longitude
+			})},
+			"zipCode":  &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.ZipCode })},
+			"timeZone": &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.TimeZone })},
+			"lastSeen": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Days since the proxy was last seen",
+				Resolve: ipField(func(ip *models.IP) interface{} {
+					if ip.LastSeen == nil {
+						return nil
+					}
+					return *ip.LastSeen
+				}),
+			},
+			"threat":   &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.Threat })},
+			"provider": &graphql.Field{Type: graphql.String, Resolve: ipString(func(ip *models.IP) string { return ip.Provider })},
+		},
+	})
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"ip": &graphql.Field{
+				Type:        ipType,
+				Description: "Looks up an IPv4 address, null when it is not in the dataset. Requires the lookup scope",
+				Args: graphql.FieldConfigArgument{
+					"address": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolveIP,
+			},
+			"ips": &graphql.Field{
+				Type:        graphql.NewList(ipType),
+				Description: fmt.Sprintf("Looks up up to %d IPv4 addresses in one query. Requires the lookup scope", maxAddresses),
+				Args: graphql.FieldConfigArgument{
+					"addresses": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				},
+				Resolve: resolveIPs,
+			},
+			"country": &graphql.Field{
+				Type: countryType,
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return &country{Name: strings.Title(p.Args["name"].(string))}, nil
+				},
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func resolveIP(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p.Context, auth.ScopeLookup); err != nil {
+		return nil, err
+	}
+	return lookup(p.Context, p.Args["address"].(string))
+}
+
+func resolveIPs(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p.Context, auth.ScopeLookup); err != nil {
+		return nil, err
+	}
+	addresses := p.Args["addresses"].([]interface{})
+	if len(addresses) > maxAddresses {
+		return nil, fmt.Errorf("at most %d addresses per query", maxAddresses)
+	}
+	thunks := make([]func() (interface{}, error), 0, len(addresses))
+	for _, address := range addresses {
+		thunk, err := lookup(p.Context, address.(string))
+		if err != nil {
+			return nil, err
+		}
+		thunks = append(thunks, thunk)
+	}
+	return func() (interface{}, error) {
+		output := make([]interface{}, 0, len(thunks))
+		for _, thunk := range thunks {
+			ip, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, ip)
+		}
+		return output, nil
+	}, nil
+}
+
+// lookup queues address on the request loader, the thunk returned resolves
+// to the redacted model or nil when the address is not in the dataset.
+func lookup(ctx context.Context, address string) (func() (interface{}, error), error) {
+	if _, err := conversion.IPv4ToDecimal(address); err != nil {
+		return nil, err
+	}
+	load := loadersFrom(ctx).ips.load(address)
+	return func() (interface{}, error) {
+		ip, err := load()
+		if err != nil {
+			return nil, err
+		}
+		metrics.ObserveLookup(ip != nil)
+		if ip == nil {
+			return nil, nil
+		}
+		usage.AddAddresses(ctx, 1)
+		return models.ToIPModel(address, ip, auth.TierFromContext(ctx)), nil
+	}, nil
+}
+
+func resolveIPQuantity(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p.Context, auth.ScopeAggregate); err != nil {
+		return nil, err
+	}
+	c := p.Source.(*country)
+	countries := loadersFrom(p.Context).countries
+	return func() (interface{}, error) {
+		return countries.quantity(c.Name)
+	}, nil
+}
+
+func resolveTopISPs(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p.Context, auth.ScopeAggregate); err != nil {
+		return nil, err
+	}
+	if !auth.TierFromContext(p.Context).Allows(auth.FieldISP) {
+		return nil, errors.New("isp data is not included in your tier")
+	}
+	c := p.Source.(*country)
+	countries := loadersFrom(p.Context).countries
+	return func() (interface{}, error) {
+		names, err := countries.topISPs(c.Name)
+		if err != nil {
+			return nil, err
+		}
+		output := make([]*isp, 0)
+		for _, name := range names.([]string) {
+			output = append(output, &isp{Name: name})
+		}
+		return output, nil
+	}, nil
+}
+
+// require checks the scope of the principal Authentication stored.
+func require(ctx context.Context, scope string) error {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil {
+		return auth.ErrUnauthenticated
+	}
+	if !principal.HasScope(scope) {
+		return fmt.Errorf("%w: %s required", auth.ErrForbidden, scope)
+	}
+	return nil
+}
+
+func ipField(get func(*models.IP) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*models.IP)), nil
+	}
+}
+
+// ipString resolves empty strings, redacted or missing in the dataset, to null.
+func ipString(get func(*models.IP) string) graphql.FieldResolveFn {
+	return ipField(func(ip *models.IP) interface{} {
+		if value := get(ip); value != "" {
+			return value
+		}
+		return nil
+	})
+}
+
+func nullable(get func(*country) string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if value := get(p.Source.(*country)); value != "" {
+			return value, nil
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	ipsv1 "github.com/mborroni/dreamlab-challenge/api/ips/v1"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/rpc"
+	"github.com/mborroni/dreamlab-challenge/internal/application"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// newGRPCServer serves the gRPC API alongside the HTTP one, with the standard
+// health service and reflection so tools like grpcurl can discover it.
+func newGRPCServer(engine *application.Engine) *grpc.Server {
+	guard := rpc.NewGuard(engine.KeysService, engine.UsageService).WithTiers(engine.Tiers)
+	if engine.TokenValidator != nil {
+		guard.WithTokens(engine.TokenValidator)
+	}
+	if engine.Limiter != nil {
+		guard.WithLimiter(engine.Limiter)
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(guard.Unary()),
+		grpc.ChainStreamInterceptor(guard.Stream()),
+	)
+	ipsv1.RegisterIPAddressesServer(server, rpc.NewAddressesServer(engine.AddressesService))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	return server
+}
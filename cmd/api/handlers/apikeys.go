@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/apikeys"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+//go:generate mockgen -source=apikeys.go -destination=apikeys_mock.go -package=handlers
+
+type keysService interface {
+	Create(context.Context, string, []string, string) (*apikeys.Key, string, error)
+	List(context.Context) ([]*apikeys.Key, error)
+	Rotate(context.Context, int64) (*apikeys.Key, string, error)
+	Revoke(context.Context, int64) error
+}
+
+type APIKeysHandler struct {
+	service keysService
+}
+
+func NewAPIKeysHandler(service keysService) *APIKeysHandler {
+	return &APIKeysHandler{
+		service: service,
+	}
+}
+
+func (h *APIKeysHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input models.CreateAPIKey
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		_ = Respond(w, r, "invalid body", http.StatusBadRequest)
+		return
+	}
+	key, secret, err := h.service.Create(r.Context(), input.Name, input.Scopes, input.Tier)
+	if err != nil {
+		if errors.Is(err, apikeys.ErrMissingName) || errors.Is(err, apikeys.ErrInvalidScope) ||
+			errors.Is(err, apikeys.ErrInvalidTier) {
+			_ = Respond(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, apikeys.ErrReadOnly) {
+			_ = Respond(w, r, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		log.WithContext(r.Context()).
+			WithFields(log.Fields{"event": "create api key"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = Respond(w, r, models.ToAPIKeyModel(key, secret), http.StatusCreated)
+}
+
+func (h *APIKeysHandler) List(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.List(r.Context())
+	if err != nil {
+		log.WithContext(r.Context()).
+			WithFields(log.Fields{"event": "list api keys"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = Respond(w, r, models.ToAPIKeysModel(keys), http.StatusOK)
+}
+
+func (h *APIKeysHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "ID"), 10, 64)
+	if err != nil {
+		_ = Respond(w, r, "invalid id", http.StatusBadRequest)
+		return
+	}
+	key, secret, err := h.service.Rotate(r.Context(), id)
+	if err != nil {
+		if err == apikeys.ErrNotFound {
+			_ = Respond(w, r, nil, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, apikeys.ErrReadOnly) {
+			_ = Respond(w, r, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		log.WithContext(r.Context()).
+			WithFields(log.Fields{"event": "rotate api key"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = Respond(w, r, models.ToAPIKeyModel(key, secret), http.StatusOK)
+}
+
+func (h *APIKeysHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "ID"), 10, 64)
+	if err != nil {
+		_ = Respond(w, r, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.Revoke(r.Context(), id); err != nil {
+		if err == apikeys.ErrNotFound {
+			_ = Respond(w, r, nil, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, apikeys.ErrReadOnly) {
+			_ = Respond(w, r, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		log.WithContext(r.Context()).
+			WithFields(log.Fields{"event": "revoke api key"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = Respond(w, r, nil, http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: apikeys.go
+
+// Package handlers is a generated GoMock package.
+package handlers
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	apikeys "github.com/mborroni/dreamlab-challenge/internal/apikeys"
+	reflect "reflect"
+)
+
+// MockkeysService is a mock of keysService interface
+type MockkeysService struct {
+	ctrl     *gomock.Controller
+	recorder *MockkeysServiceMockRecorder
+}
+
+// MockkeysServiceMockRecorder is the mock recorder for MockkeysService
+type MockkeysServiceMockRecorder struct {
+	mock *MockkeysService
+}
+
+// NewMockkeysService creates a new mock instance
+func NewMockkeysService(ctrl *gomock.Controller) *MockkeysService {
+	mock := &MockkeysService{ctrl: ctrl}
+	mock.recorder = &MockkeysServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockkeysService) EXPECT() *MockkeysServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockkeysService) Create(arg0 context.Context, arg1 string, arg2 []string, arg3 string) (*apikeys.Key, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*apikeys.Key)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create
+func (mr *MockkeysServiceMockRecorder) Create(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockkeysService)(nil).Create), arg0, arg1, arg2, arg3)
+}
+
+// List mocks base method
+func (m *MockkeysService) List(arg0 context.Context) ([]*apikeys.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]*apikeys.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockkeysServiceMockRecorder) List(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockkeysService)(nil).List), arg0)
+}
+
+// Rotate mocks base method
+func (m *MockkeysService) Rotate(arg0 context.Context, arg1 int64) (*apikeys.Key, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", arg0, arg1)
+	ret0, _ := ret[0].(*apikeys.Key)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Rotate indicates an expected call of Rotate
+func (mr *MockkeysServiceMockRecorder) Rotate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockkeysService)(nil).Rotate), arg0, arg1)
+}
+
+// Revoke mocks base method
+func (m *MockkeysService) Revoke(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke
+func (mr *MockkeysServiceMockRecorder) Revoke(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockkeysService)(nil).Revoke), arg0, arg1)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/apikeys"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newMockAPIKeysHandler(ctrl *gomock.Controller) *APIKeysHandler {
+	return NewAPIKeysHandler(NewMockkeysService(ctrl))
+}
+
+func TestAPIKeysHandler_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAPIKeysHandler(ctrl)
+	createdAt := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	type fields struct {
+		body string
+	}
+
+	type want struct {
+		statusCode int
+		key        string
+	}
+
+	tests := []struct {
+		name         string
+		fields       fields
+		expectations func(fields fields)
+		want         want
+	}{
+		{
+			name:   "ok",
+			fields: fields{body: `{"name":"analytics","scopes":["lookup"],"tier":"premium"}`},
+			expectations: func(fields fields) {
+				handler.service.(*MockkeysService).
+					EXPECT().
+					Create(gomock.Any(), "analytics", []string{"lookup"}, "premium").
+					Return(&apikeys.Key{ID: 1, Name: "analytics", Prefix: "ipk_12345678",
+						Scopes: []string{"lookup"}, Tier: "premium", CreatedAt: createdAt}, "ipk_12345678secret", nil)
+			},
+			want: want{statusCode: http.StatusCreated, key: "ipk_12345678secret"},
+		},
+		{
+			name:         "invalid body",
+			fields:       fields{body: `{"name":`},
+			expectations: func(fields fields) {},
+			want:         want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name:   "invalid scope",
+			fields: fields{body: `{"name":"analytics","scopes":["root"]}`},
+			expectations: func(fields fields) {
+				handler.service.(*MockkeysService).
+					EXPECT().
+					Create(gomock.Any(), "analytics", []string{"root"}, "").
+					Return(nil, "", fmt.Errorf("%w: %q", apikeys.ErrInvalidScope, "root"))
+			},
+			want: want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name:   "invalid tier",
+			fields: fields{body: `{"name":"analytics","scopes":["lookup"],"tier":"ful"}`},
+			expectations: func(fields fields) {
+				handler.service.(*MockkeysService).
+					EXPECT().
+					Create(gomock.Any(), "analytics", []string{"lookup"}, "ful").
+					Return(nil, "", fmt.Errorf("%w: %q", apikeys.ErrInvalidTier, "ful"))
+			},
+			want: want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name:   "read only",
+			fields: fields{body: `{"name":"analytics","scopes":["lookup"]}`},
+			expectations: func(fields fields) {
+				handler.service.(*MockkeysService).
+					EXPECT().
+					Create(gomock.Any(), "analytics", []string{"lookup"}, "").
+					Return(nil, "", apikeys.ErrReadOnly)
+			},
+			want: want{statusCode: http.StatusNotImplemented},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations(tc.fields)
+			app := chi.NewRouter()
+
+			app.Post("/v1/admin/keys", handler.Create)
+			r := httptest.NewRequest(http.MethodPost, "/v1/admin/keys", strings.NewReader(tc.fields.body))
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.want.statusCode, w.Code)
+			if tc.want.key != "" {
+				var output models.APIKey
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.want.key, output.Key)
+			}
+		})
+	}
+}
+
+func TestAPIKeysHandler_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAPIKeysHandler(ctrl)
+
+	type want struct {
+		statusCode int
+	}
+
+	tests := []struct {
+		name         string
+		id           string
+		expectations func()
+		want         want
+	}{
+		{
+			name: "ok",
+			id:   "1",
+			expectations: func() {
+				handler.service.(*MockkeysService).EXPECT().Revoke(gomock.Any(), int64(1)).Return(nil)
+			},
+			want: want{statusCode: http.StatusNoContent},
+		},
+		{
+			name: "not found",
+			id:   "2",
+			expectations: func() {
+				handler.service.(*MockkeysService).EXPECT().Revoke(gomock.Any(), int64(2)).Return(apikeys.ErrNotFound)
+			},
+			want: want{statusCode: http.StatusNotFound},
+		},
+		{
+			name:         "invalid id",
+			id:           "abc",
+			expectations: func() {},
+			want:         want{statusCode: http.StatusBadRequest},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+
+			app.Delete("/v1/admin/keys/{ID}", handler.Revoke)
+			r := httptest.NewRequest(http.MethodDelete, "/v1/admin/keys/"+tc.id, nil)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.want.statusCode, w.Code)
+		})
+	}
+}
+
+func TestAPIKeysHandler_Rotate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAPIKeysHandler(ctrl)
+	handler.service.(*MockkeysService).
+		EXPECT().
+		Rotate(gomock.Any(), int64(1)).
+		Return(&apikeys.Key{ID: 1, Name: "analytics"}, "ipk_newsecret", nil)
+
+	app := chi.NewRouter()
+	app.Post("/v1/admin/keys/{ID}/rotate", handler.Rotate)
+	r := httptest.NewRequest(http.MethodPost, "/v1/admin/keys/1/rotate", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	var output models.APIKey
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ipk_newsecret", output.Key)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+//go:generate mockgen -source=countries.go -destination=countries_mock.go -package=handlers
+
+// countryCode accepts ISO 3166-1 alpha-2 codes regardless of case.
+var countryCode = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+type countriesService interface {
+	ListCountries(context.Context) ([]*ips.CountrySummary, error)
+	GetCountry(context.Context, string) (*ips.CountrySummary, error)
+	ListRegions(context.Context, string) ([]*ips.RegionSummary, error)
+	ListCities(context.Context, string, string) ([]*ips.CityTotal, error)
+	GetCity(context.Context, string, string, string, int) (*ips.City, error)
+}
+
+type CountriesHandler struct {
+	service countriesService
+}
+
+func NewCountriesHandler(service countriesService) *CountriesHandler {
+	return &CountriesHandler{
+		service: service,
+	}
+}
+
+// List returns every country of the dataset by name, with the spelling the
+// country filters expect.
+func (h *CountriesHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "CountriesHandler.List")
+	defer span.End()
+
+	countries, err := h.service.ListCountries(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "list countries"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = Respond(w, r, models.ToCountrySummariesModel(countries), http.StatusOK)
+}
+
+func (h *CountriesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "CountriesHandler.Get")
+	defer span.End()
+
+	code := chi.URLParam(r, "code")
+	if !countryCode.MatchString(code) {
+		_ = Respond(w, r, "invalid country code, expected ISO 3166-1 alpha-2", http.StatusBadRequest)
+		return
+	}
+	country, err := h.service.GetCountry(ctx, strings.ToUpper(code))
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "get country"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if country == nil {
+		_ = Respond(w, r, nil, http.StatusNotFound)
+		return
+	}
+	_ = Respond(w, r, models.ToCountrySummaryModel(country), http.StatusOK)
+}
+
+// ListRegions summarizes the regions of a country, it needs the region field
+// of the tier.
+func (h *CountriesHandler) ListRegions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "CountriesHandler.ListRegions")
+	defer span.End()
+
+	if !auth.TierFromContext(ctx).Allows(auth.FieldRegion) {
+		_ = Respond(w, r, "region data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	code := chi.URLParam(r, "code")
+	if !countryCode.MatchString(code) {
+		_ = Respond(w, r, "invalid country code, expected ISO 3166-1 alpha-2", http.StatusBadRequest)
+		return
+	}
+	regions, err := h.service.ListRegions(ctx, strings.ToUpper(code))
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "list regions"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if regions == nil {
+		_ = Respond(w, r, nil, http.StatusNotFound)
+		return
+	}
+	_ = Respond(w, r, models.ToRegionSummariesModel(regions), http.StatusOK)
+}
+
+// ListCities summarizes the cities of a region, it needs the region and city
+// fields of the tier.
+func (h *CountriesHandler) ListCities(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "CountriesHandler.ListCities")
+	defer span.End()
+
+	tier := auth.TierFromContext(ctx)
+	if !tier.Allows(auth.FieldRegion) || !tier.Allows(auth.FieldCity) {
+		_ = Respond(w, r, "city data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	code := chi.URLParam(r, "code")
+	if !countryCode.MatchString(code) {
+		_ = Respond(w, r, "invalid country code, expected ISO 3166-1 alpha-2", http.StatusBadRequest)
+		return
+	}
+	cities, err := h.service.ListCities(ctx, strings.ToUpper(code), pathParam(r, "region"))
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "list cities"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if cities == nil {
+		_ = Respond(w, r, nil, http.StatusNotFound)
+		return
+	}
+	_ = Respond(w, r, models.ToCityTotalsModel(cities), http.StatusOK)
+}
+
+// GetCity lists the ranges of a city in ascending order along with its
+// totals, it needs the region and city fields of the tier.
+func (h *CountriesHandler) GetCity(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "CountriesHandler.GetCity")
+	defer span.End()
+
+	tier := auth.TierFromContext(ctx)
+	if !tier.Allows(auth.FieldRegion) || !tier.Allows(auth.FieldCity) {
+		_ = Respond(w, r, "city data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	code := chi.URLParam(r, "code")
+	if !countryCode.MatchString(code) {
+		_ = Respond(w, r, "invalid country code, expected ISO 3166-1 alpha-2", http.StatusBadRequest)
+		return
+	}
+	limit := obtainLimit(r.URL)
+	if limit < 1 || limit > maxRangesLimit {
+		_ = Respond(w, r, fmt.Sprintf("limit must be between 1 and %d", maxRangesLimit), http.StatusBadRequest)
+		return
+	}
+	city, err := h.service.GetCity(ctx, strings.ToUpper(code), pathParam(r, "region"), pathParam(r, "city"), limit)
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "get city"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if city == nil {
+		_ = Respond(w, r, nil, http.StatusNotFound)
+		return
+	}
+	output := models.ToCityModel(city, tier)
+	usage.AddRows(ctx, len(output.IPs))
+	_ = Respond(w, r, output, http.StatusOK)
+}
+
+// pathParam decodes a URL parameter, chi leaves it escaped when the path has
+// characters like an encoded slash.
+func pathParam(r *http.Request, key string) string {
+	value := chi.URLParam(r, key)
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: countries.go
+
+// Package handlers is a generated GoMock package.
+package handlers
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	reflect "reflect"
+)
+
+// MockcountriesService is a mock of countriesService interface
+type MockcountriesService struct {
+	ctrl     *gomock.Controller
+	recorder *MockcountriesServiceMockRecorder
+}
+
+// MockcountriesServiceMockRecorder is the mock recorder for MockcountriesService
+type MockcountriesServiceMockRecorder struct {
+	mock *MockcountriesService
+}
+
+// NewMockcountriesService creates a new mock instance
+func NewMockcountriesService(ctrl *gomock.Controller) *MockcountriesService {
+	mock := &MockcountriesService{ctrl: ctrl}
+	mock.recorder = &MockcountriesServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockcountriesService) EXPECT() *MockcountriesServiceMockRecorder {
+	return m.recorder
+}
+
+// ListCountries mocks base method
+func (m *MockcountriesService) ListCountries(arg0 context.Context) ([]*ips.CountrySummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCountries", arg0)
+	ret0, _ := ret[0].([]*ips.CountrySummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCountries indicates an expected call of ListCountries
+func (mr *MockcountriesServiceMockRecorder) ListCountries(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCountries", reflect.TypeOf((*MockcountriesService)(nil).ListCountries), arg0)
+}
+
+// GetCountry mocks base method
+func (m *MockcountriesService) GetCountry(arg0 context.Context, arg1 string) (*ips.CountrySummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCountry", arg0, arg1)
+	ret0, _ := ret[0].(*ips.CountrySummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCountry indicates an expected call of GetCountry
+func (mr *MockcountriesServiceMockRecorder) GetCountry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCountry", reflect.TypeOf((*MockcountriesService)(nil).GetCountry), arg0, arg1)
+}
+
+// ListRegions mocks base method
+func (m *MockcountriesService) ListRegions(arg0 context.Context, arg1 string) ([]*ips.RegionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRegions", arg0, arg1)
+	ret0, _ := ret[0].([]*ips.RegionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRegions indicates an expected call of ListRegions
+func (mr *MockcountriesServiceMockRecorder) ListRegions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRegions", reflect.TypeOf((*MockcountriesService)(nil).ListRegions), arg0, arg1)
+}
+
+// ListCities mocks base method
+func (m *MockcountriesService) ListCities(arg0 context.Context, arg1, arg2 string) ([]*ips.CityTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCities", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*ips.CityTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCities indicates an expected call of ListCities
+func (mr *MockcountriesServiceMockRecorder) ListCities(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCities", reflect.TypeOf((*MockcountriesService)(nil).ListCities), arg0, arg1, arg2)
+}
+
+// GetCity mocks base method
+func (m *MockcountriesService) GetCity(arg0 context.Context, arg1, arg2, arg3 string, arg4 int) (*ips.City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCity", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*ips.City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCity indicates an expected call of GetCity
+func (mr *MockcountriesServiceMockRecorder) GetCity(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCity", reflect.TypeOf((*MockcountriesService)(nil).GetCity), arg0, arg1, arg2, arg3, arg4)
+}
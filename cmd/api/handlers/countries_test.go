@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var argentinaSummary = &ips.CountrySummary{
+	Country:   ips.Country{Code: "AR", Name: "Argentina"},
+	Ranges:    1200,
+	Addresses: 4000,
+	Regions:   24,
+	Cities:    310,
+}
+
+func newMockCountriesHandler(ctrl *gomock.Controller) *CountriesHandler {
+	return NewCountriesHandler(NewMockcountriesService(ctrl))
+}
+
+func TestCountriesHandler_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockCountriesHandler(ctrl)
+	handler.service.(*MockcountriesService).
+		EXPECT().
+		ListCountries(gomock.Any()).
+		Return([]*ips.CountrySummary{argentinaSummary}, nil)
+
+	app := chi.NewRouter()
+	app.Get("/v1/countries", handler.List)
+	r := httptest.NewRequest(http.MethodGet, "/v1/countries", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	var output []*models.CountrySummary
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []*models.CountrySummary{
+		{Code: "AR", Name: "Argentina", Ranges: 1200, Addresses: 4000, Regions: 24, Cities: 310},
+	}, output)
+}
+
+func TestCountriesHandler_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockCountriesHandler(ctrl)
+
+	type want struct {
+		statusCode int
+		country    *models.CountrySummary
+	}
+
+	tests := []struct {
+		name         string
+		code         string
+		expectations func()
+		want         want
+	}{
+		{
+			name: "ok",
+			code: "ar",
+			expectations: func() {
+				handler.service.(*MockcountriesService).EXPECT().GetCountry(gomock.Any(), "AR").Return(argentinaSummary, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				country:    &models.CountrySummary{Code: "AR", Name: "Argentina", Ranges: 1200, Addresses: 4000, Regions: 24, Cities: 310},
+			},
+		},
+		{
+			name: "not found",
+			code: "ZZ",
+			expectations: func() {
+				handler.service.(*MockcountriesService).EXPECT().GetCountry(gomock.Any(), "ZZ").Return(nil, nil)
+			},
+			want: want{statusCode: http.StatusNotFound},
+		},
+		{
+			name:         "invalid code",
+			code:         "ARG",
+			expectations: func() {},
+			want:         want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name: "service error",
+			code: "AR",
+			expectations: func() {
+				handler.service.(*MockcountriesService).EXPECT().GetCountry(gomock.Any(), "AR").Return(nil, errors.New("connection refused"))
+			},
+			want: want{statusCode: http.StatusInternalServerError},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+
+			app.Get("/v1/countries/{code}", handler.Get)
+			r := httptest.NewRequest(http.MethodGet, "/v1/countries/"+tc.code, nil)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.want.statusCode, w.Code)
+			if tc.want.country != nil {
+				var output models.CountrySummary
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.want.country, &output)
+			}
+		})
+	}
+}
+
+func TestCountriesHandler_ListRegions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockCountriesHandler(ctrl)
+
+	tests := []struct {
+		name         string
+		path         string
+		tier         *auth.Tier
+		expectations func()
+		statusCode   int
+		output       []*models.RegionSummary
+	}{
+		{
+			name: "ok",
+			path: "/v1/countries/ar/regions",
+			expectations: func() {
+				handler.service.(*MockcountriesService).
+					EXPECT().
+					ListRegions(gomock.Any(), "AR").
+					Return([]*ips.RegionSummary{{Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Cordoba"},
+						Ranges: 80, Addresses: 500, Cities: 12}}, nil)
+			},
+			statusCode: http.StatusOK,
+			output: []*models.RegionSummary{{Country: models.Country{Code: "AR", Name: "Argentina", Region: "Cordoba"},
+				Ranges: 80, Addresses: 500, Cities: 12}},
+		},
+		{
+			name: "not found",
+			path: "/v1/countries/ZZ/regions",
+			expectations: func() {
+				handler.service.(*MockcountriesService).EXPECT().ListRegions(gomock.Any(), "ZZ").Return(nil, nil)
+			},
+			statusCode: http.StatusNotFound,
+		},
+		{name: "invalid code", path: "/v1/countries/ARG/regions", expectations: func() {},
+			statusCode: http.StatusBadRequest},
+		{name: "forbidden", path: "/v1/countries/AR/regions", expectations: func() {},
+			tier: mustTier("country"), statusCode: http.StatusForbidden},
+		{
+			name: "product without regions",
+			path: "/v1/countries/AR/regions",
+			expectations: func() {
+				handler.service.(*MockcountriesService).
+					EXPECT().
+					ListRegions(gomock.Any(), "AR").
+					Return(nil, &ips.ColumnError{Product: "PX2", Column: ips.ColumnRegion})
+			},
+			statusCode: http.StatusNotImplemented,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+			app.Get("/v1/countries/{code}/regions", handler.ListRegions)
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.tier != nil {
+				r = r.WithContext(auth.WithTier(r.Context(), tc.tier))
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+			if tc.output != nil {
+				var output []*models.RegionSummary
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.output, output)
+			}
+		})
+	}
+}
+
+func TestCountriesHandler_ListCities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockCountriesHandler(ctrl)
+	handler.service.(*MockcountriesService).
+		EXPECT().
+		ListCities(gomock.Any(), "AR", "Santa Fe").
+		Return([]*ips.CityTotal{{Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Santa Fe",
+			City: "Rosario"}, Ranges: 2, Addresses: 20}}, nil)
+
+	app := chi.NewRouter()
+	app.Get("/v1/countries/{code}/regions/{region}/cities", handler.ListCities)
+	r := httptest.NewRequest(http.MethodGet, "/v1/countries/AR/regions/Santa%20Fe/cities", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	var output []*models.CityTotal
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []*models.CityTotal{{Country: models.Country{Code: "AR", Name: "Argentina",
+		Region: "Santa Fe", City: "Rosario"}, Ranges: 2, Addresses: 20}}, output)
+
+	r = httptest.NewRequest(http.MethodGet, "/v1/countries/AR/regions/Santa%20Fe/cities", nil)
+	r = r.WithContext(auth.WithTier(r.Context(), mustTier("region", auth.FieldRegion)))
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCountriesHandler_GetCity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockCountriesHandler(ctrl)
+	rosario := ips.Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"}
+
+	tests := []struct {
+		name         string
+		path         string
+		expectations func()
+		statusCode   int
+		output       *models.City
+	}{
+		{
+			name: "ok",
+			path: "/v1/countries/AR/regions/Santa%20Fe/cities/Rosario/ips?limit=1",
+			expectations: func() {
+				handler.service.(*MockcountriesService).
+					EXPECT().
+					GetCity(gomock.Any(), "AR", "Santa Fe", "Rosario", 1).
+					Return(&ips.City{
+						Total:  ips.CityTotal{Country: rosario, Ranges: 2, Addresses: 20},
+						Ranges: []*ips.IP{{From: 10, To: 19, Country: rosario}},
+					}, nil)
+			},
+			statusCode: http.StatusOK,
+			output: &models.City{
+				Country:   models.Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"},
+				Ranges:    2,
+				Addresses: 20,
+				IPs: []*models.IP{{IP: "0.0.0.10", From: 10, To: 19,
+					Country: models.Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"}}},
+			},
+		},
+		{
+			name: "not found",
+			path: "/v1/countries/AR/regions/Santa%20Fe/cities/Atlantis/ips",
+			expectations: func() {
+				handler.service.(*MockcountriesService).
+					EXPECT().
+					GetCity(gomock.Any(), "AR", "Santa Fe", "Atlantis", 100).
+					Return(nil, nil)
+			},
+			statusCode: http.StatusNotFound,
+		},
+		{name: "invalid limit", path: "/v1/countries/AR/regions/Santa%20Fe/cities/Rosario/ips?limit=0",
+			expectations: func() {}, statusCode: http.StatusBadRequest},
+		{
+			name: "service error",
+			path: "/v1/countries/AR/regions/Santa%20Fe/cities/Rosario/ips",
+			expectations: func() {
+				handler.service.(*MockcountriesService).
+					EXPECT().
+					GetCity(gomock.Any(), "AR", "Santa Fe", "Rosario", 100).
+					Return(nil, errors.New("connection refused"))
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+			app.Get("/v1/countries/{code}/regions/{region}/cities/{city}/ips", handler.GetCity)
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+			if tc.output != nil {
+				var output *models.City
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.output, output)
+			}
+		})
+	}
+}
+
+func TestCountriesHandler_ListCSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockCountriesHandler(ctrl)
+	handler.service.(*MockcountriesService).
+		EXPECT().
+		ListCountries(gomock.Any()).
+		Return([]*ips.CountrySummary{argentinaSummary}, nil)
+
+	app := chi.NewRouter()
+	app.Get("/v1/countries", handler.List)
+	r := httptest.NewRequest(http.MethodGet, "/v1/countries", nil)
+	r.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, "code,name,ranges,addresses,regions,cities\nAR,Argentina,1200,4000,24,310\n", w.Body.String())
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned by Negotiate when the client accepts none of
+// the formats responses are encoded in.
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// Format encodes responses in one media type.
+type Format struct {
+	// Name is the value of the format query parameter choosing it.
+	Name        string
+	ContentType string
+	// aliases are other media types the format is requested with.
+	aliases []string
+	// encode writes v.
+	encode func(io.Writer, interface{}) error
+}
+
+// Formats responses are encoded in, JSON is the default.
+var (
+	FormatJSON    = &Format{Name: "json", ContentType: "application/json", encode: encodeJSON}
+	FormatCSV     = &Format{Name: "csv", ContentType: "text/csv", encode: encodeCSV}
+	FormatXML     = &Format{Name: "xml", ContentType: "application/xml", aliases: []string{"text/xml"}, encode: encodeXML}
+	FormatMsgPack = &Format{Name: "msgpack", ContentType: "application/msgpack",
+		aliases: []string{"application/x-msgpack", "application/vnd.msgpack"}, encode: encodeMsgPack}
+)
+
+var formats = []*Format{FormatJSON, FormatCSV, FormatXML, FormatMsgPack}
+
+// Tabular is implemented by responses holding a list next to its totals, the
+// list is what their CSV rows are made of.
+type Tabular interface {
+	Rows() interface{}
+}
+
+func (f *Format) matches(mediaRange string) bool {
+	if mediaRange == "*/*" {
+		return true
+	}
+	for _, contentType := range append([]string{f.ContentType}, f.aliases...) {
+		if mediaRange == contentType {
+			return true
+		}
+		if strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate picks the format of the response to r. The format query
+// parameter overrides the Accept header, whose media ranges are tried by
+// quality and then in order. Requests accepting anything get JSON.
+func Negotiate(r *http.Request) (*Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		for _, format := range formats {
+			if strings.EqualFold(format.Name, name) {
+				return format, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: unknown format %q", ErrNotAcceptable, name)
+	}
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, nil
+	}
+	type mediaRange struct {
+		value   string
+		quality float64
+	}
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		value, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{value: value, quality: quality})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+	for _, mediaRange := range ranges {
+		for _, format := range formats {
+			if format.matches(mediaRange.value) {
+				return format, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotAcceptable, accept)
+}
+
+// NotAcceptable answers requests Negotiate fails for with the formats
+// available.
+func NotAcceptable(w http.ResponseWriter, err error) {
+	available := make([]string, 0, len(formats))
+	for _, format := range formats {
+		available = append(available, format.ContentType)
+	}
+	_ = RespondJSON(w, fmt.Sprintf("%s, available formats are %s", err, strings.Join(available, ", ")),
+		http.StatusNotAcceptable)
+}
+
+// Respond encodes v in the format r negotiates. JSON responses are written
+// as RespondJSON does. If v can't be encoded the error is logged and the
+// client gets a 500.
+func Respond(w http.ResponseWriter, r *http.Request, v interface{}, code int) error {
+	format, err := Negotiate(r)
+	if err != nil {
+		NotAcceptable(w, err)
+		return err
+	}
+	w.Header().Add("Vary", "Accept")
+	if code == http.StatusNoContent || v == nil {
+		return RespondJSON(w, v, code)
+	}
+	var buffer bytes.Buffer
+	if err := format.encode(&buffer, v); err != nil {
+		log.WithContext(r.Context()).
+			WithFields(log.Fields{"event": "encode response", "format": format.Name}).
+			Error(err)
+		_ = RespondJSON(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Type", format.ContentType)
+	w.WriteHeader(code)
+	_, err = w.Write(buffer.Bytes())
+	return err
+}
+
+// encodeJSON writes []byte and io.Reader values as they are, they already
+// hold JSON.
+func encodeJSON(w io.Writer, v interface{}) error {
+	switch v := v.(type) {
+	case []byte:
+		_, err := w.Write(v)
+		return err
+	case io.Reader:
+		_, err := io.Copy(w, v)
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// encodeMsgPack names the fields as JSON does.
+func encodeMsgPack(w io.Writer, v interface{}) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(v)
+}
+
+// member is a field of a JSON object, objects are decoded as lists of them to
+// keep the order of the fields.
+type member struct {
+	key   string
+	value interface{}
+}
+
+// tree decodes the JSON form of v into nested []member, []interface{} and
+// scalars, so CSV and XML follow the field names and omissions of JSON.
+func tree(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeValue(decoder)
+}
+
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := make([]member, 0)
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, member{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := make([]interface{}, 0)
+		for decoder.More() {
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+// scalar formats a JSON scalar as text, null is empty.
+func scalar(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// encodeCSV writes a row per element of a list, or a single row otherwise.
+// Nested objects are flattened into dotted columns like country.code and
+// nested lists are left as JSON.
+func encodeCSV(w io.Writer, v interface{}) error {
+	if tabular, ok := v.(Tabular); ok {
+		v = tabular.Rows()
+	}
+	root, err := tree(v)
+	if err != nil {
+		return err
+	}
+	elements, ok := root.([]interface{})
+	if !ok {
+		elements = []interface{}{root}
+	}
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	rows := make([]map[string]string, 0, len(elements))
+	for _, element := range elements {
+		row := make(map[string]string)
+		filled, err := flatten("", element, row)
+		if err != nil {
+			return err
+		}
+		for _, column := range filled {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+		rows = append(rows, row)
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// flatten fills row with the cells of value and returns their columns in
+// order, rows may lack the fields JSON omits.
+func flatten(prefix string, value interface{}, row map[string]string) ([]string, error) {
+	switch value := value.(type) {
+	case []member:
+		columns := make([]string, 0, len(value))
+		for _, field := range value {
+			filled, err := flatten(join(prefix, field.key), field.value, row)
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, filled...)
+		}
+		return columns, nil
+	case []interface{}:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		row[columnName(prefix)] = string(data)
+	default:
+		row[columnName(prefix)] = scalar(value)
+	}
+	return []string{columnName(prefix)}, nil
+}
+
+func join(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// columnName names the column of a scalar, a bare one is a value.
+func columnName(prefix string) string {
+	if prefix == "" {
+		return "value"
+	}
+	return prefix
+}
+
+// encodeXML wraps v in a response element. Fields are elements named as in
+// JSON and list elements are item elements.
+func encodeXML(w io.Writer, v interface{}) error {
+	root, err := tree(v)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	if err := encodeElement(encoder, "response", root); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+func encodeElement(encoder *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case []member:
+		for _, field := range value {
+			if err := encodeElement(encoder, field.key, field.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if err := encodeElement(encoder, "item", item); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := encoder.EncodeToken(xml.CharData(scalar(value))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   *Format
+	}{
+		{name: "no accept", target: "/", want: FormatJSON},
+		{name: "anything", target: "/", accept: "*/*", want: FormatJSON},
+		{name: "csv", target: "/", accept: "text/csv", want: FormatCSV},
+		{name: "text range", target: "/", accept: "text/*", want: FormatCSV},
+		{name: "xml alias", target: "/", accept: "text/xml", want: FormatXML},
+		{name: "msgpack", target: "/", accept: "application/x-msgpack", want: FormatMsgPack},
+		{name: "by quality", target: "/", accept: "application/json;q=0.5, application/xml", want: FormatXML},
+		{name: "in order", target: "/", accept: "text/html, application/msgpack, application/json", want: FormatMsgPack},
+		{name: "excluded", target: "/", accept: "text/csv;q=0, */*;q=0.1", want: FormatJSON},
+		{name: "query override", target: "/?format=CSV", accept: "application/json", want: FormatCSV},
+		{name: "unsupported", target: "/", accept: "text/html, image/png"},
+		{name: "unknown format", target: "/?format=yaml"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			format, err := Negotiate(r)
+			if tc.want == nil {
+				assert.ErrorIs(t, err, ErrNotAcceptable)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, format)
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	sample := &models.Sample{Seed: 42, IPs: []*models.IP{
+		{IP: "128.65.194.136", Country: models.Country{Code: "CH", Name: "Switzerland", City: "Carouge"}},
+		{IP: "128.65.195.22", Usage: "DCH", Country: models.Country{Code: "CH", Name: "Switzerland"}},
+	}}
+
+	tests := []struct {
+		name        string
+		format      string
+		value       interface{}
+		contentType string
+		body        string
+	}{
+		{
+			name:        "json",
+			format:      "json",
+			value:       sample,
+			contentType: "application/json",
+			body: `{"seed":42,"ips":[{"ip":"128.65.194.136","country":{"code":"CH","name":"Switzerland","city":"Carouge"}},` +
+				`{"ip":"128.65.195.22","country":{"code":"CH","name":"Switzerland"},"usage":"DCH"}]}`,
+		},
+		{
+			name:        "csv rows",
+			format:      "csv",
+			value:       sample,
+			contentType: "text/csv",
+			body: "ip,country.code,country.name,country.city,usage\n" +
+				"128.65.194.136,CH,Switzerland,Carouge,\n" +
+				"128.65.195.22,CH,Switzerland,,DCH\n",
+		},
+		{
+			name:        "csv object",
+			format:      "csv",
+			value:       &models.CountrySummary{Code: "CH", Name: "Switzerland", Ranges: 2, Addresses: 10},
+			contentType: "text/csv",
+			body:        "code,name,ranges,addresses,regions,cities\nCH,Switzerland,2,10,0,0\n",
+		},
+		{
+			name:        "csv values",
+			format:      "csv",
+			value:       []string{"Swisscom AG", "Sunrise, UPC"},
+			contentType: "text/csv",
+			body:        "value\nSwisscom AG\n\"Sunrise, UPC\"\n",
+		},
+		{
+			name:        "xml",
+			format:      "xml",
+			value:       sample,
+			contentType: "application/xml",
+			body: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<response><seed>42</seed><ips>` +
+				`<item><ip>128.65.194.136</ip><country><code>CH</code><name>Switzerland</name><city>Carouge</city></country></item>` +
+				`<item><ip>128.65.195.22</ip><country><code>CH</code><name>Switzerland</name></country><usage>DCH</usage></item>` +
+				`</ips></response>`,
+		},
+		{
+			name:        "xml message",
+			format:      "xml",
+			value:       "n must be a number",
+			contentType: "application/xml",
+			body:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response>n must be a number</response>`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?format="+tc.format, nil)
+			w := httptest.NewRecorder()
+
+			assert.NoError(t, Respond(w, r, tc.value, http.StatusOK))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+			assert.Equal(t, tc.body, w.Body.String())
+		})
+	}
+}
+
+func TestRespond_MsgPack(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+
+	assert.NoError(t, Respond(w, r, &models.Sample{Seed: 42, IPs: []*models.IP{{IP: "128.65.194.136"}}}, http.StatusOK))
+
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	var output map[string]interface{}
+	assert.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &output))
+	assert.Equal(t, map[string]interface{}{
+		"seed": int64(42),
+		"ips":  []interface{}{map[string]interface{}{"ip": "128.65.194.136", "country": map[string]interface{}{}}},
+	}, output)
+}
+
+func TestRespond_NotAcceptable(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+
+	assert.ErrorIs(t, Respond(w, r, "ok", http.StatusOK), ErrNotAcceptable)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, `"not acceptable: text/html, available formats are application/json, text/csv, `+
+		`application/xml, application/msgpack"`, w.Body.String())
+}
+
+func TestRespond_EncodeError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?format=csv", nil)
+	w := httptest.NewRecorder()
+
+	assert.Error(t, Respond(w, r, map[string]interface{}{"ch": make(chan int)}, http.StatusOK))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `"Internal Server Error"`, w.Body.String())
+}
+
+func TestRespond_EncodeJSONError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	assert.Error(t, Respond(w, r, map[string]interface{}{"ch": make(chan int)}, http.StatusOK))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `"Internal Server Error"`, w.Body.String())
+}
@@ -1,27 +1,65 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
 	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/metrics"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
 	log "github.com/sirupsen/logrus"
-	"io"
-	"io/ioutil"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
 //go:generate mockgen -source=handlers.go -destination=handlers_mock.go -package=handlers
 
+var tracer = tracing.Tracer("github.com/mborroni/dreamlab-challenge/cmd/api/handlers")
+
+// Bounds of the searches returning ranges, a wider circle or a longer page
+// would read most of the table.
+const (
+	maxRadiusKm    = 1000
+	maxRangesLimit = 1000
+)
+
+// Bounds of an ISP search, trigram matching needs at least three characters.
+const (
+	minISPQuery     = 3
+	maxISPQuery     = 100
+	maxISPLimit     = 100
+	defaultISPLimit = 10
+)
+
+// Bounds of a random sample, the addresses are drawn one by one.
+const (
+	maxSampleSize     = 1000
+	defaultSampleSize = 10
+)
+
+// domainFormat accepts lowercase host names, labels of letters, digits and
+// hyphens separated by dots.
+var domainFormat = regexp.MustCompile(`^([a-z0-9-]+\.)*[a-z0-9-]+$`)
+
 type service interface {
 	List(context.Context, int, map[string]interface{}) ([]*ips.IP, error)
 	Get(context.Context, string) (*ips.IP, error)
 	GetTop10ISPByCountry(context.Context, string) ([]string, error)
 	GetIPQuantityByCountry(context.Context, string) (int, error)
+	Near(context.Context, ips.Circle, int) (*ips.Nearby, error)
+	GetByDomain(context.Context, string, int) (*ips.Domain, error)
+	SearchISPs(context.Context, string, string, int) ([]*ips.ISPMatch, error)
+	Sample(context.Context, int, int64, map[string]interface{}) ([]*ips.IP, error)
 }
 
 type AddressesHandler struct {
@@ -35,75 +73,293 @@ func NewAddressesHandler(service service) *AddressesHandler {
 }
 
 func (h *AddressesHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.List")
+	defer span.End()
+
 	limit := obtainLimit(r.URL)
 	filters := obtainFilters(r.URL)
-	ipAddresses, err := h.service.List(r.Context(), limit, filters)
+	ipAddresses, err := h.service.List(ctx, limit, filters)
 	if err != nil {
-		log.WithContext(r.Context()).
+		log.WithContext(ctx).
 			WithFields(log.Fields{"event": "error listing"}).
 			Error(err)
-		_ = RespondJSON(w, err.Error(), http.StatusInternalServerError)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	if len(ipAddresses) == 0 {
-		_ = RespondJSON(w, nil, http.StatusNoContent)
+		_ = Respond(w, r, nil, http.StatusNoContent)
 		return
 	}
-	_ = RespondJSON(w, models.ToIPsModel(ipAddresses), http.StatusOK)
+	output := models.ToIPsModel(ipAddresses, auth.TierFromContext(ctx))
+	usage.AddRows(ctx, len(output))
+	_ = Respond(w, r, output, http.StatusOK)
 	return
 }
 
 func (h *AddressesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.Get")
+	defer span.End()
+
 	input := chi.URLParam(r, "IP")
-	ip, err := h.service.Get(r.Context(), input)
+	ip, err := h.service.Get(ctx, input)
 	if err != nil {
-		log.WithContext(r.Context()).
+		log.WithContext(ctx).
 			WithFields(log.Fields{"event": "get ip address"}).
 			Error(err)
-		_ = RespondJSON(w, err, http.StatusInternalServerError)
+		_ = Respond(w, r, err, http.StatusInternalServerError)
 		return
 	}
+	metrics.ObserveLookup(ip != nil)
 	if ip == nil {
-		_ = RespondJSON(w, nil, http.StatusNotFound)
+		_ = Respond(w, r, nil, http.StatusNotFound)
 		return
 	}
-	_ = RespondJSON(w, models.ToIPModel(input, ip), http.StatusOK)
+	usage.AddAddresses(ctx, 1)
+	_ = Respond(w, r, models.ToIPModel(input, ip, auth.TierFromContext(ctx)), http.StatusOK)
 	return
 }
 
 func (h *AddressesHandler) GetTop10ISPByCountry(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.GetTop10ISPByCountry")
+	defer span.End()
+
+	if !auth.TierFromContext(ctx).Allows(auth.FieldISP) {
+		_ = Respond(w, r, "isp data is not included in your tier", http.StatusForbidden)
+		return
+	}
 	country := r.URL.Query().Get("country")
 	if country == "" {
-		_ = RespondJSON(w, "missing country", http.StatusBadRequest)
+		_ = Respond(w, r, "missing country", http.StatusBadRequest)
+		return
+	}
+	isps, err := h.service.GetTop10ISPByCountry(ctx, strings.Title(country))
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
 		return
 	}
-	isps, err := h.service.GetTop10ISPByCountry(r.Context(), strings.Title(country))
 	if err != nil {
-		log.WithContext(r.Context()).
+		log.WithContext(ctx).
 			WithFields(log.Fields{"event": "get top 10 ISPs"}).
 			Error(err)
-		_ = RespondJSON(w, err, http.StatusInternalServerError)
+		_ = Respond(w, r, err, http.StatusInternalServerError)
 		return
 	}
-	_ = RespondJSON(w, isps, http.StatusOK)
+	_ = Respond(w, r, isps, http.StatusOK)
 	return
 }
 
 func (h *AddressesHandler) GetIPQuantityByCountry(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.GetIPQuantityByCountry")
+	defer span.End()
+
 	country := strings.Title(r.URL.Query().Get("country"))
 	if country == "" {
-		_ = RespondJSON(w, "missing country", http.StatusBadRequest)
+		_ = Respond(w, r, "missing country", http.StatusBadRequest)
 		return
 	}
-	quantity, err := h.service.GetIPQuantityByCountry(r.Context(), country)
+	quantity, err := h.service.GetIPQuantityByCountry(ctx, country)
 	if err != nil {
-		log.WithContext(r.Context()).
+		log.WithContext(ctx).
 			WithFields(log.Fields{"event": "get ip quantity by country"}).
 			Error(err)
-		_ = RespondJSON(w, err, http.StatusInternalServerError)
+		_ = Respond(w, r, err, http.StatusInternalServerError)
 		return
 	}
-	_ = RespondJSON(w, models.ToCountryQuantityModel(country, quantity), http.StatusOK)
+	_ = Respond(w, r, models.ToCountryQuantityModel(country, quantity), http.StatusOK)
+	return
+}
+
+// Near searches the ranges located within radius_km kilometers of lat and
+// lon, it needs the location and city fields of the tier.
+func (h *AddressesHandler) Near(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.Near")
+	defer span.End()
+
+	tier := auth.TierFromContext(ctx)
+	if !tier.Allows(auth.FieldLocation) || !tier.Allows(auth.FieldCity) {
+		_ = Respond(w, r, "location data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	circle, err := obtainCircle(r.URL)
+	if err != nil {
+		_ = Respond(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := obtainLimit(r.URL)
+	if limit < 1 || limit > maxRangesLimit {
+		_ = Respond(w, r, fmt.Sprintf("limit must be between 1 and %d", maxRangesLimit), http.StatusBadRequest)
+		return
+	}
+	nearby, err := h.service.Near(ctx, circle, limit)
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "search near"}).
+			Error(err)
+		_ = Respond(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	output := models.ToNearbyModel(nearby, tier)
+	usage.AddRows(ctx, len(output.Ranges))
+	_ = Respond(w, r, output, http.StatusOK)
+	return
+}
+
+// GetByDomain lists the ranges registered to a domain or its subdomains, it
+// needs the domain field of the tier.
+func (h *AddressesHandler) GetByDomain(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.GetByDomain")
+	defer span.End()
+
+	tier := auth.TierFromContext(ctx)
+	if !tier.Allows(auth.FieldDomain) {
+		_ = Respond(w, r, "domain data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	domain := strings.ToLower(chi.URLParam(r, "domain"))
+	if len(domain) > 253 || !domainFormat.MatchString(domain) {
+		_ = Respond(w, r, "invalid domain", http.StatusBadRequest)
+		return
+	}
+	limit := obtainLimit(r.URL)
+	if limit < 1 || limit > maxRangesLimit {
+		_ = Respond(w, r, fmt.Sprintf("limit must be between 1 and %d", maxRangesLimit), http.StatusBadRequest)
+		return
+	}
+	result, err := h.service.GetByDomain(ctx, domain, limit)
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "get by domain"}).
+			Error(err)
+		_ = Respond(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		_ = Respond(w, r, nil, http.StatusNotFound)
+		return
+	}
+	output := models.ToDomainModel(result, tier)
+	usage.AddRows(ctx, len(output.Ranges))
+	_ = Respond(w, r, output, http.StatusOK)
+	return
+}
+
+// SearchISPs ranks the ISPs whose name resembles q, optionally in the
+// country given by code or name. It needs the isp field of the tier.
+func (h *AddressesHandler) SearchISPs(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.SearchISPs")
+	defer span.End()
+
+	if !auth.TierFromContext(ctx).Allows(auth.FieldISP) {
+		_ = Respond(w, r, "isp data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if length := len([]rune(query)); length < minISPQuery || length > maxISPQuery {
+		_ = Respond(w, r, fmt.Sprintf("q must have between %d and %d characters", minISPQuery, maxISPQuery),
+			http.StatusBadRequest)
+		return
+	}
+	country := r.URL.Query().Get("country")
+	if len(country) == 2 {
+		country = strings.ToUpper(country)
+	} else {
+		country = strings.Title(country)
+	}
+	limit := defaultISPLimit
+	if r.URL.Query().Get("limit") != "" {
+		limit = obtainLimit(r.URL)
+	}
+	if limit < 1 || limit > maxISPLimit {
+		_ = Respond(w, r, fmt.Sprintf("limit must be between 1 and %d", maxISPLimit), http.StatusBadRequest)
+		return
+	}
+	matches, err := h.service.SearchISPs(ctx, query, country, limit)
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "search isps"}).
+			Error(err)
+		_ = Respond(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	_ = Respond(w, r, models.ToISPMatchesModel(matches), http.StatusOK)
+	return
+}
+
+// Sample draws n random addresses from the ranges matching the country,
+// proxy_type and usage_type filters. The seed it answers with, random unless
+// given, draws the same addresses again.
+func (h *AddressesHandler) Sample(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "AddressesHandler.Sample")
+	defer span.End()
+
+	tier := auth.TierFromContext(ctx)
+	n := defaultSampleSize
+	if value := r.URL.Query().Get("n"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			_ = Respond(w, r, "n must be a number", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+	if n < 1 || n > maxSampleSize {
+		_ = Respond(w, r, fmt.Sprintf("n must be between 1 and %d", maxSampleSize), http.StatusBadRequest)
+		return
+	}
+	seed := rand.Int63()
+	if value := r.URL.Query().Get("seed"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			_ = Respond(w, r, "seed must be an integer", http.StatusBadRequest)
+			return
+		}
+		seed = parsed
+	}
+	filters := obtainSampleFilters(r.URL)
+	if filters[ips.SampleProxyType] != nil && !tier.Allows(auth.FieldProxyType) {
+		_ = Respond(w, r, "proxy type data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	if filters[ips.SampleUsage] != nil && !tier.Allows(auth.FieldUsage) {
+		_ = Respond(w, r, "usage data is not included in your tier", http.StatusForbidden)
+		return
+	}
+	sample, err := h.service.Sample(ctx, n, seed, filters)
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		_ = Respond(w, r, columnErr.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "sample"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(sample) == 0 {
+		_ = Respond(w, r, nil, http.StatusNoContent)
+		return
+	}
+	output := models.ToSampleModel(seed, sample, tier)
+	usage.AddRows(ctx, len(output.IPs))
+	_ = Respond(w, r, output, http.StatusOK)
 	return
 }
 
@@ -112,22 +368,13 @@ func RespondJSON(w http.ResponseWriter, v interface{}, code int) error {
 		w.WriteHeader(code)
 		return nil
 	}
-	var jsonData []byte
-	var err error
-	switch v := v.(type) {
-	case []byte:
-		jsonData = v
-	case io.Reader:
-		jsonData, err = ioutil.ReadAll(v)
-	default:
-		jsonData, err = json.Marshal(v)
-	}
-	if err != nil {
+	var buffer bytes.Buffer
+	if err := encodeJSON(&buffer, v); err != nil {
 		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	if _, err := w.Write(jsonData); err != nil {
+	if _, err := w.Write(buffer.Bytes()); err != nil {
 		return err
 	}
 	return nil
@@ -150,3 +397,48 @@ func obtainFilters(u *url.URL) map[string]interface{} {
 	}
 	return filters
 }
+
+// obtainSampleFilters reads the filters of a sample, a two letter country is
+// a code and anything longer a name.
+func obtainSampleFilters(u *url.URL) map[string]interface{} {
+	filters := make(map[string]interface{})
+	if country := u.Query().Get("country"); len(country) == 2 {
+		filters[ips.SampleCountry] = strings.ToUpper(country)
+	} else if country != "" {
+		filters[ips.SampleCountry] = strings.Title(country)
+	}
+	if proxyType := u.Query().Get("proxy_type"); proxyType != "" {
+		filters[ips.SampleProxyType] = strings.ToUpper(proxyType)
+	}
+	if usageType := u.Query().Get("usage_type"); usageType != "" {
+		filters[ips.SampleUsage] = strings.ToUpper(usageType)
+	}
+	return filters
+}
+
+// obtainCircle parses the lat, lon and radius_km parameters of a radius
+// search.
+func obtainCircle(u *url.URL) (ips.Circle, error) {
+	circle := ips.Circle{}
+	values := []struct {
+		name     string
+		target   *float64
+		min, max float64
+	}{
+		{name: "lat", target: &circle.Latitude, min: -90, max: 90},
+		{name: "lon", target: &circle.Longitude, min: -180, max: 180},
+		{name: "radius_km", target: &circle.RadiusKm, min: 0, max: maxRadiusKm},
+	}
+	for _, value := range values {
+		input := u.Query().Get(value.name)
+		if input == "" {
+			return circle, fmt.Errorf("missing %s", value.name)
+		}
+		parsed, err := strconv.ParseFloat(input, 64)
+		if err != nil || math.IsNaN(parsed) || parsed < value.min || parsed > value.max {
+			return circle, fmt.Errorf("%s must be a number between %g and %g", value.name, value.min, value.max)
+		}
+		*value.target = parsed
+	}
+	return circle, nil
+}
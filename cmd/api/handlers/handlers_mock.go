@@ -64,7 +64,7 @@ func (mr *MockserviceMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockservice)(nil).Get), arg0, arg1)
 }
 
-// GetTopNISPByCountry mocks base method
+// GetTop10ISPByCountry mocks base method
 func (m *Mockservice) GetTop10ISPByCountry(arg0 context.Context, arg1 string) ([]string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetTop10ISPByCountry", arg0, arg1)
@@ -73,8 +73,8 @@ func (m *Mockservice) GetTop10ISPByCountry(arg0 context.Context, arg1 string) ([
 	return ret0, ret1
 }
 
-// GetTopNISPByCountry indicates an expected call of GetTopNISPByCountry
-func (mr *MockserviceMockRecorder) GetTopNISPByCountry(arg0, arg1 interface{}) *gomock.Call {
+// GetTop10ISPByCountry indicates an expected call of GetTop10ISPByCountry
+func (mr *MockserviceMockRecorder) GetTop10ISPByCountry(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTop10ISPByCountry", reflect.TypeOf((*Mockservice)(nil).GetTop10ISPByCountry), arg0, arg1)
 }
@@ -93,3 +93,63 @@ func (mr *MockserviceMockRecorder) GetIPQuantityByCountry(arg0, arg1 interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPQuantityByCountry", reflect.TypeOf((*Mockservice)(nil).GetIPQuantityByCountry), arg0, arg1)
 }
+
+// Near mocks base method
+func (m *Mockservice) Near(arg0 context.Context, arg1 ips.Circle, arg2 int) (*ips.Nearby, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Near", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*ips.Nearby)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Near indicates an expected call of Near
+func (mr *MockserviceMockRecorder) Near(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Near", reflect.TypeOf((*Mockservice)(nil).Near), arg0, arg1, arg2)
+}
+
+// GetByDomain mocks base method
+func (m *Mockservice) GetByDomain(arg0 context.Context, arg1 string, arg2 int) (*ips.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByDomain", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*ips.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByDomain indicates an expected call of GetByDomain
+func (mr *MockserviceMockRecorder) GetByDomain(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByDomain", reflect.TypeOf((*Mockservice)(nil).GetByDomain), arg0, arg1, arg2)
+}
+
+// SearchISPs mocks base method
+func (m *Mockservice) SearchISPs(arg0 context.Context, arg1, arg2 string, arg3 int) ([]*ips.ISPMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchISPs", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*ips.ISPMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchISPs indicates an expected call of SearchISPs
+func (mr *MockserviceMockRecorder) SearchISPs(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchISPs", reflect.TypeOf((*Mockservice)(nil).SearchISPs), arg0, arg1, arg2, arg3)
+}
+
+// Sample mocks base method
+func (m *Mockservice) Sample(arg0 context.Context, arg1 int, arg2 int64, arg3 map[string]interface{}) ([]*ips.IP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sample", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*ips.IP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sample indicates an expected call of Sample
+func (mr *MockserviceMockRecorder) Sample(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sample", reflect.TypeOf((*Mockservice)(nil).Sample), arg0, arg1, arg2, arg3)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
 	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
@@ -50,6 +51,8 @@ func TestAddressesHandler_Get(t *testing.T) {
 					EXPECT().
 					Get(gomock.Any(), fields.ip).
 					Return(&ips.IP{
+						From:      3049261568,
+						To:        3049261823,
 						ProxyType: "PUB",
 						Country: ips.Country{
 							Code:   "AR",
@@ -160,7 +163,7 @@ func TestAddressesHandler_GetTop10ISPByCountry(t *testing.T) {
 			expectations: func(fields fields) {
 				handler.service.(*Mockservice).
 					EXPECT().
-					GetTopNISPByCountry(gomock.Any(), gomock.Any()).
+					GetTop10ISPByCountry(gomock.Any(), gomock.Any()).
 					Return([]string{"Rook Media GmbH", "RapidSeedbox Ltd", "Sunrise UPC GmbH",
 						"Swisscom AG", "Google LLC", "Private Layer Inc", "Datapark AG",
 						"Zscaler Inc.", "Bluewin is an LIR and ISP in Switzerland.",
@@ -189,13 +192,28 @@ func TestAddressesHandler_GetTop10ISPByCountry(t *testing.T) {
 			expectations: func(fields fields) {
 				handler.service.(*Mockservice).
 					EXPECT().
-					GetTopNISPByCountry(gomock.Any(), gomock.Any()).
+					GetTop10ISPByCountry(gomock.Any(), gomock.Any()).
 					Return(nil, errors.New("error"))
 			},
 			want: want{
 				statusCode: http.StatusInternalServerError,
 			},
 		},
+		{
+			name: "product without isp",
+			fields: fields{
+				country: "Argentina",
+			},
+			expectations: func(fields fields) {
+				handler.service.(*Mockservice).
+					EXPECT().
+					GetTop10ISPByCountry(gomock.Any(), gomock.Any()).
+					Return(nil, &ips.ColumnError{Product: "DB3", Column: ips.ColumnISP})
+			},
+			want: want{
+				statusCode: http.StatusNotImplemented,
+			},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -226,6 +244,154 @@ func TestAddressesHandler_GetTop10ISPByCountry(t *testing.T) {
 	}
 }
 
+func TestAddressesHandler_Tier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAddressesHandler(ctrl)
+	tier, _ := auth.NewTier("city", []string{auth.FieldRegion, auth.FieldCity})
+	tiered := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r.WithContext(auth.WithTier(r.Context(), tier)))
+		}
+	}
+
+	t.Run("redacts lookup", func(t *testing.T) {
+		handler.service.(*Mockservice).
+			EXPECT().
+			Get(gomock.Any(), "181.192.10.182").
+			Return(&ips.IP{
+				ProxyType: "PUB",
+				Country:   ips.Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"},
+				ISP:       "CTL LATAM",
+				Domain:    "ctl.com",
+				ASN:       3549,
+				AS:        "Level 3",
+			}, nil)
+		app := chi.NewRouter()
+		app.Get("/v1/ips/{IP}", tiered(handler.Get))
+		r := httptest.NewRequest(http.MethodGet, "/v1/ips/181.192.10.182", nil)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		var output *models.IP
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+		assert.Equal(t, &models.IP{
+			IP:      "181.192.10.182",
+			Country: models.Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"},
+		}, output)
+	})
+
+	t.Run("forbids top ISPs", func(t *testing.T) {
+		app := chi.NewRouter()
+		app.Get("/v1/ips/isps/top", tiered(handler.GetTop10ISPByCountry))
+		r := httptest.NewRequest(http.MethodGet, "/v1/ips/isps/top?country=Argentina", nil)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("forbids near", func(t *testing.T) {
+		app := chi.NewRouter()
+		app.Get("/v1/ips/near", tiered(handler.Near))
+		r := httptest.NewRequest(http.MethodGet, "/v1/ips/near?lat=-34.6&lon=-58.4&radius_km=25", nil)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestAddressesHandler_Near(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAddressesHandler(ctrl)
+	circle := ips.Circle{Latitude: -34.6, Longitude: -58.4, RadiusKm: 25}
+	latitude, longitude := -34.61315, -58.37723
+
+	tests := []struct {
+		name         string
+		query        string
+		expectations func()
+		statusCode   int
+		output       *models.Nearby
+	}{
+		{
+			name:  "ok",
+			query: "lat=-34.6&lon=-58.4&radius_km=25&limit=10",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					Near(gomock.Any(), circle, 10).
+					Return(&ips.Nearby{
+						Ranges: []*ips.IP{{From: 150178520, To: 150178530,
+							Country:  ips.Country{Code: "AR", Name: "Argentina", City: "Buenos Aires"},
+							Latitude: &latitude, Longitude: &longitude}},
+						Cities: []*ips.CityTotal{{Country: ips.Country{Code: "AR", Name: "Argentina",
+							City: "Buenos Aires"}, Ranges: 1, Addresses: 11}},
+					}, nil)
+			},
+			statusCode: http.StatusOK,
+			output: &models.Nearby{
+				Ranges: []*models.IP{{IP: "8.243.138.216", From: 150178520, To: 150178530,
+					Country:  models.Country{Code: "AR", Name: "Argentina", City: "Buenos Aires"},
+					Latitude: &latitude, Longitude: &longitude}},
+				Cities: []*models.CityTotal{{Country: models.Country{Code: "AR", Name: "Argentina",
+					City: "Buenos Aires"}, Ranges: 1, Addresses: 11}},
+			},
+		},
+		{name: "missing radius", query: "lat=-34.6&lon=-58.4", expectations: func() {},
+			statusCode: http.StatusBadRequest},
+		{name: "invalid latitude", query: "lat=-95&lon=-58.4&radius_km=25", expectations: func() {},
+			statusCode: http.StatusBadRequest},
+		{name: "radius too wide", query: "lat=-34.6&lon=-58.4&radius_km=5000", expectations: func() {},
+			statusCode: http.StatusBadRequest},
+		{name: "limit too large", query: "lat=-34.6&lon=-58.4&radius_km=25&limit=5000", expectations: func() {},
+			statusCode: http.StatusBadRequest},
+		{
+			name:  "product without coordinates",
+			query: "lat=-34.6&lon=-58.4&radius_km=25",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					Near(gomock.Any(), circle, 100).
+					Return(nil, &ips.ColumnError{Product: "PX7", Column: ips.ColumnLatitude})
+			},
+			statusCode: http.StatusNotImplemented,
+		},
+		{
+			name:  "error",
+			query: "lat=-34.6&lon=-58.4&radius_km=25",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					Near(gomock.Any(), circle, 100).
+					Return(nil, errors.New("error"))
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+			app.Get("/v1/ips/near", handler.Near)
+			r := httptest.NewRequest(http.MethodGet, "/v1/ips/near?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+			if tc.output != nil {
+				var output *models.Nearby
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.output, output)
+			}
+		})
+	}
+}
+
 func TestAddressesHandler_GetIPQuantityByCountry(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -454,3 +620,263 @@ func TestAddressesHandler_List(t *testing.T) {
 		})
 	}
 }
+
+func TestAddressesHandler_GetByDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAddressesHandler(ctrl)
+	tier := mustTier("domain", auth.FieldDomain)
+
+	tests := []struct {
+		name         string
+		path         string
+		tier         *auth.Tier
+		expectations func()
+		statusCode   int
+		output       *models.Domain
+	}{
+		{
+			name: "ok",
+			path: "/v1/domains/CenturyLink.com?limit=10",
+			tier: tier,
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					GetByDomain(gomock.Any(), "centurylink.com", 10).
+					Return(&ips.Domain{
+						Name: "centurylink.com",
+						Ranges: []*ips.IP{{From: 150178520, To: 150178530, ISP: "CTL LATAM",
+							Domain: "centurylink.com", Country: ips.Country{Code: "AR", Name: "Argentina"}}},
+						Addresses: 11,
+						Countries: []*ips.CountryTotal{{Country: ips.Country{Code: "AR", Name: "Argentina"},
+							Ranges: 1, Addresses: 11}},
+						ISPs: []*ips.ISPTotal{{ISP: "CTL LATAM", Ranges: 1, Addresses: 11}},
+					}, nil)
+			},
+			statusCode: http.StatusOK,
+			output: &models.Domain{
+				Domain:    "centurylink.com",
+				Addresses: 11,
+				Ranges: []*models.IP{{IP: "8.243.138.216", From: 150178520, To: 150178530,
+					Domain: "centurylink.com", Country: models.Country{Code: "AR", Name: "Argentina"}}},
+				Countries: []*models.CountryTotal{{Country: models.Country{Code: "AR", Name: "Argentina"},
+					Ranges: 1, Addresses: 11}},
+			},
+		},
+		{
+			name: "not found",
+			path: "/v1/domains/example.com",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					GetByDomain(gomock.Any(), "example.com", 100).
+					Return(nil, nil)
+			},
+			statusCode: http.StatusNotFound,
+		},
+		{name: "invalid domain", path: "/v1/domains/example..com", expectations: func() {},
+			statusCode: http.StatusBadRequest},
+		{name: "forbidden", path: "/v1/domains/example.com", expectations: func() {},
+			tier: mustTier("city", auth.FieldCity), statusCode: http.StatusForbidden},
+		{
+			name: "product without domain",
+			path: "/v1/domains/example.com",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					GetByDomain(gomock.Any(), "example.com", 100).
+					Return(nil, &ips.ColumnError{Product: "DB11", Column: ips.ColumnDomain})
+			},
+			statusCode: http.StatusNotImplemented,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+			app.Get("/v1/domains/{domain}", handler.GetByDomain)
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.tier != nil {
+				r = r.WithContext(auth.WithTier(r.Context(), tc.tier))
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+			if tc.output != nil {
+				var output *models.Domain
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.output, output)
+			}
+		})
+	}
+}
+
+func TestAddressesHandler_SearchISPs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAddressesHandler(ctrl)
+
+	tests := []struct {
+		name         string
+		query        string
+		tier         *auth.Tier
+		expectations func()
+		statusCode   int
+		output       []*models.ISPMatch
+	}{
+		{
+			name:  "ok",
+			query: "q=swisscom&country=ch",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					SearchISPs(gomock.Any(), "swisscom", "CH", 10).
+					Return([]*ips.ISPMatch{{ISP: "Swisscom AG", Score: 1, Ranges: 40, Addresses: 1200,
+						Countries: []*ips.CountryTotal{{Country: ips.Country{Code: "CH", Name: "Switzerland"},
+							Ranges: 40, Addresses: 1200}}}}, nil)
+			},
+			statusCode: http.StatusOK,
+			output: []*models.ISPMatch{{ISP: "Swisscom AG", Score: 1, Ranges: 40, Addresses: 1200,
+				Countries: []*models.CountryTotal{{Country: models.Country{Code: "CH", Name: "Switzerland"},
+					Ranges: 40, Addresses: 1200}}}},
+		},
+		{
+			name:  "country name",
+			query: "q=swisscom&country=switzerland&limit=50",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					SearchISPs(gomock.Any(), "swisscom", "Switzerland", 50).
+					Return([]*ips.ISPMatch{}, nil)
+			},
+			statusCode: http.StatusOK,
+			output:     []*models.ISPMatch{},
+		},
+		{name: "short query", query: "q=sw", expectations: func() {}, statusCode: http.StatusBadRequest},
+		{name: "limit too large", query: "q=swisscom&limit=500", expectations: func() {},
+			statusCode: http.StatusBadRequest},
+		{name: "forbidden", query: "q=swisscom", tier: mustTier("city", auth.FieldCity), expectations: func() {},
+			statusCode: http.StatusForbidden},
+		{
+			name:  "product without isp",
+			query: "q=swisscom",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					SearchISPs(gomock.Any(), "swisscom", "", 10).
+					Return(nil, &ips.ColumnError{Product: "DB1", Column: ips.ColumnISP})
+			},
+			statusCode: http.StatusNotImplemented,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+			app.Get("/v1/isps", handler.SearchISPs)
+			r := httptest.NewRequest(http.MethodGet, "/v1/isps?"+tc.query, nil)
+			if tc.tier != nil {
+				r = r.WithContext(auth.WithTier(r.Context(), tc.tier))
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+			if tc.output != nil {
+				var output []*models.ISPMatch
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.output, output)
+			}
+		})
+	}
+}
+
+func TestAddressesHandler_Sample(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := newMockAddressesHandler(ctrl)
+	swiss := map[string]interface{}{ips.SampleCountry: "CH", ips.SampleUsage: "DCH"}
+
+	tests := []struct {
+		name         string
+		query        string
+		tier         *auth.Tier
+		expectations func()
+		statusCode   int
+		output       *models.Sample
+	}{
+		{
+			name:  "ok",
+			query: "n=20&seed=42&country=ch&usage_type=dch",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					Sample(gomock.Any(), 20, int64(42), swiss).
+					Return([]*ips.IP{{From: 12, To: 12, Usage: "DCH",
+						Country: ips.Country{Code: "CH", Name: "Switzerland"}}}, nil)
+			},
+			statusCode: http.StatusOK,
+			output: &models.Sample{Seed: 42, IPs: []*models.IP{{IP: "0.0.0.12", Usage: "DCH",
+				Country: models.Country{Code: "CH", Name: "Switzerland"}}}},
+		},
+		{
+			name:  "country name",
+			query: "country=switzerland&proxy_type=pub&seed=1",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					Sample(gomock.Any(), 10, int64(1), map[string]interface{}{ips.SampleCountry: "Switzerland",
+						ips.SampleProxyType: "PUB"}).
+					Return([]*ips.IP{}, nil)
+			},
+			statusCode: http.StatusNoContent,
+		},
+		{name: "n too large", query: "n=5000", expectations: func() {}, statusCode: http.StatusBadRequest},
+		{name: "invalid seed", query: "seed=abc", expectations: func() {}, statusCode: http.StatusBadRequest},
+		{name: "forbidden filter", query: "usage_type=DCH", tier: mustTier("city", auth.FieldCity),
+			expectations: func() {}, statusCode: http.StatusForbidden},
+		{
+			name:  "product without usage",
+			query: "usage_type=DCH",
+			expectations: func() {
+				handler.service.(*Mockservice).
+					EXPECT().
+					Sample(gomock.Any(), 10, gomock.Any(), map[string]interface{}{ips.SampleUsage: "DCH"}).
+					Return(nil, &ips.ColumnError{Product: "PX2", Column: ips.ColumnUsage})
+			},
+			statusCode: http.StatusNotImplemented,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+			app.Get("/v1/ips/sample", handler.Sample)
+			r := httptest.NewRequest(http.MethodGet, "/v1/ips/sample?"+tc.query, nil)
+			if tc.tier != nil {
+				r = r.WithContext(auth.WithTier(r.Context(), tc.tier))
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+			if tc.output != nil {
+				var output *models.Sample
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.Equal(t, tc.output, output)
+			}
+		})
+	}
+}
+
+func mustTier(name string, fields ...string) *auth.Tier {
+	tier, err := auth.NewTier(name, fields)
+	if err != nil {
+		panic(err)
+	}
+	return tier
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"github.com/mborroni/dreamlab-challenge/internal/health"
+	"net/http"
+)
+
+type checker interface {
+	Run(context.Context) *health.Report
+}
+
+type HealthHandler struct {
+	checker checker
+}
+
+func NewHealthHandler(checker checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Live only tells the process is serving requests, it must not depend on
+// external services or the orchestrator would restart healthy replicas.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	_ = Respond(w, r, &health.Report{Status: health.StatusUp, Checks: []*health.Result{}}, http.StatusOK)
+}
+
+// Ready runs every registered check and answers 503 when any is down, so no
+// traffic is routed to the replica.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+	if report.Status != health.StatusUp {
+		_ = Respond(w, r, report, http.StatusServiceUnavailable)
+		return
+	}
+	_ = Respond(w, r, report, http.StatusOK)
+}
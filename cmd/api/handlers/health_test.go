@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/mborroni/dreamlab-challenge/internal/health"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler_Live(t *testing.T) {
+	handler := NewHealthHandler(health.NewChecker(time.Second))
+
+	w := httptest.NewRecorder()
+	handler.Live(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHealthHandler_Ready(t *testing.T) {
+	type want struct {
+		statusCode int
+		status     string
+	}
+
+	tests := []struct {
+		name  string
+		check health.Check
+		want  want
+	}{
+		{
+			name:  "ready",
+			check: func(context.Context) error { return nil },
+			want:  want{statusCode: http.StatusOK, status: health.StatusUp},
+		},
+		{
+			name:  "not ready",
+			check: func(context.Context) error { return errors.New("connection refused") },
+			want:  want{statusCode: http.StatusServiceUnavailable, status: health.StatusDown},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := health.NewChecker(time.Second)
+			checker.Register("database", tc.check)
+			handler := NewHealthHandler(checker)
+
+			w := httptest.NewRecorder()
+			handler.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			var report health.Report
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, tc.want.statusCode, w.Code)
+			assert.Equal(t, tc.want.status, report.Status)
+			assert.Len(t, report.Checks, 1)
+		})
+	}
+}
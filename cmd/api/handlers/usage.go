@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+//go:generate mockgen -source=usage.go -destination=usage_mock.go -package=handlers
+
+type usageService interface {
+	Report(context.Context, string, time.Time, time.Time) (*usage.Report, error)
+	CheckQuota(context.Context, string) (*usage.QuotaStatus, error)
+}
+
+type UsageHandler struct {
+	service usageService
+}
+
+func NewUsageHandler(service usageService) *UsageHandler {
+	return &UsageHandler{
+		service: service,
+	}
+}
+
+// Get reports the consumption of the caller, it can't see other clients'.
+func (h *UsageHandler) Get(w http.ResponseWriter, r *http.Request) {
+	principal := auth.PrincipalFromContext(r.Context())
+	if principal == nil {
+		_ = Respond(w, r, nil, http.StatusUnauthorized)
+		return
+	}
+	from, err := obtainDate(r, "from")
+	if err != nil {
+		_ = Respond(w, r, "invalid from, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	to, err := obtainDate(r, "to")
+	if err != nil {
+		_ = Respond(w, r, "invalid to, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	report, err := h.service.Report(r.Context(), principal.ID, from, to)
+	if err != nil {
+		log.WithContext(r.Context()).
+			WithFields(log.Fields{"event": "usage report"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	status, err := h.service.CheckQuota(r.Context(), principal.ID)
+	if err != nil {
+		log.WithContext(r.Context()).
+			WithFields(log.Fields{"event": "usage quota"}).
+			Error(err)
+		_ = Respond(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = Respond(w, r, models.ToUsageModel(report, status), http.StatusOK)
+}
+
+func obtainDate(r *http.Request, param string) (time.Time, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usage.go
+
+// Package handlers is a generated GoMock package.
+package handlers
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	usage "github.com/mborroni/dreamlab-challenge/internal/usage"
+	reflect "reflect"
+	time "time"
+)
+
+// MockusageService is a mock of usageService interface
+type MockusageService struct {
+	ctrl     *gomock.Controller
+	recorder *MockusageServiceMockRecorder
+}
+
+// MockusageServiceMockRecorder is the mock recorder for MockusageService
+type MockusageServiceMockRecorder struct {
+	mock *MockusageService
+}
+
+// NewMockusageService creates a new mock instance
+func NewMockusageService(ctrl *gomock.Controller) *MockusageService {
+	mock := &MockusageService{ctrl: ctrl}
+	mock.recorder = &MockusageServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockusageService) EXPECT() *MockusageServiceMockRecorder {
+	return m.recorder
+}
+
+// Report mocks base method
+func (m *MockusageService) Report(arg0 context.Context, arg1 string, arg2, arg3 time.Time) (*usage.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*usage.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Report indicates an expected call of Report
+func (mr *MockusageServiceMockRecorder) Report(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockusageService)(nil).Report), arg0, arg1, arg2, arg3)
+}
+
+// CheckQuota mocks base method
+func (m *MockusageService) CheckQuota(arg0 context.Context, arg1 string) (*usage.QuotaStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckQuota", arg0, arg1)
+	ret0, _ := ret[0].(*usage.QuotaStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckQuota indicates an expected call of CheckQuota
+func (mr *MockusageServiceMockRecorder) CheckQuota(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckQuota", reflect.TypeOf((*MockusageService)(nil).CheckQuota), arg0, arg1)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUsageHandler_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewUsageHandler(NewMockusageService(ctrl))
+	from := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 12, 2, 0, 0, 0, 0, time.UTC)
+
+	type fields struct {
+		query string
+	}
+
+	type want struct {
+		statusCode int
+		usage      *models.Usage
+	}
+
+	tests := []struct {
+		name         string
+		fields       fields
+		expectations func()
+		want         want
+	}{
+		{
+			name:   "ok",
+			fields: fields{query: "?from=2021-12-01&to=2021-12-02"},
+			expectations: func() {
+				handler.service.(*MockusageService).EXPECT().
+					Report(gomock.Any(), "key:1", from, to).
+					Return(&usage.Report{
+						ClientID: "key:1",
+						From:     from,
+						To:       to,
+						Total:    usage.Consumption{Requests: 2, Addresses: 2},
+						Entries: []*usage.Entry{
+							{Day: from, Endpoint: "GET /v1/ips/{IP}/", Consumption: usage.Consumption{Requests: 2, Addresses: 2}},
+						},
+					}, nil)
+				handler.service.(*MockusageService).EXPECT().
+					CheckQuota(gomock.Any(), "key:1").
+					Return(&usage.QuotaStatus{
+						Quota:    usage.Quota{Requests: 100},
+						Used:     usage.Consumption{Requests: 2, Addresses: 2},
+						ResetsAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+					}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				usage: &models.Usage{
+					Client: "key:1",
+					From:   "2021-12-01",
+					To:     "2021-12-02",
+					Total:  models.Consumption{Requests: 2, Addresses: 2},
+					Quota: models.Quota{
+						Requests: 100,
+						Used:     models.Consumption{Requests: 2, Addresses: 2},
+						ResetsAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+					},
+					Entries: []*models.UsageEntry{
+						{Day: "2021-12-01", Endpoint: "GET /v1/ips/{IP}/", Consumption: models.Consumption{Requests: 2, Addresses: 2}},
+					},
+				},
+			},
+		},
+		{
+			name:         "invalid date",
+			fields:       fields{query: "?from=december"},
+			expectations: func() {},
+			want:         want{statusCode: http.StatusBadRequest},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			app := chi.NewRouter()
+
+			app.Get("/v1/usage", handler.Get)
+			r := httptest.NewRequest(http.MethodGet, "/v1/usage"+tc.fields.query, nil)
+			r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{ID: "key:1"}))
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.want.statusCode, w.Code)
+			if tc.want.usage != nil {
+				var output *models.Usage
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+				assert.EqualValues(t, tc.want.usage, output)
+			}
+		})
+	}
+}
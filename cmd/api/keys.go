@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/application"
+	"strings"
+)
+
+const keysUsage = "usage: api keys create <name> <scope,...> [tier]"
+
+// keys bootstraps API keys from the shell, needed at least once to create the
+// first admin key that manages the rest through /v1/admin/keys.
+func keys(args []string) error {
+	if len(args) < 3 || len(args) > 4 || args[0] != "create" {
+		return errors.New(keysUsage)
+	}
+	tier := ""
+	if len(args) == 4 {
+		tier = args[3]
+	}
+	service, err := application.BuildKeysService()
+	if err != nil {
+		return err
+	}
+	key, secret, err := service.Create(context.Background(), args[1], strings.Split(args[2], ","), tier)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("id: %d\nname: %s\nscopes: %s\ntier: %s\nkey: %s\n",
+		key.ID, key.Name, strings.Join(key.Scopes, ","), key.Tier, secret)
+	return nil
+}
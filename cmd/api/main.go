@@ -1,11 +1,25 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/mborroni/dreamlab-challenge/internal/application"
+	log "github.com/sirupsen/logrus"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := command(os.Args[1], os.Args[2:]); err != nil {
+			panic(err)
+		}
+		return
+	}
 	server, err := newServer()
 	if err != nil {
 		panic(err)
@@ -14,8 +28,51 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	routes(server, engine)
-	if err := http.ListenAndServe(":8080", server); err != nil {
+	if err := routes(server, engine); err != nil {
+		panic(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{Addr: ":8080", Handler: server}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			panic(err)
+		}
+	}()
+
+	grpcServer := newGRPCServer(engine)
+	listener, err := net.Listen("tcp", ":9090")
+	if err != nil {
 		panic(err)
 	}
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			panic(err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.WithContext(shutdownCtx).Error(err)
+	}
+	grpcServer.GracefulStop()
+	if err := engine.Close(shutdownCtx); err != nil {
+		log.WithContext(shutdownCtx).Error(err)
+	}
+}
+
+func command(name string, args []string) error {
+	switch name {
+	case "migrate":
+		return migrate(args)
+	case "keys":
+		return keys(args)
+	case "export-mmdb":
+		return exportMMDB(args)
+	}
+	return fmt.Errorf("unknown command %q", name)
 }
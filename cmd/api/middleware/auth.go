@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+const (
+	APIKeyHeader     = "X-API-Key"
+	APIKeyQueryParam = "api_key"
+)
+
+const bearerPrefix = "Bearer "
+
+type authenticator interface {
+	Authenticate(context.Context, string) (*auth.Principal, error)
+}
+
+// Authentication resolves the caller and stores it in the request context.
+// Requests carrying an "Authorization: Bearer" header are validated by
+// tokens, nil when JWT authentication is disabled; the rest by keys, from
+// the X-API-Key header or the api_key query parameter for clients that
+// can't set headers.
+func Authentication(keys authenticator, tokens authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticator, credential, bearer := keys, r.Header.Get(APIKeyHeader), false
+			if header := r.Header.Get("Authorization"); tokens != nil && strings.HasPrefix(header, bearerPrefix) {
+				authenticator, credential, bearer = tokens, strings.TrimPrefix(header, bearerPrefix), true
+			} else if credential == "" {
+				credential = r.URL.Query().Get(APIKeyQueryParam)
+			}
+			principal, err := authenticator.Authenticate(r.Context(), credential)
+			if err != nil {
+				if err == auth.ErrUnauthenticated {
+					if bearer {
+						w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					}
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return
+				}
+				log.WithContext(r.Context()).
+					WithFields(log.Fields{"event": "authenticate"}).
+					Error(err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireScope rejects requests whose principal lacks scope. It must run
+// after Authentication.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.PrincipalFromContext(r.Context())
+			if principal == nil {
+				http.Error(w, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+				return
+			}
+			if !principal.HasScope(scope) {
+				http.Error(w, auth.ErrForbidden.Error()+": "+scope+" required", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type authenticatorFunc func(context.Context, string) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, secret string) (*auth.Principal, error) {
+	return f(ctx, secret)
+}
+
+func TestMiddleware_Authentication(t *testing.T) {
+	keys := authenticatorFunc(func(ctx context.Context, secret string) (*auth.Principal, error) {
+		switch secret {
+		case "lookup-key":
+			return &auth.Principal{ID: "key:1", Scopes: []string{auth.ScopeLookup}}, nil
+		case "broken-key":
+			return nil, errors.New("connection refused")
+		}
+		return nil, auth.ErrUnauthenticated
+	})
+
+	tokens := authenticatorFunc(func(ctx context.Context, token string) (*auth.Principal, error) {
+		if token == "valid-token" {
+			return &auth.Principal{ID: "key:1", Scopes: []string{auth.ScopeLookup}}, nil
+		}
+		return nil, auth.ErrUnauthenticated
+	})
+
+	type fields struct {
+		header        string
+		query         string
+		authorization string
+		tokens        authenticator
+	}
+
+	type want struct {
+		statusCode      int
+		wwwAuthenticate string
+	}
+
+	tests := []struct {
+		name   string
+		fields fields
+		want   want
+	}{
+		{name: "header", fields: fields{header: "lookup-key"}, want: want{statusCode: http.StatusOK}},
+		{name: "query param", fields: fields{query: "lookup-key"}, want: want{statusCode: http.StatusOK}},
+		{name: "missing", fields: fields{}, want: want{statusCode: http.StatusUnauthorized}},
+		{name: "invalid", fields: fields{header: "other-key"}, want: want{statusCode: http.StatusUnauthorized}},
+		{name: "error", fields: fields{header: "broken-key"}, want: want{statusCode: http.StatusInternalServerError}},
+		{
+			name:   "bearer",
+			fields: fields{authorization: "Bearer valid-token", tokens: tokens},
+			want:   want{statusCode: http.StatusOK},
+		},
+		{
+			name:   "invalid bearer",
+			fields: fields{authorization: "Bearer expired-token", header: "lookup-key", tokens: tokens},
+			want:   want{statusCode: http.StatusUnauthorized, wwwAuthenticate: `Bearer error="invalid_token"`},
+		},
+		{
+			name:   "bearer disabled",
+			fields: fields{authorization: "Bearer valid-token"},
+			want:   want{statusCode: http.StatusUnauthorized},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := chi.NewRouter()
+			app.Use(Authentication(keys, tc.fields.tokens))
+			app.Get("/v1/ips/{IP}", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "key:1", auth.PrincipalFromContext(r.Context()).ID)
+				w.WriteHeader(http.StatusOK)
+			})
+			url := "/v1/ips/1.1.1.1"
+			if tc.fields.query != "" {
+				url += "?api_key=" + tc.fields.query
+			}
+			r := httptest.NewRequest(http.MethodGet, url, nil)
+			if tc.fields.header != "" {
+				r.Header.Set(APIKeyHeader, tc.fields.header)
+			}
+			if tc.fields.authorization != "" {
+				r.Header.Set("Authorization", tc.fields.authorization)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.want.statusCode, w.Code)
+			assert.Equal(t, tc.want.wwwAuthenticate, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestMiddleware_RequireScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		statusCode int
+	}{
+		{name: "allowed", principal: &auth.Principal{Scopes: []string{auth.ScopeAggregate}}, statusCode: http.StatusOK},
+		{name: "forbidden", principal: &auth.Principal{Scopes: []string{auth.ScopeLookup}}, statusCode: http.StatusForbidden},
+		{name: "anonymous", principal: nil, statusCode: http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := RequireScope(auth.ScopeAggregate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/v1/ips/quantity", nil)
+			if tc.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), tc.principal))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/mborroni/dreamlab-challenge/internal/logging"
+	log "github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Logging attaches the request ID, route, client IP and start time to the
+// request context, so every log.WithContext(r.Context()) line carries them,
+// and logs one line per completed request.
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &logging.Request{
+			ID:       chimiddleware.GetReqID(r.Context()),
+			ClientIP: clientIP(r),
+			Start:    time.Now(),
+			Route: func() string {
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					return rctx.RoutePattern()
+				}
+				return ""
+			},
+		}
+		ctx := logging.WithRequest(r.Context(), request)
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		log.WithContext(ctx).
+			WithFields(log.Fields{
+				"event":  "request",
+				"method": r.Method,
+				"path":   r.URL.Path,
+				"status": status,
+				"bytes":  ww.BytesWritten(),
+			}).
+			Info("request completed")
+	})
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/mborroni/dreamlab-challenge/internal/logging"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware_Logging(t *testing.T) {
+	assert.NoError(t, logging.Setup(logging.Config{}))
+	buffer := &bytes.Buffer{}
+	log.SetOutput(buffer)
+
+	app := chi.NewRouter()
+	app.Use(chimiddleware.RequestID)
+	app.Use(Logging)
+	app.Get("/v1/ips/{IP}", func(w http.ResponseWriter, r *http.Request) {
+		log.WithContext(r.Context()).Warn("inside handler")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r := httptest.NewRequest(http.MethodGet, "/v1/ips/1.1.1.1", nil)
+	r.RemoteAddr = "10.0.0.1:51234"
+	app.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, raw := range lines {
+		var line map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(raw), &line))
+		assert.NotEmpty(t, line["request_id"])
+		assert.Equal(t, "10.0.0.1", line["client_ip"])
+		assert.Equal(t, "/v1/ips/{IP}", line["route"])
+	}
+	var completed map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &completed))
+	assert.Equal(t, float64(http.StatusNotFound), completed["status"])
+}
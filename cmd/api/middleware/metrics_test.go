@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/mborroni/dreamlab-challenge/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware_Metrics(t *testing.T) {
+	app := chi.NewRouter()
+	app.Use(Metrics)
+	app.Get("/v1/ips/{IP}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2"} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/ips/"+ip, nil)
+		app.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.True(t, strings.Contains(w.Body.String(),
+		`http_requests_total{method="GET",route="/v1/ips/{IP}",status="4xx"} 2`))
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/mborroni/dreamlab-challenge/cmd/api/handlers"
+	"net/http"
+)
+
+// Negotiation answers 406 to requests accepting none of the formats
+// responses are encoded in, before authenticating or charging them.
+func Negotiation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := handlers.Negotiate(r); err != nil {
+			handlers.NotAcceptable(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiation(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		accept     string
+		statusCode int
+	}{
+		{name: "json", target: "/", accept: "application/json", statusCode: http.StatusOK},
+		{name: "csv", target: "/", accept: "text/csv", statusCode: http.StatusOK},
+		{name: "format override", target: "/?format=xml", accept: "text/html", statusCode: http.StatusOK},
+		{name: "unsupported", target: "/", accept: "text/html", statusCode: http.StatusNotAcceptable},
+		{name: "unknown format", target: "/?format=yaml", statusCode: http.StatusNotAcceptable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			handler := Negotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			r.Header.Set("Accept", tc.accept)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.statusCode, w.Code)
+			assert.Equal(t, tc.statusCode == http.StatusOK, called)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/ratelimit"
+	log "github.com/sirupsen/logrus"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type limiter interface {
+	Allow(context.Context, string, int) (*ratelimit.Result, error)
+}
+
+// RateLimit takes cost tokens from the bucket of the caller, identified by
+// its principal when authenticated or by its IP otherwise, and answers 429
+// once it is empty. If the limiter fails the request goes through, an outage
+// of the shared state must not take the API down.
+func RateLimit(limiter limiter, cost int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ip:" + clientIP(r)
+			if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+				key = principal.ID
+			}
+			result, err := limiter.Allow(r.Context(), key, cost)
+			if err != nil {
+				log.WithContext(r.Context()).
+					WithFields(log.Fields{"event": "rate limit"}).
+					Error(err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(result.ResetAfter))
+			if !result.Allowed {
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
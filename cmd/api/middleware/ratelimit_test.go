@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	type fields struct {
+		principal *auth.Principal
+		requests  int
+	}
+
+	type want struct {
+		statusCode int
+		headers    map[string]string
+	}
+
+	tests := []struct {
+		name   string
+		fields fields
+		want   want
+	}{
+		{
+			name:   "allowed",
+			fields: fields{principal: &auth.Principal{ID: "key:1"}, requests: 1},
+			want: want{
+				statusCode: http.StatusOK,
+				headers:    map[string]string{"RateLimit-Limit": "10", "RateLimit-Remaining": "5", "RateLimit-Reset": "5"},
+			},
+		},
+		{
+			name:   "limited",
+			fields: fields{principal: &auth.Principal{ID: "key:1"}, requests: 3},
+			want: want{
+				statusCode: http.StatusTooManyRequests,
+				headers:    map[string]string{"RateLimit-Remaining": "0", "Retry-After": "5", "RateLimit-Reset": "10"},
+			},
+		},
+		{
+			name:   "keyed by ip when anonymous",
+			fields: fields{principal: nil, requests: 2},
+			want: want{
+				statusCode: http.StatusOK,
+				headers:    map[string]string{"RateLimit-Remaining": "0"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 1, Burst: 10})
+			app := chi.NewRouter()
+			app.With(RateLimit(limiter, 5)).Get("/v1/ips/quantity", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			var w *httptest.ResponseRecorder
+			for i := 0; i < tc.fields.requests; i++ {
+				r := httptest.NewRequest(http.MethodGet, "/v1/ips/quantity", nil)
+				if tc.fields.principal != nil {
+					r = r.WithContext(auth.WithPrincipal(r.Context(), tc.fields.principal))
+				}
+				w = httptest.NewRecorder()
+				app.ServeHTTP(w, r)
+			}
+
+			assert.Equal(t, tc.want.statusCode, w.Code)
+			for header, value := range tc.want.headers {
+				assert.Equal(t, value, w.Header().Get(header), header)
+			}
+		})
+	}
+}
+
+func TestMiddleware_RateLimit_FailsOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := ratelimit.NewMockstore(ctrl)
+	store.EXPECT().Take(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(float64(0), false, errors.New("connection refused"))
+	limiter := ratelimit.NewLimiter(store, ratelimit.Limit{Rate: 1, Burst: 10})
+
+	app := chi.NewRouter()
+	app.With(RateLimit(limiter, 1)).Get("/v1/ips/{IP}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/ips/1.1.1.1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMiddleware_RateLimit_BeforeAuthentication(t *testing.T) {
+	lookups := 0
+	keys := authenticatorFunc(func(ctx context.Context, secret string) (*auth.Principal, error) {
+		lookups++
+		return nil, auth.ErrUnauthenticated
+	})
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 1, Burst: 2})
+
+	app := chi.NewRouter()
+	app.With(RateLimit(limiter, 1), Authentication(keys, nil)).Get("/v1/ips/{IP}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	codes := make([]int, 0)
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/v1/ips/1.1.1.1", nil)
+		r.Header.Set(APIKeyHeader, "bad-key")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+		codes = append(codes, w.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, 2, lookups)
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"net/http"
+)
+
+const DataTierHeader = "X-Data-Tier"
+
+// DataTier applies the tier of the authenticated client, handlers redact the
+// fields it doesn't include, and reports it in the X-Data-Tier header. It
+// must run after Authentication.
+func DataTier(tiers *auth.Tiers) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tier := tiers.For(auth.PrincipalFromContext(r.Context()))
+			w.Header().Set(DataTierHeader, tier.Name)
+			next.ServeHTTP(w, r.WithContext(auth.WithTier(r.Context(), tier)))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_DataTier(t *testing.T) {
+	tiers, err := auth.ParseTiers("country:;full:*", "country")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		tier      string
+	}{
+		{name: "assigned", principal: &auth.Principal{ID: "key:1", Tier: "full"}, tier: "full"},
+		{name: "default", principal: &auth.Principal{ID: "key:1"}, tier: "country"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := DataTier(tiers)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.tier, auth.TierFromContext(r.Context()).Name)
+				w.WriteHeader(http.StatusOK)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/v1/ips/1.1.1.1", nil)
+			r = r.WithContext(auth.WithPrincipal(r.Context(), tc.principal))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.tier, w.Header().Get(DataTierHeader))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+type usageTracker interface {
+	CheckQuota(context.Context, string) (*usage.QuotaStatus, error)
+	Record(context.Context, string, string, usage.Consumption) error
+}
+
+type quotaExceeded struct {
+	Error    string           `json:"error"`
+	Quota    map[string]int64 `json:"quota"`
+	Used     map[string]int64 `json:"used"`
+	ResetsAt time.Time        `json:"resets_at"`
+}
+
+// Usage rejects with 403 the requests of principals that exceeded their
+// monthly quota, and records the consumption of every request served. Only
+// successful responses and lookup misses (404) are charged. It must run after
+// Authentication, and after RequireScope and RateLimit so the requests they
+// reject don't check the quota.
+func Usage(tracker usageTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.PrincipalFromContext(r.Context())
+			if principal == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			status, err := tracker.CheckQuota(r.Context(), principal.ID)
+			if err != nil {
+				log.WithContext(r.Context()).
+					WithFields(log.Fields{"event": "check quota"}).
+					Error(err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if status.Exceeded() {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(&quotaExceeded{
+					Error:    "monthly quota exceeded",
+					Quota:    map[string]int64{"requests": status.Quota.Requests, "rows": status.Quota.Rows},
+					Used:     map[string]int64{"requests": status.Used.Requests, "rows": status.Used.Rows},
+					ResetsAt: status.ResetsAt,
+				})
+				return
+			}
+
+			ctx := usage.WithCounter(r.Context())
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			if code >= http.StatusBadRequest && code != http.StatusNotFound {
+				return
+			}
+			endpoint := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				endpoint = rctx.RoutePattern()
+			}
+			if err := tracker.Record(context.WithoutCancel(ctx), principal.ID, r.Method+" "+endpoint, usage.Counted(ctx)); err != nil {
+				log.WithContext(ctx).
+					WithFields(log.Fields{"event": "record usage"}).
+					Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeTracker struct {
+	status   *usage.QuotaStatus
+	recorded map[string]usage.Consumption
+}
+
+func (f *fakeTracker) CheckQuota(ctx context.Context, clientID string) (*usage.QuotaStatus, error) {
+	return f.status, nil
+}
+
+func (f *fakeTracker) Record(ctx context.Context, clientID string, endpoint string, consumption usage.Consumption) error {
+	f.recorded[clientID+" "+endpoint] = consumption
+	return nil
+}
+
+func TestMiddleware_Usage(t *testing.T) {
+	type fields struct {
+		status     *usage.QuotaStatus
+		statusCode int
+	}
+
+	type want struct {
+		statusCode int
+		recorded   map[string]usage.Consumption
+	}
+
+	tests := []struct {
+		name   string
+		fields fields
+		want   want
+	}{
+		{
+			name: "recorded",
+			fields: fields{
+				status:     &usage.QuotaStatus{Quota: usage.Quota{Requests: 10}, Used: usage.Consumption{Requests: 9}},
+				statusCode: http.StatusOK,
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				recorded: map[string]usage.Consumption{
+					"key:1 GET /v1/ips/{IP}": {Requests: 1, Addresses: 1},
+				},
+			},
+		},
+		{
+			name: "quota exceeded",
+			fields: fields{
+				status:     &usage.QuotaStatus{Quota: usage.Quota{Requests: 10}, Used: usage.Consumption{Requests: 10}},
+				statusCode: http.StatusOK,
+			},
+			want: want{
+				statusCode: http.StatusForbidden,
+				recorded:   map[string]usage.Consumption{},
+			},
+		},
+		{
+			name: "failed requests are not charged",
+			fields: fields{
+				status:     &usage.QuotaStatus{},
+				statusCode: http.StatusInternalServerError,
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				recorded:   map[string]usage.Consumption{},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := &fakeTracker{status: tc.fields.status, recorded: map[string]usage.Consumption{}}
+			app := chi.NewRouter()
+			app.Use(Usage(tracker))
+			app.Get("/v1/ips/{IP}", func(w http.ResponseWriter, r *http.Request) {
+				usage.AddAddresses(r.Context(), 1)
+				w.WriteHeader(tc.fields.statusCode)
+			})
+			r := httptest.NewRequest(http.MethodGet, "/v1/ips/1.1.1.1", nil)
+			r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{ID: "key:1"}))
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.want.statusCode, w.Code)
+			assert.Equal(t, tc.want.recorded, tracker.recorded)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/apikeys"
+	"time"
+)
+
+type APIKey struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	Tier      string     `json:"tier,omitempty"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateAPIKey struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	Tier   string   `json:"tier,omitempty"`
+}
+
+// ToAPIKeyModel maps key, secret is only set right after creating or rotating it.
+func ToAPIKeyModel(key *apikeys.Key, secret string) *APIKey {
+	return &APIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		Tier:      key.Tier,
+		Key:       secret,
+		CreatedAt: key.CreatedAt,
+		RotatedAt: key.RotatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+func ToAPIKeysModel(keys []*apikeys.Key) []*APIKey {
+	output := make([]*APIKey, 0)
+	for _, key := range keys {
+		output = append(output, ToAPIKeyModel(key, ""))
+	}
+	return output
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+)
+
+type CountrySummary struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Ranges    int    `json:"ranges"`
+	Addresses int64  `json:"addresses"`
+	Regions   int    `json:"regions"`
+	Cities    int    `json:"cities"`
+}
+
+func ToCountrySummaryModel(entity *ips.CountrySummary) *CountrySummary {
+	return &CountrySummary{
+		Code:      entity.Country.Code,
+		Name:      entity.Country.Name,
+		Ranges:    entity.Ranges,
+		Addresses: entity.Addresses,
+		Regions:   entity.Regions,
+		Cities:    entity.Cities,
+	}
+}
+
+func ToCountrySummariesModel(entities []*ips.CountrySummary) []*CountrySummary {
+	output := make([]*CountrySummary, 0, len(entities))
+	for _, entity := range entities {
+		output = append(output, ToCountrySummaryModel(entity))
+	}
+	return output
+}
+
+type RegionSummary struct {
+	Country   Country `json:"country"`
+	Ranges    int     `json:"ranges"`
+	Addresses int64   `json:"addresses"`
+	Cities    int     `json:"cities"`
+}
+
+type City struct {
+	Country   Country `json:"country"`
+	Ranges    int     `json:"ranges"`
+	Addresses int64   `json:"addresses"`
+	IPs       []*IP   `json:"ips"`
+}
+
+// Rows are the ranges, the CSV form leaves the totals out.
+func (c *City) Rows() interface{} {
+	return c.IPs
+}
+
+func ToRegionSummariesModel(entities []*ips.RegionSummary) []*RegionSummary {
+	output := make([]*RegionSummary, 0, len(entities))
+	for _, entity := range entities {
+		output = append(output, &RegionSummary{
+			Country:   Country{Code: entity.Country.Code, Name: entity.Country.Name, Region: entity.Country.Region},
+			Ranges:    entity.Ranges,
+			Addresses: entity.Addresses,
+			Cities:    entity.Cities,
+		})
+	}
+	return output
+}
+
+func ToCityTotalsModel(entities []*ips.CityTotal) []*CityTotal {
+	output := make([]*CityTotal, 0, len(entities))
+	for _, entity := range entities {
+		output = append(output, &CityTotal{
+			Country: Country{
+				Code:   entity.Country.Code,
+				Name:   entity.Country.Name,
+				Region: entity.Country.Region,
+				City:   entity.Country.City,
+			},
+			Ranges:    entity.Ranges,
+			Addresses: entity.Addresses,
+		})
+	}
+	return output
+}
+
+// ToCityModel redacts the ranges as ToIPModel, each one identified by its
+// first address.
+func ToCityModel(entity *ips.City, tier *auth.Tier) *City {
+	output := &City{
+		Country: Country{
+			Code:   entity.Total.Country.Code,
+			Name:   entity.Total.Country.Name,
+			Region: entity.Total.Country.Region,
+			City:   entity.Total.Country.City,
+		},
+		Ranges:    entity.Total.Ranges,
+		Addresses: entity.Total.Addresses,
+		IPs:       make([]*IP, 0, len(entity.Ranges)),
+	}
+	for _, ip := range entity.Ranges {
+		model := ToIPModel(conversion.DecimalToIPv4(ip.From), ip, tier)
+		output.IPs = append(output.IPs, model)
+	}
+	return output
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+)
+
+type Domain struct {
+	Domain    string          `json:"domain"`
+	Addresses int64           `json:"addresses"`
+	Ranges    []*IP           `json:"ranges"`
+	Countries []*CountryTotal `json:"countries"`
+	ISPs      []*ISPTotal     `json:"isps,omitempty"`
+}
+
+// Rows are the ranges, the CSV form leaves the totals out.
+func (d *Domain) Rows() interface{} {
+	return d.Ranges
+}
+
+type CountryTotal struct {
+	Country   Country `json:"country"`
+	Ranges    int     `json:"ranges"`
+	Addresses int64   `json:"addresses"`
+}
+
+type ISPTotal struct {
+	ISP       string `json:"isp"`
+	Ranges    int    `json:"ranges"`
+	Addresses int64  `json:"addresses"`
+}
+
+// ToDomainModel redacts the ranges as ToIPModel and leaves the ISP totals
+// out unless tier allows ISPs.
+func ToDomainModel(entity *ips.Domain, tier *auth.Tier) *Domain {
+	output := &Domain{
+		Domain:    entity.Name,
+		Addresses: entity.Addresses,
+		Ranges:    make([]*IP, 0, len(entity.Ranges)),
+		Countries: make([]*CountryTotal, 0, len(entity.Countries)),
+	}
+	for _, ip := range entity.Ranges {
+		model := ToIPModel(conversion.DecimalToIPv4(ip.From), ip, tier)
+		output.Ranges = append(output.Ranges, model)
+	}
+	for _, country := range entity.Countries {
+		output.Countries = append(output.Countries, &CountryTotal{
+			Country:   Country{Code: country.Country.Code, Name: country.Country.Name},
+			Ranges:    country.Ranges,
+			Addresses: country.Addresses,
+		})
+	}
+	if tier.Allows(auth.FieldISP) {
+		output.ISPs = make([]*ISPTotal, 0, len(entity.ISPs))
+		for _, isp := range entity.ISPs {
+			output.ISPs = append(output.ISPs, &ISPTotal{ISP: isp.ISP, Ranges: isp.Ranges, Addresses: isp.Addresses})
+		}
+	}
+	return output
+}
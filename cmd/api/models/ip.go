@@ -1,21 +1,29 @@
 package models
 
 import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
 	"github.com/mborroni/dreamlab-challenge/internal/conversion"
 	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
 )
 
 type IP struct {
-	IP        string  `json:"ip,omitempty"`
-	From      int64   `json:"from,omitempty"`
-	To        int64   `json:"to,omitempty"`
-	ProxyType string  `json:"proxy_type,omitempty"`
-	Country   Country `json:"country,omitempty"`
-	ISP       string  `json:"isp,omitempty"`
-	Domain    string  `json:"domain,omitempty"`
-	Usage     string  `json:"usage,omitempty"`
-	ASN       int     `json:"asn,omitempty"`
-	AS        string  `json:"as,omitempty"`
+	IP        string   `json:"ip,omitempty"`
+	From      int64    `json:"from,omitempty"`
+	To        int64    `json:"to,omitempty"`
+	ProxyType string   `json:"proxy_type,omitempty"`
+	Country   Country  `json:"country,omitempty"`
+	ISP       string   `json:"isp,omitempty"`
+	Domain    string   `json:"domain,omitempty"`
+	Usage     string   `json:"usage,omitempty"`
+	ASN       int      `json:"asn,omitempty"`
+	AS        string   `json:"as,omitempty"`
+	LastSeen  *int     `json:"last_seen,omitempty"`
+	Threat    string   `json:"threat,omitempty"`
+	Provider  string   `json:"provider,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	ZipCode   string   `json:"zip_code,omitempty"`
+	TimeZone  string   `json:"time_zone,omitempty"`
 }
 
 type Country struct {
@@ -25,34 +33,67 @@ type Country struct {
 	City   string `json:"city,omitempty"`
 }
 
-func ToIPModel(ip string, entity *ips.IP) *IP {
-	return &IP{
-		IP:        ip,
-		ProxyType: entity.ProxyType,
+// ToIPModel maps entity leaving out the fields tier is not licensed for, a
+// nil tier keeps them all. Fields the served product lacks are empty and
+// omitted as well. From and To bound the range where the returned data holds.
+func ToIPModel(ip string, entity *ips.IP, tier *auth.Tier) *IP {
+	output := &IP{
+		IP:   ip,
+		From: entity.From,
+		To:   entity.To,
 		Country: Country{
-			Code:   entity.Country.Code,
-			Name:   entity.Country.Name,
-			Region: entity.Country.Region,
-			City:   entity.Country.City,
+			Code: entity.Country.Code,
+			Name: entity.Country.Name,
 		},
-		ISP:    entity.ISP,
-		Domain: entity.Domain,
-		Usage:  entity.Usage,
-		ASN:    entity.ASN,
-		AS:     entity.AS,
 	}
+	if tier.Allows(auth.FieldRegion) {
+		output.Country.Region = entity.Country.Region
+	}
+	if tier.Allows(auth.FieldCity) {
+		output.Country.City = entity.Country.City
+	}
+	if tier.Allows(auth.FieldProxyType) {
+		output.ProxyType = entity.ProxyType
+	}
+	if tier.Allows(auth.FieldISP) {
+		output.ISP = entity.ISP
+	}
+	if tier.Allows(auth.FieldDomain) {
+		output.Domain = entity.Domain
+	}
+	if tier.Allows(auth.FieldUsage) {
+		output.Usage = entity.Usage
+	}
+	if tier.Allows(auth.FieldASN) {
+		output.ASN = entity.ASN
+		output.AS = entity.AS
+	}
+	if tier.Allows(auth.FieldLocation) {
+		output.Latitude = entity.Latitude
+		output.Longitude = entity.Longitude
+		output.ZipCode = entity.ZipCode
+		output.TimeZone = entity.TimeZone
+	}
+	if tier.Allows(auth.FieldThreat) {
+		output.LastSeen = entity.LastSeen
+		output.Threat = entity.Threat
+		output.Provider = entity.Provider
+	}
+	return output
 }
 
-func ToIPsModel(entities []*ips.IP) []*IP {
+func ToIPsModel(entities []*ips.IP, tier *auth.Tier) []*IP {
 	output := make([]*IP, 0)
 	for _, ip := range entities {
 		tmp := &IP{
 			IP: conversion.DecimalToIPv4(ip.From),
 			Country: Country{
 				Name: ip.Country.Name,
-				City: ip.Country.City,
 			},
 		}
+		if tier.Allows(auth.FieldCity) {
+			tmp.Country.City = ip.Country.City
+		}
 		output = append(output, tmp)
 	}
 	return output
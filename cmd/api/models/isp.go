@@ -0,0 +1,35 @@
+package models
+
+import (
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+)
+
+type ISPMatch struct {
+	ISP       string          `json:"isp"`
+	Score     float64         `json:"score"`
+	Ranges    int             `json:"ranges"`
+	Addresses int64           `json:"addresses"`
+	Countries []*CountryTotal `json:"countries"`
+}
+
+func ToISPMatchesModel(entities []*ips.ISPMatch) []*ISPMatch {
+	output := make([]*ISPMatch, 0, len(entities))
+	for _, entity := range entities {
+		match := &ISPMatch{
+			ISP:       entity.ISP,
+			Score:     entity.Score,
+			Ranges:    entity.Ranges,
+			Addresses: entity.Addresses,
+			Countries: make([]*CountryTotal, 0, len(entity.Countries)),
+		}
+		for _, country := range entity.Countries {
+			match.Countries = append(match.Countries, &CountryTotal{
+				Country:   Country{Code: country.Country.Code, Name: country.Country.Name},
+				Ranges:    country.Ranges,
+				Addresses: country.Addresses,
+			})
+		}
+		output = append(output, match)
+	}
+	return output
+}
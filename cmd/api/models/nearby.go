@@ -0,0 +1,52 @@
+package models
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+)
+
+type Nearby struct {
+	Ranges []*IP        `json:"ranges"`
+	Cities []*CityTotal `json:"cities"`
+}
+
+// Rows are the ranges, the CSV form leaves the city totals out.
+func (n *Nearby) Rows() interface{} {
+	return n.Ranges
+}
+
+type CityTotal struct {
+	Country   Country `json:"country"`
+	Ranges    int     `json:"ranges"`
+	Addresses int64   `json:"addresses"`
+}
+
+// ToNearbyModel redacts the ranges as ToIPModel, each one identified by its
+// first address. The city totals need the city field, callers check it.
+func ToNearbyModel(entity *ips.Nearby, tier *auth.Tier) *Nearby {
+	output := &Nearby{
+		Ranges: make([]*IP, 0, len(entity.Ranges)),
+		Cities: make([]*CityTotal, 0, len(entity.Cities)),
+	}
+	for _, ip := range entity.Ranges {
+		model := ToIPModel(conversion.DecimalToIPv4(ip.From), ip, tier)
+		output.Ranges = append(output.Ranges, model)
+	}
+	for _, city := range entity.Cities {
+		total := &CityTotal{
+			Country: Country{
+				Code: city.Country.Code,
+				Name: city.Country.Name,
+				City: city.Country.City,
+			},
+			Ranges:    city.Ranges,
+			Addresses: city.Addresses,
+		}
+		if tier.Allows(auth.FieldRegion) {
+			total.Country.Region = city.Country.Region
+		}
+		output.Cities = append(output.Cities, total)
+	}
+	return output
+}
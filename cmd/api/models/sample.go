@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+)
+
+type Sample struct {
+	Seed int64 `json:"seed"`
+	IPs  []*IP `json:"ips"`
+}
+
+// Rows are the addresses, the CSV form leaves the seed out.
+func (s *Sample) Rows() interface{} {
+	return s.IPs
+}
+
+// ToSampleModel redacts every address drawn as ToIPModel, along with the
+// seed that draws them again. Drawn addresses are single address ranges, so
+// From and To are left out.
+func ToSampleModel(seed int64, entities []*ips.IP, tier *auth.Tier) *Sample {
+	output := &Sample{
+		Seed: seed,
+		IPs:  make([]*IP, 0, len(entities)),
+	}
+	for _, ip := range entities {
+		model := ToIPModel(conversion.DecimalToIPv4(ip.From), ip, tier)
+		model.From, model.To = 0, 0
+		output.IPs = append(output.IPs, model)
+	}
+	return output
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+type Consumption struct {
+	Requests  int64 `json:"requests"`
+	Addresses int64 `json:"addresses"`
+	Rows      int64 `json:"rows"`
+}
+
+type UsageEntry struct {
+	Day      string `json:"day"`
+	Endpoint string `json:"endpoint"`
+	Consumption
+}
+
+type Quota struct {
+	Requests int64       `json:"requests,omitempty"`
+	Rows     int64       `json:"rows,omitempty"`
+	Used     Consumption `json:"used"`
+	ResetsAt time.Time   `json:"resets_at"`
+}
+
+type Usage struct {
+	Client  string        `json:"client"`
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Total   Consumption   `json:"total"`
+	Quota   Quota         `json:"quota"`
+	Entries []*UsageEntry `json:"entries"`
+}
+
+// Rows are the entries, the CSV form leaves the totals and quota out.
+func (u *Usage) Rows() interface{} {
+	return u.Entries
+}
+
+func ToUsageModel(report *usage.Report, status *usage.QuotaStatus) *Usage {
+	entries := make([]*UsageEntry, 0)
+	for _, entry := range report.Entries {
+		entries = append(entries, &UsageEntry{
+			Day:         entry.Day.Format(dayLayout),
+			Endpoint:    entry.Endpoint,
+			Consumption: toConsumptionModel(entry.Consumption),
+		})
+	}
+	return &Usage{
+		Client: report.ClientID,
+		From:   report.From.Format(dayLayout),
+		To:     report.To.Format(dayLayout),
+		Total:  toConsumptionModel(report.Total),
+		Quota: Quota{
+			Requests: status.Quota.Requests,
+			Rows:     status.Quota.Rows,
+			Used:     toConsumptionModel(status.Used),
+			ResetsAt: status.ResetsAt,
+		},
+		Entries: entries,
+	}
+}
+
+func toConsumptionModel(consumption usage.Consumption) Consumption {
+	return Consumption{
+		Requests:  consumption.Requests,
+		Addresses: consumption.Addresses,
+		Rows:      consumption.Rows,
+	}
+}
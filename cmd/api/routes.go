@@ -3,26 +3,126 @@ package main
 import (
 	"fmt"
 	"github.com/go-chi/chi/v5"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/gql"
 	"github.com/mborroni/dreamlab-challenge/cmd/api/handlers"
 	"github.com/mborroni/dreamlab-challenge/cmd/api/middleware"
 	"github.com/mborroni/dreamlab-challenge/internal/application"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/metrics"
 	"net/http"
 	"strings"
 )
 
-func routes(router *chi.Mux, engine *application.Engine) {
+// Rate limit costs in tokens, aggregations scan every range of a country while
+// a lookup reads a single row.
+const (
+	lookupCost    = 1
+	listCost      = 5
+	aggregateCost = 10
+)
+
+// graphqlLimits keeps a single GraphQL query around the cost of a full
+// page of lookups or a few country aggregates, each priced like its REST
+// route.
+var graphqlLimits = gql.Limits{MaxComplexity: 500, MaxDepth: 6, AggregateCost: aggregateCost * graphqlComplexityPerToken}
+
+// graphqlComplexityPerToken prices GraphQL queries, the most complex one
+// accepted costs 50 tokens and the simplest ones 1.
+const graphqlComplexityPerToken = 10
+
+func routes(router *chi.Mux, engine *application.Engine) error {
 	router.Get("/ping", Ping)
+	router.Handle("/metrics", metrics.Handler())
+
+	healthHandler := handlers.NewHealthHandler(engine.Health)
+	router.With(middleware.Negotiation).Get("/health/live", healthHandler.Live)
+	router.With(middleware.Negotiation).Get("/health/ready", healthHandler.Ready)
+
+	authentication := middleware.Authentication(engine.KeysService, nil)
+	if engine.TokenValidator != nil {
+		authentication = middleware.Authentication(engine.KeysService, engine.TokenValidator)
+	}
+	rateLimit := func(cost int) func(http.Handler) http.Handler {
+		if engine.Limiter == nil {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return middleware.RateLimit(engine.Limiter, cost)
+	}
+	// clientRateLimit runs before authentication, so it throttles callers by
+	// IP and floods of bad keys don't reach the key lookups.
+	clientRateLimit := rateLimit(lookupCost)
+	// quota runs after the scope and rate limit checks, requests they reject
+	// don't need their quota checked.
+	quota := middleware.Usage(engine.UsageService)
+	dataTier := func(next http.Handler) http.Handler { return next }
+	if engine.Tiers != nil {
+		dataTier = middleware.DataTier(engine.Tiers)
+	}
 
 	handler := handlers.NewAddressesHandler(engine.AddressesService)
 	router.Route("/v1/ips", func(r chi.Router) {
-		r.Get("/", handler.List)
+		r.Use(middleware.Negotiation, clientRateLimit, authentication, dataTier)
+		r.With(middleware.RequireScope(auth.ScopeExport), rateLimit(listCost), quota).Get("/", handler.List)
+		r.With(middleware.RequireScope(auth.ScopeExport), rateLimit(listCost), quota).Get("/sample", handler.Sample)
 		r.Route("/{IP}", func(r chi.Router) {
-			r.With(middleware.IPValidation).Get("/", handler.Get)
+			r.With(middleware.RequireScope(auth.ScopeLookup), rateLimit(lookupCost), middleware.IPValidation, quota).
+				Get("/", handler.Get)
+		})
+		r.With(middleware.RequireScope(auth.ScopeAggregate), rateLimit(aggregateCost), quota).
+			Get("/quantity", handler.GetIPQuantityByCountry)
+		r.With(middleware.RequireScope(auth.ScopeAggregate), rateLimit(aggregateCost), quota).
+			Get("/isps/top", handler.GetTop10ISPByCountry)
+		r.With(middleware.RequireScope(auth.ScopeExport), rateLimit(aggregateCost), quota).
+			Get("/near", handler.Near)
+	})
+
+	router.With(middleware.Negotiation, clientRateLimit, authentication, dataTier,
+		middleware.RequireScope(auth.ScopeExport), rateLimit(aggregateCost), quota).
+		Get("/v1/domains/{domain}", handler.GetByDomain)
+
+	router.With(middleware.Negotiation, clientRateLimit, authentication, dataTier,
+		middleware.RequireScope(auth.ScopeAggregate), rateLimit(aggregateCost), quota).
+		Get("/v1/isps", handler.SearchISPs)
+
+	countriesHandler := handlers.NewCountriesHandler(engine.AddressesService)
+	router.Route("/v1/countries", func(r chi.Router) {
+		r.Use(middleware.Negotiation, clientRateLimit, authentication, dataTier)
+		r.With(middleware.RequireScope(auth.ScopeAggregate), rateLimit(aggregateCost), quota).Get("/", countriesHandler.List)
+		r.Route("/{code}", func(r chi.Router) {
+			r.With(middleware.RequireScope(auth.ScopeAggregate), rateLimit(aggregateCost), quota).
+				Get("/", countriesHandler.Get)
+			r.With(middleware.RequireScope(auth.ScopeAggregate), rateLimit(aggregateCost), quota).
+				Get("/regions", countriesHandler.ListRegions)
+			r.With(middleware.RequireScope(auth.ScopeAggregate), rateLimit(aggregateCost), quota).
+				Get("/regions/{region}/cities", countriesHandler.ListCities)
+			r.With(middleware.RequireScope(auth.ScopeExport), rateLimit(aggregateCost), quota).
+				Get("/regions/{region}/cities/{city}/ips", countriesHandler.GetCity)
 		})
-		r.Get("/quantity", handler.GetIPQuantityByCountry)
-		r.Get("/isps/top", handler.GetTop10ISPByCountry)
 	})
+
+	usageHandler := handlers.NewUsageHandler(engine.UsageService)
+	router.With(middleware.Negotiation, clientRateLimit, authentication).Get("/v1/usage", usageHandler.Get)
+
+	keysHandler := handlers.NewAPIKeysHandler(engine.KeysService)
+	router.Route("/v1/admin/keys", func(r chi.Router) {
+		r.Use(middleware.Negotiation, clientRateLimit, authentication, middleware.RequireScope(auth.ScopeAdmin))
+		r.Get("/", keysHandler.List)
+		r.Post("/", keysHandler.Create)
+		r.Post("/{ID}/rotate", keysHandler.Rotate)
+		r.Delete("/{ID}", keysHandler.Revoke)
+	})
+	graphqlHandler, err := gql.NewHandler(engine.AddressesService, graphqlLimits,
+		func(complexity int) func(http.Handler) http.Handler {
+			cost := (complexity + graphqlComplexityPerToken - 1) / graphqlComplexityPerToken
+			return chi.Chain(rateLimit(cost), quota).Handler
+		})
+	if err != nil {
+		return err
+	}
+	router.With(clientRateLimit, authentication, dataTier).Handle("/graphql", graphqlHandler)
+
 	printRoutes(router)
+	return nil
 }
 
 func Ping(w http.ResponseWriter, r *http.Request) {
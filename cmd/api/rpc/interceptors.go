@@ -0,0 +1,234 @@
+package rpc
+
+import (
+	"context"
+	ipsv1 "github.com/mborroni/dreamlab-challenge/api/ips/v1"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/ratelimit"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	APIKeyMetadata   = "x-api-key"
+	DataTierMetadata = "x-data-tier"
+	bearerPrefix     = "Bearer "
+)
+
+type authenticator interface {
+	Authenticate(context.Context, string) (*auth.Principal, error)
+}
+
+type limiter interface {
+	Allow(context.Context, string, int) (*ratelimit.Result, error)
+}
+
+type usageTracker interface {
+	CheckQuota(context.Context, string) (*usage.QuotaStatus, error)
+	Record(context.Context, string, string, usage.Consumption) error
+}
+
+// policy is what an RPC requires, the same scopes and costs as REST. RPCs
+// with lookups are charged cost for each address in the request, and each
+// one counts as a request for the quota.
+type policy struct {
+	scope   string
+	cost    int
+	lookups func(req interface{}) int
+}
+
+var policies = map[string]policy{
+	ipsv1.IPAddresses_Lookup_FullMethodName:                 {scope: auth.ScopeLookup, cost: 1},
+	ipsv1.IPAddresses_BatchLookup_FullMethodName:            {scope: auth.ScopeLookup, cost: 1, lookups: batchSize},
+	ipsv1.IPAddresses_List_FullMethodName:                   {scope: auth.ScopeExport, cost: 5},
+	ipsv1.IPAddresses_GetIPQuantityByCountry_FullMethodName: {scope: auth.ScopeAggregate, cost: 10},
+	ipsv1.IPAddresses_GetTop10ISPByCountry_FullMethodName:   {scope: auth.ScopeAggregate, cost: 10},
+}
+
+// batchSize is the number of addresses in a BatchLookup, at least 1 so empty
+// batches are still charged.
+func batchSize(req interface{}) int {
+	if request, ok := req.(*ipsv1.BatchLookupRequest); ok && len(request.GetIps()) > 1 {
+		return len(request.GetIps())
+	}
+	return 1
+}
+
+// Guard applies to the gRPC API what the /v1/ips middlewares apply to REST:
+// authentication, scopes, data tiers, rate limiting and usage quotas. RPCs
+// without a policy, like health and reflection, are served as they are.
+type Guard struct {
+	keys    authenticator
+	tokens  authenticator
+	tiers   *auth.Tiers
+	limiter limiter
+	tracker usageTracker
+}
+
+func NewGuard(keys authenticator, tracker usageTracker) *Guard {
+	return &Guard{
+		keys:    keys,
+		tracker: tracker,
+	}
+}
+
+// WithTokens accepts bearer tokens in the authorization metadata.
+func (g *Guard) WithTokens(tokens authenticator) *Guard {
+	g.tokens = tokens
+	return g
+}
+
+func (g *Guard) WithTiers(tiers *auth.Tiers) *Guard {
+	g.tiers = tiers
+	return g
+}
+
+func (g *Guard) WithLimiter(limiter limiter) *Guard {
+	g.limiter = limiter
+	return g
+}
+
+func (g *Guard) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, done, err := g.authorize(ctx, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		done(err)
+		return resp, err
+	}
+}
+
+func (g *Guard) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, done, err := g.authorize(stream.Context(), info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		err = handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		done(err)
+		return err
+	}
+}
+
+// authorize returns the context the RPC runs with and a function recording
+// its consumption once it returns. req is nil for streaming RPCs.
+func (g *Guard) authorize(ctx context.Context, method string, req interface{}) (context.Context, func(error), error) {
+	noop := func(error) {}
+	policy, ok := policies[method]
+	if !ok {
+		return ctx, noop, nil
+	}
+	principal, err := g.authenticate(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !principal.HasScope(policy.scope) {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "%s: %s required", auth.ErrForbidden, policy.scope)
+	}
+	ctx = auth.WithPrincipal(ctx, principal)
+	if g.tiers != nil {
+		tier := g.tiers.For(principal)
+		ctx = auth.WithTier(ctx, tier)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(DataTierMetadata, tier.Name))
+	}
+	lookups := 1
+	if policy.lookups != nil {
+		lookups = policy.lookups(req)
+	}
+	if err := g.rateLimit(ctx, principal, policy.cost*lookups); err != nil {
+		return nil, nil, err
+	}
+
+	quota, err := g.tracker.CheckQuota(ctx, principal.ID)
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "check quota"}).
+			Error(err)
+		return nil, nil, status.Error(codes.Internal, "check quota")
+	}
+	if quota.Exceeded() {
+		return nil, nil, status.Errorf(codes.ResourceExhausted, "monthly quota exceeded, resets at %s",
+			quota.ResetsAt.Format("2006-01-02T15:04:05Z07:00"))
+	}
+	ctx = usage.WithCounter(ctx)
+	return ctx, func(err error) {
+		if code := status.Code(err); code != codes.OK && code != codes.NotFound {
+			return
+		}
+		consumption := usage.Counted(ctx)
+		consumption.Requests = int64(lookups)
+		if err := g.tracker.Record(context.WithoutCancel(ctx), principal.ID, "RPC "+method, consumption); err != nil {
+			log.WithContext(ctx).
+				WithFields(log.Fields{"event": "record usage"}).
+				Error(err)
+		}
+	}, nil
+}
+
+func (g *Guard) authenticate(ctx context.Context) (*auth.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authenticator, credential := g.keys, first(md, APIKeyMetadata)
+	if header := first(md, "authorization"); g.tokens != nil && strings.HasPrefix(header, bearerPrefix) {
+		authenticator, credential = g.tokens, strings.TrimPrefix(header, bearerPrefix)
+	}
+	principal, err := authenticator.Authenticate(ctx, credential)
+	if err != nil {
+		if err == auth.ErrUnauthenticated {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "authenticate"}).
+			Error(err)
+		return nil, status.Error(codes.Internal, "authenticate")
+	}
+	return principal, nil
+}
+
+// rateLimit fails open like the REST middleware.
+func (g *Guard) rateLimit(ctx context.Context, principal *auth.Principal, cost int) error {
+	if g.limiter == nil {
+		return nil
+	}
+	result, err := g.limiter.Allow(ctx, principal.ID, cost)
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{"event": "rate limit"}).
+			Error(err)
+		return nil
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(result.Limit),
+		"ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"ratelimit-reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))),
+	))
+	if !result.Allowed {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %ds",
+			int(math.Ceil(result.RetryAfter.Seconds())))
+	}
+	return nil
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,219 @@
+package rpc
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	ipsv1 "github.com/mborroni/dreamlab-challenge/api/ips/v1"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/ratelimit"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+type authenticatorFunc func(context.Context, string) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, credential string) (*auth.Principal, error) {
+	return f(ctx, credential)
+}
+
+type fakeTracker struct {
+	mu       sync.Mutex
+	exceeded bool
+	recorded map[string]usage.Consumption
+}
+
+func (f *fakeTracker) CheckQuota(context.Context, string) (*usage.QuotaStatus, error) {
+	if f.exceeded {
+		return &usage.QuotaStatus{Quota: usage.Quota{Requests: 1}, Used: usage.Consumption{Requests: 1}}, nil
+	}
+	return &usage.QuotaStatus{}, nil
+}
+
+func (f *fakeTracker) Record(_ context.Context, clientID string, endpoint string, consumption usage.Consumption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recorded[clientID+" "+endpoint] = consumption
+	return nil
+}
+
+type limiterFunc func(context.Context, string, int) (*ratelimit.Result, error)
+
+func (f limiterFunc) Allow(ctx context.Context, key string, cost int) (*ratelimit.Result, error) {
+	return f(ctx, key, cost)
+}
+
+var keys = authenticatorFunc(func(ctx context.Context, secret string) (*auth.Principal, error) {
+	switch secret {
+	case "lookup-key":
+		return &auth.Principal{ID: "key:1", Scopes: []string{auth.ScopeLookup, auth.ScopeExport}, Tier: "full"}, nil
+	case "country-key":
+		return &auth.Principal{ID: "key:2", Scopes: []string{auth.ScopeLookup}}, nil
+	}
+	return nil, auth.ErrUnauthenticated
+})
+
+// dial serves guard and service over an in-memory listener.
+func dial(t *testing.T, guard *Guard, service service) ipsv1.IPAddressesClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(guard.Unary()), grpc.ChainStreamInterceptor(guard.Stream()))
+	ipsv1.RegisterIPAddressesServer(server, NewAddressesServer(service))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return ipsv1.NewIPAddressesClient(conn)
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadata, key)
+}
+
+func TestGuard_Unary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewMockservice(ctrl)
+	service.EXPECT().Get(gomock.Any(), "181.192.10.182").Return(buenosAires, nil).AnyTimes()
+	tiers, _ := auth.ParseTiers("country:;full:*", "country")
+	tracker := &fakeTracker{recorded: make(map[string]usage.Consumption)}
+	client := dial(t, NewGuard(keys, tracker).WithTiers(tiers), service)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+		isp  string
+		tier string
+	}{
+		{name: "full tier", ctx: withKey("lookup-key"), code: codes.OK, isp: "CTL LATAM", tier: "full"},
+		{name: "default tier", ctx: withKey("country-key"), code: codes.OK, tier: "country"},
+		{name: "missing key", ctx: context.Background(), code: codes.Unauthenticated},
+		{name: "invalid key", ctx: withKey("other-key"), code: codes.Unauthenticated},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var header metadata.MD
+			ip, err := client.Lookup(tc.ctx, &ipsv1.LookupRequest{Ip: "181.192.10.182"}, grpc.Header(&header))
+
+			assert.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.OK {
+				assert.Equal(t, tc.isp, ip.GetIsp())
+				assert.Equal(t, []string{tc.tier}, header.Get(DataTierMetadata))
+			}
+		})
+	}
+	assert.Equal(t, usage.Consumption{Requests: 1, Addresses: 1},
+		tracker.recorded["key:1 RPC "+ipsv1.IPAddresses_Lookup_FullMethodName])
+}
+
+func TestGuard_Scope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tracker := &fakeTracker{recorded: make(map[string]usage.Consumption)}
+	client := dial(t, NewGuard(keys, tracker), NewMockservice(ctrl))
+
+	_, err := client.GetIPQuantityByCountry(withKey("lookup-key"), &ipsv1.CountryRequest{Country: "Argentina"})
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestGuard_Limits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("rate limited", func(t *testing.T) {
+		limiter := limiterFunc(func(ctx context.Context, key string, cost int) (*ratelimit.Result, error) {
+			assert.Equal(t, "key:1", key)
+			assert.Equal(t, 1, cost)
+			return &ratelimit.Result{Allowed: false, Limit: 10}, nil
+		})
+		tracker := &fakeTracker{recorded: make(map[string]usage.Consumption)}
+		client := dial(t, NewGuard(keys, tracker).WithLimiter(limiter), NewMockservice(ctrl))
+
+		_, err := client.Lookup(withKey("lookup-key"), &ipsv1.LookupRequest{Ip: "181.192.10.182"})
+
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("batch charged per address", func(t *testing.T) {
+		service := NewMockservice(ctrl)
+		service.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+		limiter := limiterFunc(func(ctx context.Context, key string, cost int) (*ratelimit.Result, error) {
+			assert.Equal(t, 3, cost)
+			return &ratelimit.Result{Allowed: true, Limit: 10}, nil
+		})
+		tracker := &fakeTracker{recorded: make(map[string]usage.Consumption)}
+		client := dial(t, NewGuard(keys, tracker).WithLimiter(limiter), service)
+
+		_, err := client.BatchLookup(withKey("lookup-key"), &ipsv1.BatchLookupRequest{Ips: []string{"10.0.0.1", "10.0.0.2", "nope"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, usage.Consumption{Requests: 3},
+			tracker.recorded["key:1 RPC "+ipsv1.IPAddresses_BatchLookup_FullMethodName])
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		tracker := &fakeTracker{exceeded: true, recorded: make(map[string]usage.Consumption)}
+		client := dial(t, NewGuard(keys, tracker), NewMockservice(ctrl))
+
+		_, err := client.Lookup(withKey("lookup-key"), &ipsv1.LookupRequest{Ip: "181.192.10.182"})
+
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Empty(t, tracker.recorded)
+	})
+}
+
+func TestGuard_Stream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewMockservice(ctrl)
+	service.EXPECT().List(gomock.Any(), 2, map[string]interface{}{"country": "Argentina"}).
+		Return([]*ips.IP{
+			{From: 3232235777, To: 3232235777, Country: ips.Country{Name: "Argentina", City: "Cordoba"}},
+			{From: 3232235778, To: 3232235778, Country: ips.Country{Name: "Argentina", City: "Rosario"}},
+		}, nil)
+	tracker := &fakeTracker{recorded: make(map[string]usage.Consumption)}
+	client := dial(t, NewGuard(keys, tracker), service)
+
+	stream, err := client.List(withKey("lookup-key"), &ipsv1.ListRequest{Country: "argentina", Limit: 2})
+	assert.NoError(t, err)
+	cities := make([]string, 0)
+	for {
+		ip, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		cities = append(cities, ip.GetCountry().GetCity())
+	}
+
+	assert.Equal(t, []string{"Cordoba", "Rosario"}, cities)
+	assert.Equal(t, usage.Consumption{Requests: 1, Rows: 2},
+		tracker.recorded["key:1 RPC "+ipsv1.IPAddresses_List_FullMethodName])
+
+	stream, err = client.List(withKey("country-key"), &ipsv1.ListRequest{Country: "argentina"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
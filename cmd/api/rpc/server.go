@@ -0,0 +1,186 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	ipsv1 "github.com/mborroni/dreamlab-challenge/api/ips/v1"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/metrics"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+//go:generate mockgen -source=server.go -destination=server_mock.go -package=rpc
+
+const (
+	defaultLimit  = 100
+	maxListLimit  = 1000
+	maxBatchLimit = 100
+)
+
+type service interface {
+	List(context.Context, int, map[string]interface{}) ([]*ips.IP, error)
+	Get(context.Context, string) (*ips.IP, error)
+	GetTop10ISPByCountry(context.Context, string) ([]string, error)
+	GetIPQuantityByCountry(context.Context, string) (int, error)
+}
+
+// AddressesServer serves the gRPC API on top of the same service as the
+// REST handlers, redacting fields by the tier in the context as they do.
+type AddressesServer struct {
+	ipsv1.UnimplementedIPAddressesServer
+	service service
+}
+
+func NewAddressesServer(service service) *AddressesServer {
+	return &AddressesServer{
+		service: service,
+	}
+}
+
+func (s *AddressesServer) Lookup(ctx context.Context, request *ipsv1.LookupRequest) (*ipsv1.IP, error) {
+	if _, err := conversion.IPv4ToDecimal(request.GetIp()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ip, err := s.service.Get(ctx, request.GetIp())
+	if err != nil {
+		return nil, internal(ctx, "get ip address", err)
+	}
+	metrics.ObserveLookup(ip != nil)
+	if ip == nil {
+		return nil, status.Errorf(codes.NotFound, "%s not found", request.GetIp())
+	}
+	usage.AddAddresses(ctx, 1)
+	return toIP(models.ToIPModel(request.GetIp(), ip, auth.TierFromContext(ctx))), nil
+}
+
+func (s *AddressesServer) BatchLookup(ctx context.Context, request *ipsv1.BatchLookupRequest) (*ipsv1.BatchLookupResponse, error) {
+	if len(request.GetIps()) > maxBatchLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d addresses per batch", maxBatchLimit)
+	}
+	tier := auth.TierFromContext(ctx)
+	response := &ipsv1.BatchLookupResponse{Results: make([]*ipsv1.LookupResult, 0, len(request.GetIps()))}
+	for _, input := range request.GetIps() {
+		result := &ipsv1.LookupResult{Ip: input}
+		response.Results = append(response.Results, result)
+		if _, err := conversion.IPv4ToDecimal(input); err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		ip, err := s.service.Get(ctx, input)
+		if err != nil {
+			return nil, internal(ctx, "batch lookup", err)
+		}
+		metrics.ObserveLookup(ip != nil)
+		if ip == nil {
+			continue
+		}
+		usage.AddAddresses(ctx, 1)
+		result.Found = true
+		result.Address = toIP(models.ToIPModel(input, ip, tier))
+	}
+	return response, nil
+}
+
+func (s *AddressesServer) List(request *ipsv1.ListRequest, stream ipsv1.IPAddresses_ListServer) error {
+	ctx := stream.Context()
+	limit := int(request.GetLimit())
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxListLimit {
+		return status.Errorf(codes.InvalidArgument, "limit must be at most %d", maxListLimit)
+	}
+	filters := make(map[string]interface{})
+	if request.GetCountry() != "" {
+		filters["country"] = strings.Title(request.GetCountry())
+	}
+	ipAddresses, err := s.service.List(ctx, limit, filters)
+	if err != nil {
+		return internal(ctx, "error listing", err)
+	}
+	for _, ip := range models.ToIPsModel(ipAddresses, auth.TierFromContext(ctx)) {
+		if err := stream.Send(toIP(ip)); err != nil {
+			return err
+		}
+		usage.AddRows(ctx, 1)
+	}
+	return nil
+}
+
+func (s *AddressesServer) GetIPQuantityByCountry(ctx context.Context, request *ipsv1.CountryRequest) (*ipsv1.CountryQuantity, error) {
+	country := strings.Title(request.GetCountry())
+	if country == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing country")
+	}
+	quantity, err := s.service.GetIPQuantityByCountry(ctx, country)
+	if err != nil {
+		return nil, internal(ctx, "get ip quantity by country", err)
+	}
+	return &ipsv1.CountryQuantity{Country: country, Quantity: int64(quantity)}, nil
+}
+
+func (s *AddressesServer) GetTop10ISPByCountry(ctx context.Context, request *ipsv1.CountryRequest) (*ipsv1.TopISPs, error) {
+	if !auth.TierFromContext(ctx).Allows(auth.FieldISP) {
+		return nil, status.Error(codes.PermissionDenied, "isp data is not included in your tier")
+	}
+	country := strings.Title(request.GetCountry())
+	if country == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing country")
+	}
+	isps, err := s.service.GetTop10ISPByCountry(ctx, country)
+	var columnErr *ips.ColumnError
+	if errors.As(err, &columnErr) {
+		return nil, status.Error(codes.Unimplemented, columnErr.Error())
+	}
+	if err != nil {
+		return nil, internal(ctx, "get top 10 ISPs", err)
+	}
+	return &ipsv1.TopISPs{Country: country, Isps: isps}, nil
+}
+
+// internal logs err and hides it from the client, it may carry queries and
+// driver details.
+func internal(ctx context.Context, event string, err error) error {
+	log.WithContext(ctx).
+		WithFields(log.Fields{"event": event}).
+		Error(err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func toIP(ip *models.IP) *ipsv1.IP {
+	output := &ipsv1.IP{
+		Ip:        ip.IP,
+		From:      ip.From,
+		To:        ip.To,
+		ProxyType: ip.ProxyType,
+		Country: &ipsv1.Country{
+			Code:   ip.Country.Code,
+			Name:   ip.Country.Name,
+			Region: ip.Country.Region,
+			City:   ip.Country.City,
+		},
+		Isp:       ip.ISP,
+		Domain:    ip.Domain,
+		Usage:     ip.Usage,
+		Asn:       int32(ip.ASN),
+		As:        ip.AS,
+		Latitude:  ip.Latitude,
+		Longitude: ip.Longitude,
+		ZipCode:   ip.ZipCode,
+		TimeZone:  ip.TimeZone,
+		Threat:    ip.Threat,
+		Provider:  ip.Provider,
+	}
+	if ip.LastSeen != nil {
+		lastSeen := int32(*ip.LastSeen)
+		output.LastSeen = &lastSeen
+	}
+	return output
+}
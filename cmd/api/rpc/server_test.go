@@ -0,0 +1,214 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	ipsv1 "github.com/mborroni/dreamlab-challenge/api/ips/v1"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func newMockAddressesServer(ctrl *gomock.Controller) *AddressesServer {
+	return NewAddressesServer(NewMockservice(ctrl))
+}
+
+var buenosAires = &ips.IP{
+	ProxyType: "PUB",
+	Country: ips.Country{
+		Code:   "AR",
+		Name:   "Argentina",
+		Region: "Ciudad Autonoma de Buenos Aires",
+		City:   "Buenos Aires",
+	},
+	ISP:    "CTL LATAM",
+	Domain: "ctl.com",
+	ASN:    3549,
+	AS:     "Level 3 Parent LLC",
+}
+
+func TestAddressesServer_Lookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newMockAddressesServer(ctrl)
+
+	tests := []struct {
+		name         string
+		ip           string
+		expectations func()
+		code         codes.Code
+	}{
+		{
+			name: "ok",
+			ip:   "181.192.10.182",
+			expectations: func() {
+				server.service.(*Mockservice).EXPECT().Get(gomock.Any(), "181.192.10.182").Return(buenosAires, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name: "not found",
+			ip:   "10.0.0.1",
+			expectations: func() {
+				server.service.(*Mockservice).EXPECT().Get(gomock.Any(), "10.0.0.1").Return(nil, nil)
+			},
+			code: codes.NotFound,
+		},
+		{name: "invalid", ip: "181.192", expectations: func() {}, code: codes.InvalidArgument},
+		{
+			name: "error",
+			ip:   "181.192.10.182",
+			expectations: func() {
+				server.service.(*Mockservice).EXPECT().Get(gomock.Any(), "181.192.10.182").Return(nil, errors.New("error"))
+			},
+			code: codes.Internal,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+
+			ip, err := server.Lookup(context.Background(), &ipsv1.LookupRequest{Ip: tc.ip})
+
+			assert.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.Internal {
+				assert.Equal(t, "internal error", status.Convert(err).Message())
+			}
+			if tc.code == codes.OK {
+				assert.Equal(t, "CTL LATAM", ip.GetIsp())
+				assert.Equal(t, "Buenos Aires", ip.GetCountry().GetCity())
+			}
+		})
+	}
+}
+
+func TestAddressesServer_BatchLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newMockAddressesServer(ctrl)
+	server.service.(*Mockservice).EXPECT().Get(gomock.Any(), "181.192.10.182").Return(buenosAires, nil)
+	server.service.(*Mockservice).EXPECT().Get(gomock.Any(), "10.0.0.1").Return(nil, nil)
+	tier, _ := auth.NewTier("country", nil)
+	ctx := auth.WithTier(context.Background(), tier)
+
+	response, err := server.BatchLookup(ctx, &ipsv1.BatchLookupRequest{Ips: []string{"181.192.10.182", "10.0.0.1", "nope"}})
+
+	assert.NoError(t, err)
+	results := response.GetResults()
+	assert.Len(t, results, 3)
+	assert.True(t, results[0].GetFound())
+	assert.Equal(t, "Argentina", results[0].GetAddress().GetCountry().GetName())
+	assert.Empty(t, results[0].GetAddress().GetIsp(), "redacted by tier")
+	assert.False(t, results[1].GetFound())
+	assert.Empty(t, results[1].GetError())
+	assert.NotEmpty(t, results[2].GetError())
+}
+
+type listStream struct {
+	ipsv1.IPAddresses_ListServer
+	sent []*ipsv1.IP
+}
+
+func (s *listStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *listStream) Send(ip *ipsv1.IP) error {
+	s.sent = append(s.sent, ip)
+	return nil
+}
+
+func TestAddressesServer_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newMockAddressesServer(ctrl)
+
+	tests := []struct {
+		name         string
+		limit        int32
+		expectations func()
+		code         codes.Code
+		sent         int
+	}{
+		{
+			name:  "default limit",
+			limit: 0,
+			expectations: func() {
+				server.service.(*Mockservice).EXPECT().List(gomock.Any(), defaultLimit, gomock.Any()).
+					Return([]*ips.IP{buenosAires}, nil)
+			},
+			code: codes.OK,
+			sent: 1,
+		},
+		{name: "limit too high", limit: maxListLimit + 1, expectations: func() {}, code: codes.InvalidArgument},
+		{
+			name:  "error",
+			limit: 10,
+			expectations: func() {
+				server.service.(*Mockservice).EXPECT().List(gomock.Any(), 10, gomock.Any()).
+					Return(nil, errors.New("pq: relation does not exist"))
+			},
+			code: codes.Internal,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			stream := &listStream{}
+
+			err := server.List(&ipsv1.ListRequest{Limit: tc.limit}, stream)
+
+			assert.Equal(t, tc.code, status.Code(err))
+			assert.Len(t, stream.sent, tc.sent)
+			if tc.code == codes.Internal {
+				assert.Equal(t, "internal error", status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestAddressesServer_GetTop10ISPByCountry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newMockAddressesServer(ctrl)
+	server.service.(*Mockservice).EXPECT().GetTop10ISPByCountry(gomock.Any(), "Switzerland").
+		Return([]string{"Swisscom AG"}, nil)
+
+	top, err := server.GetTop10ISPByCountry(context.Background(), &ipsv1.CountryRequest{Country: "switzerland"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Swisscom AG"}, top.GetIsps())
+
+	tier, _ := auth.NewTier("country", nil)
+	_, err = server.GetTop10ISPByCountry(auth.WithTier(context.Background(), tier), &ipsv1.CountryRequest{Country: "Switzerland"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = server.GetTop10ISPByCountry(context.Background(), &ipsv1.CountryRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	server.service.(*Mockservice).EXPECT().GetTop10ISPByCountry(gomock.Any(), "Argentina").
+		Return(nil, &ips.ColumnError{Product: "DB3", Column: ips.ColumnISP})
+	_, err = server.GetTop10ISPByCountry(context.Background(), &ipsv1.CountryRequest{Country: "Argentina"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestAddressesServer_GetIPQuantityByCountry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newMockAddressesServer(ctrl)
+	server.service.(*Mockservice).EXPECT().GetIPQuantityByCountry(gomock.Any(), "Argentina").Return(42, nil)
+
+	quantity, err := server.GetIPQuantityByCountry(context.Background(), &ipsv1.CountryRequest{Country: "argentina"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ipsv1.CountryQuantity{Country: "Argentina", Quantity: 42}, quantity)
+}
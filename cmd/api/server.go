@@ -3,12 +3,15 @@ package main
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	apimiddleware "github.com/mborroni/dreamlab-challenge/cmd/api/middleware"
 )
 
 func newServer() (*chi.Mux, error) {
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
-	router.Use(middleware.Logger)
+	router.Use(apimiddleware.Tracing)
+	router.Use(apimiddleware.Metrics)
+	router.Use(apimiddleware.Logging)
 	router.Use(middleware.Recoverer)
 	return router, nil
 }
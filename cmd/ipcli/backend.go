@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// batchSize matches the most addresses the API resolves in one call.
+const batchSize = 100
+
+// backend answers the subcommands either from the database or a remote API,
+// in the API representation in both cases.
+type backend interface {
+	// Lookup returns the addresses found, keyed by input.
+	Lookup(context.Context, []string) (map[string]*models.IP, error)
+	List(context.Context, string, int) ([]*models.IP, error)
+	Quantity(context.Context, string) (int, error)
+	Top(context.Context, string) ([]string, error)
+}
+
+type addressesService interface {
+	List(context.Context, int, map[string]interface{}) ([]*ips.IP, error)
+	GetMany(context.Context, []string) (map[string]*ips.IP, error)
+	GetTop10ISPByCountry(context.Context, string) ([]string, error)
+	GetIPQuantityByCountry(context.Context, string) (int, error)
+}
+
+// serviceBackend queries the configured database through AddressesService.
+type serviceBackend struct {
+	service addressesService
+}
+
+func (b *serviceBackend) Lookup(ctx context.Context, addresses []string) (map[string]*models.IP, error) {
+	output := make(map[string]*models.IP)
+	for start := 0; start < len(addresses); start += batchSize {
+		end := start + batchSize
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		found, err := b.service.GetMany(ctx, addresses[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for address, ip := range found {
+			output[address] = models.ToIPModel(address, ip, nil)
+		}
+	}
+	return output, nil
+}
+
+func (b *serviceBackend) List(ctx context.Context, country string, limit int) ([]*models.IP, error) {
+	list, err := b.service.List(ctx, limit, map[string]interface{}{"country": strings.Title(country)})
+	if err != nil {
+		return nil, err
+	}
+	return models.ToIPsModel(list, nil), nil
+}
+
+func (b *serviceBackend) Quantity(ctx context.Context, country string) (int, error) {
+	return b.service.GetIPQuantityByCountry(ctx, strings.Title(country))
+}
+
+func (b *serviceBackend) Top(ctx context.Context, country string) ([]string, error) {
+	return b.service.GetTop10ISPByCountry(ctx, strings.Title(country))
+}
+
+// apiBackend calls the /v1/ips endpoints of a running API.
+type apiBackend struct {
+	baseURL string
+	key     string
+	client  *http.Client
+}
+
+func newAPIBackend(baseURL string, key string) *apiBackend {
+	return &apiBackend{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		key:     key,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (b *apiBackend) Lookup(ctx context.Context, addresses []string) (map[string]*models.IP, error) {
+	output := make(map[string]*models.IP)
+	for _, address := range addresses {
+		ip := &models.IP{}
+		found, err := b.get(ctx, "/v1/ips/"+url.PathEscape(address), nil, ip)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			ip.IP = address
+			output[address] = ip
+		}
+	}
+	return output, nil
+}
+
+func (b *apiBackend) List(ctx context.Context, country string, limit int) ([]*models.IP, error) {
+	list := make([]*models.IP, 0)
+	query := url.Values{"country": {country}, "limit": {strconv.Itoa(limit)}}
+	if _, err := b.get(ctx, "/v1/ips", query, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (b *apiBackend) Quantity(ctx context.Context, country string) (int, error) {
+	quantity := &models.CountryQuantity{}
+	if _, err := b.get(ctx, "/v1/ips/quantity", url.Values{"country": {country}}, quantity); err != nil {
+		return 0, err
+	}
+	return quantity.Quantity, nil
+}
+
+func (b *apiBackend) Top(ctx context.Context, country string) ([]string, error) {
+	isps := make([]string, 0)
+	if _, err := b.get(ctx, "/v1/ips/isps/top", url.Values{"country": {country}}, &isps); err != nil {
+		return nil, err
+	}
+	return isps, nil
+}
+
+// get decodes the response into v, reporting false on 404 and 204.
+func (b *apiBackend) get(ctx context.Context, path string, query url.Values, v interface{}) (bool, error) {
+	endpoint := b.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	if b.key != "" {
+		request.Header.Set("X-API-Key", b.key)
+	}
+	response, err := b.client.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusNoContent:
+		return false, nil
+	case response.StatusCode != http.StatusOK:
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return false, fmt.Errorf("GET %s: %s: %s", path, response.Status, strings.TrimSpace(string(message)))
+	}
+	return true, json.NewDecoder(response.Body).Decode(v)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeService struct {
+	addressesService
+	batches [][]string
+}
+
+func (s *fakeService) GetMany(_ context.Context, addresses []string) (map[string]*ips.IP, error) {
+	s.batches = append(s.batches, addresses)
+	output := make(map[string]*ips.IP)
+	for _, address := range addresses {
+		if address != "8.8.8.8" {
+			output[address] = &ips.IP{Country: ips.Country{Code: "AU"}}
+		}
+	}
+	return output, nil
+}
+
+func TestServiceBackend_Lookup(t *testing.T) {
+	addresses := make([]string, 0)
+	for i := 0; i < 150; i++ {
+		addresses = append(addresses, fmt.Sprintf("1.0.0.%d", i))
+	}
+	addresses = append(addresses, "8.8.8.8")
+	service := &fakeService{}
+	backend := &serviceBackend{service: service}
+
+	found, err := backend.Lookup(context.Background(), addresses)
+	require.NoError(t, err)
+	assert.Len(t, service.batches, 2)
+	assert.Len(t, service.batches[0], batchSize)
+	assert.Len(t, found, 150)
+	assert.Equal(t, &models.IP{IP: "1.0.0.1", Country: models.Country{Code: "AU"}}, found["1.0.0.1"])
+	assert.NotContains(t, found, "8.8.8.8")
+}
+
+func TestAPIBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			http.Error(w, `"unauthenticated"`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/ips/1.0.0.1":
+			fmt.Fprint(w, `{"country":{"code":"AU"},"isp":"APNIC"}`)
+		case "/v1/ips":
+			fmt.Fprintf(w, `[{"ip":"1.0.0.1","country":{"name":%q}}]`, r.URL.Query().Get("country")+"/"+r.URL.Query().Get("limit"))
+		case "/v1/ips/quantity":
+			fmt.Fprint(w, `{"country":"Australia","quantity":42}`)
+		case "/v1/ips/isps/top":
+			fmt.Fprint(w, `["Telstra","Optus"]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	backend := newAPIBackend(server.URL+"/", "secret")
+
+	found, err := backend.Lookup(ctx, []string{"1.0.0.1", "8.8.8.8"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*models.IP{
+		"1.0.0.1": {IP: "1.0.0.1", Country: models.Country{Code: "AU"}, ISP: "APNIC"},
+	}, found)
+
+	list, err := backend.List(ctx, "australia", 5)
+	require.NoError(t, err)
+	assert.Equal(t, "australia/5", list[0].Country.Name)
+
+	quantity, err := backend.Quantity(ctx, "australia")
+	require.NoError(t, err)
+	assert.Equal(t, 42, quantity)
+
+	isps, err := backend.Top(ctx, "australia")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Telstra", "Optus"}, isps)
+
+	_, err = newAPIBackend(server.URL, "wrong").Quantity(ctx, "australia")
+	assert.EqualError(t, err, `GET /v1/ips/quantity: 401 Unauthorized: "unauthenticated"`)
+}
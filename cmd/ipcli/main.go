@@ -0,0 +1,158 @@
+// Command ipcli looks up the IP2Location dataset from a shell, either
+// straight from the database configured in .env or through a running API.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/mborroni/dreamlab-challenge/internal/application"
+	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const usage = `usage: ipcli [-api URL] [-key KEY] [-format table|json|csv] <command> [args]
+
+commands:
+  lookup [ip ...]          look up addresses, read one per line from stdin when none or "-" is given
+  list <country> [limit]   list the addresses of a country, 100 by default
+  quantity <country>       count the addresses of a country
+  top <country>            top 10 ISPs of a country
+
+Without -api the database configured in .env is queried directly. -api and
+-key default to the IPCLI_API_URL and IPCLI_API_KEY environment variables.
+`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr, newBackend); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newBackend(apiURL string, key string) (backend, error) {
+	if apiURL != "" {
+		return newAPIBackend(apiURL, key), nil
+	}
+	service, err := application.BuildAddressesService()
+	if err != nil {
+		return nil, err
+	}
+	return &serviceBackend{service: service}, nil
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer,
+	newBackend func(string, string) (backend, error)) error {
+	flags := flag.NewFlagSet("ipcli", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	apiURL := flags.String("api", os.Getenv("IPCLI_API_URL"), "base URL of the API")
+	key := flags.String("key", os.Getenv("IPCLI_API_KEY"), "API key")
+	format := flags.String("format", formatTable, "output format")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+	printer, err := newPrinter(*format, stdout)
+	if err != nil {
+		return err
+	}
+	command, args := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "lookup":
+	case "list", "quantity", "top":
+		if len(args) == 0 {
+			return fmt.Errorf("%s: missing country", command)
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+	backend, err := newBackend(*apiURL, *key)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "lookup":
+		return lookup(ctx, backend, printer, args, stdin, stderr)
+	case "list":
+		limit := 100
+		if len(args) > 1 {
+			if limit, err = strconv.Atoi(args[1]); err != nil || limit < 1 {
+				return fmt.Errorf("list: invalid limit %q", args[1])
+			}
+		}
+		list, err := backend.List(ctx, args[0], limit)
+		if err != nil {
+			return err
+		}
+		return printer.ips(list)
+	case "quantity":
+		quantity, err := backend.Quantity(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return printer.quantity(strings.Title(args[0]), quantity)
+	default:
+		isps, err := backend.Top(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return printer.isps(isps)
+	}
+}
+
+// lookup prints the addresses found in input order, reporting invalid and
+// missing ones on stderr.
+func lookup(ctx context.Context, backend backend, printer *printer, args []string, stdin io.Reader, stderr io.Writer) error {
+	addresses, err := readAddresses(args, stdin)
+	if err != nil {
+		return err
+	}
+	valid := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if _, err := conversion.IPv4ToDecimal(address); err != nil {
+			fmt.Fprintf(stderr, "%s: invalid IPv4 address\n", address)
+			continue
+		}
+		valid = append(valid, address)
+	}
+	found, err := backend.Lookup(ctx, valid)
+	if err != nil {
+		return err
+	}
+	output := make([]*models.IP, 0, len(valid))
+	for _, address := range valid {
+		ip, ok := found[address]
+		if !ok {
+			fmt.Fprintf(stderr, "%s: not found\n", address)
+			continue
+		}
+		output = append(output, ip)
+	}
+	return printer.ips(output)
+}
+
+func readAddresses(args []string, stdin io.Reader) ([]string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return args, nil
+	}
+	addresses := make([]string, 0)
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			addresses = append(addresses, line)
+		}
+	}
+	return addresses, scanner.Err()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type fakeBackend struct {
+	backend
+	looked []string
+}
+
+func (b *fakeBackend) Lookup(_ context.Context, addresses []string) (map[string]*models.IP, error) {
+	b.looked = addresses
+	return map[string]*models.IP{"1.0.0.1": {IP: "1.0.0.1", Country: models.Country{Code: "AU"}}}, nil
+}
+
+func (b *fakeBackend) Quantity(_ context.Context, country string) (int, error) {
+	return len(country), nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedLooked []string
+		expectedOut    string
+		expectedErr    string
+		expectedStderr string
+	}{
+		{
+			name:           "lookup arguments",
+			args:           []string{"-format", "csv", "lookup", "1.0.0.1", "8.8.8.8", "nope"},
+			expectedLooked: []string{"1.0.0.1", "8.8.8.8"},
+			expectedOut:    "ip,country_code,country,region,city,isp,domain,usage,asn,as,proxy_type\n1.0.0.1,AU,,,,,,,,,\n",
+			expectedStderr: "nope: invalid IPv4 address\n8.8.8.8: not found\n",
+		},
+		{
+			name:           "lookup stdin",
+			args:           []string{"-format", "csv", "lookup", "-"},
+			stdin:          "# addresses\n1.0.0.1\n\n 8.8.8.8 \n",
+			expectedLooked: []string{"1.0.0.1", "8.8.8.8"},
+			expectedOut:    "ip,country_code,country,region,city,isp,domain,usage,asn,as,proxy_type\n1.0.0.1,AU,,,,,,,,,\n",
+			expectedStderr: "8.8.8.8: not found\n",
+		},
+		{
+			name:        "quantity",
+			args:        []string{"-format", "json", "quantity", "australia"},
+			expectedOut: "{\n  \"country\": \"Australia\",\n  \"quantity\": 9\n}\n",
+		},
+		{
+			name:        "missing country",
+			args:        []string{"top"},
+			expectedErr: "top: missing country",
+		},
+		{
+			name:        "invalid limit",
+			args:        []string{"list", "australia", "0"},
+			expectedErr: `list: invalid limit "0"`,
+		},
+		{
+			name:           "unknown command",
+			args:           []string{"export"},
+			expectedErr:    `unknown command "export"`,
+			expectedStderr: usage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBackend{}
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			newBackend := func(string, string) (backend, error) { return fake, nil }
+
+			err := run(context.Background(), tt.args, strings.NewReader(tt.stdin), stdout, stderr, newBackend)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedLooked, fake.looked)
+			assert.Equal(t, tt.expectedOut, stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+		})
+	}
+}
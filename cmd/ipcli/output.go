@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+var ipColumns = []string{"ip", "country_code", "country", "region", "city", "isp", "domain", "usage", "asn", "as", "proxy_type"}
+
+// printer writes results as an aligned table, JSON or CSV. Tables and CSV
+// share the same columns, JSON uses the API representation.
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return &printer{format: format, w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected table, json or csv", format)
+}
+
+func (p *printer) ips(list []*models.IP) error {
+	rows := make([][]string, 0, len(list))
+	for _, ip := range list {
+		asn := ""
+		if ip.ASN != 0 {
+			asn = strconv.Itoa(ip.ASN)
+		}
+		rows = append(rows, []string{ip.IP, ip.Country.Code, ip.Country.Name, ip.Country.Region, ip.Country.City,
+			ip.ISP, ip.Domain, ip.Usage, asn, ip.AS, ip.ProxyType})
+	}
+	return p.print(list, ipColumns, rows)
+}
+
+func (p *printer) quantity(country string, quantity int) error {
+	return p.print(models.ToCountryQuantityModel(country, quantity),
+		[]string{"country", "quantity"}, [][]string{{country, strconv.Itoa(quantity)}})
+}
+
+func (p *printer) isps(isps []string) error {
+	rows := make([][]string, 0, len(isps))
+	for i, isp := range isps {
+		rows = append(rows, []string{strconv.Itoa(i + 1), isp})
+	}
+	return p.print(isps, []string{"rank", "isp"}, rows)
+}
+
+func (p *printer) print(v interface{}, columns []string, rows [][]string) error {
+	switch p.format {
+	case formatJSON:
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case formatCSV:
+		writer := csv.NewWriter(p.w)
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	}
+	writer := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
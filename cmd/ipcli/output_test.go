@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"github.com/mborroni/dreamlab-challenge/cmd/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPrinter_IPs(t *testing.T) {
+	list := []*models.IP{
+		{IP: "1.0.0.1", Country: models.Country{Code: "AU", Name: "Australia", City: "Sydney"}, ISP: "APNIC", ASN: 13335},
+	}
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:   "table",
+			format: formatTable,
+			expected: "IP       COUNTRY_CODE  COUNTRY    REGION  CITY    ISP    DOMAIN  USAGE  ASN    AS  PROXY_TYPE\n" +
+				"1.0.0.1  AU            Australia          Sydney  APNIC                 13335      \n",
+		},
+		{
+			name:   "csv",
+			format: formatCSV,
+			expected: "ip,country_code,country,region,city,isp,domain,usage,asn,as,proxy_type\n" +
+				"1.0.0.1,AU,Australia,,Sydney,APNIC,,,13335,,\n",
+		},
+		{
+			name:   "json",
+			format: formatJSON,
+			expected: "[\n  {\n    \"ip\": \"1.0.0.1\",\n    \"country\": {\n      \"code\": \"AU\",\n" +
+				"      \"name\": \"Australia\",\n      \"city\": \"Sydney\"\n    },\n    \"isp\": \"APNIC\",\n    \"asn\": 13335\n  }\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			printer, err := newPrinter(tt.format, output)
+			require.NoError(t, err)
+
+			require.NoError(t, printer.ips(list))
+			assert.Equal(t, tt.expected, output.String())
+		})
+	}
+}
+
+func TestPrinter_ISPs(t *testing.T) {
+	output := &bytes.Buffer{}
+	printer, err := newPrinter(formatCSV, output)
+	require.NoError(t, err)
+
+	require.NoError(t, printer.isps([]string{"Telstra", "Optus"}))
+	assert.Equal(t, "rank,isp\n1,Telstra\n2,Optus\n", output.String())
+}
+
+func TestNewPrinter_UnknownFormat(t *testing.T) {
+	_, err := newPrinter("yaml", &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown format "yaml", expected table, json or csv`)
+}
@@ -0,0 +1,17 @@
+package apikeys
+
+import (
+	"time"
+)
+
+type Key struct {
+	ID        int64
+	Name      string
+	Prefix    string
+	Hash      string
+	Scopes    []string
+	Tier      string
+	CreatedAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,168 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"strconv"
+	"strings"
+)
+
+//go:generate mockgen -source=keys.go -destination=keys_mock.go -package=apikeys
+
+const (
+	secretPrefix = "ipk_"
+	prefixLength = 12
+)
+
+var (
+	ErrNotFound     = errors.New("api key not found")
+	ErrInvalidScope = errors.New("invalid scope")
+	ErrInvalidTier  = errors.New("invalid tier")
+	ErrMissingName  = errors.New("missing name")
+)
+
+type repository interface {
+	Create(context.Context, *Key) (*Key, error)
+	List(context.Context) ([]*Key, error)
+	GetByHash(context.Context, string) (*Key, error)
+	Rotate(context.Context, int64, string, string) (*Key, error)
+	Revoke(context.Context, int64) error
+}
+
+type KeysService struct {
+	repository repository
+	// tiers is nil when every client sees every field.
+	tiers *auth.Tiers
+}
+
+func NewKeysService(repository repository, tiers *auth.Tiers) *KeysService {
+	return &KeysService{
+		repository: repository,
+		tiers:      tiers,
+	}
+}
+
+// Create stores a new key and returns it along with its secret, which is
+// only known at this point since just its hash is persisted. Keys created
+// without scopes get none, and an empty tier leaves the client on the
+// default one. Other tiers must be configured.
+func (s *KeysService) Create(ctx context.Context, name string, scopes []string, tier string) (*Key, string, error) {
+	if name == "" {
+		return nil, "", ErrMissingName
+	}
+	for _, scope := range scopes {
+		if !auth.ValidScope(scope) {
+			return nil, "", fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+	if scopes == nil {
+		scopes = []string{}
+	}
+	if err := validTier(s.tiers, tier); err != nil {
+		return nil, "", err
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := s.repository.Create(ctx, &Key{
+		Name:   name,
+		Prefix: secret[:prefixLength],
+		Hash:   hash(secret),
+		Scopes: scopes,
+		Tier:   tier,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+func (s *KeysService) List(ctx context.Context) ([]*Key, error) {
+	return s.repository.List(ctx)
+}
+
+// Rotate replaces the secret of a key keeping its name and scopes, the
+// previous secret stops working immediately.
+func (s *KeysService) Rotate(ctx context.Context, id int64) (*Key, string, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := s.repository.Rotate(ctx, id, secret[:prefixLength], hash(secret))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+func (s *KeysService) Revoke(ctx context.Context, id int64) error {
+	err := s.repository.Revoke(ctx, id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Authenticate resolves the principal owning secret, failing with
+// auth.ErrUnauthenticated for unknown or revoked keys.
+func (s *KeysService) Authenticate(ctx context.Context, secret string) (*auth.Principal, error) {
+	if secret == "" {
+		return nil, auth.ErrUnauthenticated
+	}
+	key, err := s.repository.GetByHash(ctx, hash(secret))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrUnauthenticated
+		}
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	return &auth.Principal{
+		ID:     "key:" + strconv.FormatInt(key.ID, 10),
+		Name:   key.Name,
+		Scopes: key.Scopes,
+		Tier:   key.Tier,
+	}, nil
+}
+
+// validTier accepts empty tiers, and any when tiers aren't configured.
+func validTier(tiers *auth.Tiers, tier string) error {
+	if tier == "" || tiers == nil {
+		return nil
+	}
+	names := tiers.Names()
+	for _, name := range names {
+		if name == tier {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q, configured tiers are %s", ErrInvalidTier, tier, strings.Join(names, ", "))
+}
+
+func generateSecret() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// hash uses a plain SHA-256: secrets are 256 random bits, so unlike passwords
+// they don't need a slow hash to resist brute force, and lookups stay cheap.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,234 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newMockKeysService(ctrl *gomock.Controller) *KeysService {
+	tiers, err := auth.ParseTiers("country:;full:*", "country")
+	if err != nil {
+		panic(err)
+	}
+	return NewKeysService(NewMockrepository(ctrl), tiers)
+}
+
+func TestKeysService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockKeysService(ctrl)
+
+	type fields struct {
+		name   string
+		scopes []string
+		tier   string
+	}
+
+	type want struct {
+		scopes []string
+		err    error
+	}
+
+	tests := []struct {
+		name         string
+		fields       fields
+		expectations func(fields fields)
+		want         want
+	}{
+		{name: "ok",
+			fields: fields{name: "analytics", scopes: []string{auth.ScopeLookup}},
+			expectations: func(fields fields) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, key *Key) (*Key, error) {
+						key.ID = 1
+						return key, nil
+					})
+			},
+			want: want{scopes: []string{auth.ScopeLookup}},
+		},
+		{name: "no scopes",
+			fields: fields{name: "analytics", scopes: nil},
+			expectations: func(fields fields) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, key *Key) (*Key, error) {
+						key.ID = 1
+						return key, nil
+					})
+			},
+			want: want{scopes: []string{}},
+		},
+		{name: "tier",
+			fields: fields{name: "analytics", scopes: []string{auth.ScopeLookup}, tier: "full"},
+			expectations: func(fields fields) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, key *Key) (*Key, error) {
+						key.ID = 1
+						return key, nil
+					})
+			},
+			want: want{scopes: []string{auth.ScopeLookup}},
+		},
+		{name: "unknown tier",
+			fields:       fields{name: "analytics", scopes: []string{auth.ScopeLookup}, tier: "ful"},
+			expectations: func(fields fields) {},
+			want:         want{err: ErrInvalidTier},
+		},
+		{name: "missing name",
+			fields:       fields{name: "", scopes: []string{auth.ScopeLookup}},
+			expectations: func(fields fields) {},
+			want:         want{err: ErrMissingName},
+		},
+		{name: "invalid scope",
+			fields:       fields{name: "analytics", scopes: []string{"root"}},
+			expectations: func(fields fields) {},
+			want:         want{err: ErrInvalidScope},
+		},
+		{name: "error",
+			fields: fields{name: "analytics", scopes: []string{auth.ScopeLookup}},
+			expectations: func(fields fields) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil, sql.ErrConnDone)
+			},
+			want: want{err: sql.ErrConnDone},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations(tc.fields)
+			key, secret, err := service.Create(context.Background(), tc.fields.name, tc.fields.scopes, tc.fields.tier)
+
+			assert.True(t, errors.Is(err, tc.want.err))
+			if tc.want.err != nil {
+				return
+			}
+			assert.True(t, strings.HasPrefix(secret, secretPrefix))
+			assert.Equal(t, hash(secret), key.Hash)
+			assert.Equal(t, secret[:prefixLength], key.Prefix)
+			assert.Equal(t, tc.want.scopes, key.Scopes)
+			assert.Equal(t, tc.fields.tier, key.Tier)
+		})
+	}
+}
+
+func TestKeysService_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockKeysService(ctrl)
+	revokedAt := time.Now()
+
+	type want struct {
+		principal *auth.Principal
+		err       error
+	}
+
+	tests := []struct {
+		name         string
+		secret       string
+		expectations func(secret string)
+		want         want
+	}{
+		{name: "ok",
+			secret: "ipk_valid",
+			expectations: func(secret string) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					GetByHash(gomock.Any(), hash(secret)).
+					Return(&Key{ID: 7, Name: "analytics", Scopes: []string{auth.ScopeLookup}, Tier: "premium"}, nil)
+			},
+			want: want{
+				principal: &auth.Principal{ID: "key:7", Name: "analytics", Scopes: []string{auth.ScopeLookup}, Tier: "premium"},
+			},
+		},
+		{name: "empty",
+			secret:       "",
+			expectations: func(secret string) {},
+			want:         want{err: auth.ErrUnauthenticated},
+		},
+		{name: "unknown",
+			secret: "ipk_unknown",
+			expectations: func(secret string) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					GetByHash(gomock.Any(), hash(secret)).
+					Return(nil, sql.ErrNoRows)
+			},
+			want: want{err: auth.ErrUnauthenticated},
+		},
+		{name: "revoked",
+			secret: "ipk_revoked",
+			expectations: func(secret string) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					GetByHash(gomock.Any(), hash(secret)).
+					Return(&Key{ID: 7, RevokedAt: &revokedAt}, nil)
+			},
+			want: want{err: auth.ErrUnauthenticated},
+		},
+		{name: "error",
+			secret: "ipk_valid",
+			expectations: func(secret string) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					GetByHash(gomock.Any(), hash(secret)).
+					Return(nil, sql.ErrConnDone)
+			},
+			want: want{err: sql.ErrConnDone},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations(tc.secret)
+			principal, err := service.Authenticate(context.Background(), tc.secret)
+
+			assert.Equal(t, tc.want.err, err)
+			assert.Equal(t, tc.want.principal, principal)
+		})
+	}
+}
+
+func TestKeysService_Rotate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockKeysService(ctrl)
+
+	service.repository.(*Mockrepository).
+		EXPECT().
+		Rotate(gomock.Any(), int64(7), gomock.Any(), gomock.Any()).
+		Return(nil, sql.ErrNoRows)
+
+	_, _, err := service.Rotate(context.Background(), 7)
+
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestKeysService_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockKeysService(ctrl)
+
+	service.repository.(*Mockrepository).
+		EXPECT().
+		Revoke(gomock.Any(), int64(7)).
+		Return(sql.ErrNoRows)
+
+	assert.Equal(t, ErrNotFound, service.Revoke(context.Background(), 7))
+}
@@ -0,0 +1,89 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+const keyColumns = "id, name, prefix, hash, scopes, tier, created_at, rotated_at, revoked_at"
+
+type DBRepository struct {
+	db *sql.DB
+}
+
+func NewDBRepository(db *sql.DB) *DBRepository {
+	return &DBRepository{
+		db: db,
+	}
+}
+
+func (r *DBRepository) Create(ctx context.Context, key *Key) (*Key, error) {
+	row := r.db.QueryRowContext(ctx, "INSERT INTO api_keys (name, prefix, hash, scopes, tier) "+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING "+keyColumns, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.Tier)
+	return scan(row)
+}
+
+func (r *DBRepository) List(ctx context.Context) ([]*Key, error) {
+	keys := make([]*Key, 0)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+keyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		key, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *DBRepository) GetByHash(ctx context.Context, hash string) (*Key, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+keyColumns+" FROM api_keys WHERE hash = $1", hash)
+	return scan(row)
+}
+
+func (r *DBRepository) Rotate(ctx context.Context, id int64, prefix string, hash string) (*Key, error) {
+	row := r.db.QueryRowContext(ctx, "UPDATE api_keys SET prefix = $2, hash = $3, rotated_at = now() "+
+		"WHERE id = $1 AND revoked_at IS NULL RETURNING "+keyColumns, id, prefix, hash)
+	return scan(row)
+}
+
+func (r *DBRepository) Revoke(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = now() "+
+		"WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+func scan(row scanner) (*Key, error) {
+	key := &Key{}
+	var rotatedAt, revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, pq.Array(&key.Scopes),
+		&key.Tier, &key.CreatedAt, &rotatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if rotatedAt.Valid {
+		key.RotatedAt = &rotatedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,95 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var columns = []string{"id", "name", "prefix", "hash", "scopes", "tier", "created_at", "rotated_at", "revoked_at"}
+
+func getDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return db, mock
+}
+
+func TestRepository_GetByHash(t *testing.T) {
+	db, mock := getDB(t)
+	r := NewDBRepository(db)
+	createdAt := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, prefix, hash, scopes, tier, created_at, rotated_at, revoked_at " +
+		"FROM api_keys WHERE hash = $1")).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(7, "analytics", "ipk_12345678", "abc", "{lookup,aggregate}", "premium", createdAt, nil, nil))
+
+	key, err := r.GetByHash(context.Background(), "abc")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Key{
+		ID:        7,
+		Name:      "analytics",
+		Prefix:    "ipk_12345678",
+		Hash:      "abc",
+		Scopes:    []string{"lookup", "aggregate"},
+		Tier:      "premium",
+		CreatedAt: createdAt,
+	}, key)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create(t *testing.T) {
+	db, mock := getDB(t)
+	r := NewDBRepository(db)
+	createdAt := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO api_keys (name, prefix, hash, scopes, tier) VALUES ($1, $2, $3, $4, $5) "+
+		"RETURNING id, name, prefix, hash, scopes, tier, created_at, rotated_at, revoked_at")).
+		WithArgs("analytics", "ipk_12345678", "abc", sqlmock.AnyArg(), "").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(7, "analytics", "ipk_12345678", "abc", "{lookup}", "", createdAt, nil, nil))
+
+	key, err := r.Create(context.Background(), &Key{
+		Name:   "analytics",
+		Prefix: "ipk_12345678",
+		Hash:   "abc",
+		Scopes: []string{"lookup"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), key.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Revoke(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		err      error
+	}{
+		{name: "ok", affected: 1, err: nil},
+		{name: "not found", affected: 0, err: sql.ErrNoRows},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := getDB(t)
+			r := NewDBRepository(db)
+
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE api_keys SET revoked_at = now() " +
+				"WHERE id = $1 AND revoked_at IS NULL")).
+				WithArgs(int64(7)).
+				WillReturnResult(sqlmock.NewResult(0, tc.affected))
+
+			assert.Equal(t, tc.err, r.Revoke(context.Background(), 7))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
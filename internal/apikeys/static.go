@@ -0,0 +1,94 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"strings"
+)
+
+// ErrReadOnly is returned when managing keys that come from configuration.
+var ErrReadOnly = errors.New("api keys are read from configuration and can't be changed")
+
+// StaticRepository serves a fixed set of keys, for deployments without
+// Postgres.
+type StaticRepository struct {
+	keys []*Key
+}
+
+// ParseStaticKeys reads definitions like
+// "analytics:ipk_secret:lookup,aggregate:city;admin:ipk_other:admin", the
+// tier is optional and must be one of tiers when they are configured. Keys
+// get IDs by position, starting at 1.
+func ParseStaticKeys(definitions string, tiers *auth.Tiers) (*StaticRepository, error) {
+	repository := &StaticRepository{keys: make([]*Key, 0)}
+	for _, definition := range strings.Split(definitions, ";") {
+		if strings.TrimSpace(definition) == "" {
+			continue
+		}
+		parts := strings.Split(definition, ":")
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid api key definition %q, expected name:secret:scopes[:tier]", definition)
+		}
+		name, secret := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if name == "" {
+			return nil, ErrMissingName
+		}
+		if len(secret) < prefixLength {
+			return nil, fmt.Errorf("api key %q: secret must have at least %d characters", name, prefixLength)
+		}
+		scopes := make([]string, 0)
+		for _, scope := range strings.Split(parts[2], ",") {
+			if scope = strings.TrimSpace(scope); scope == "" {
+				continue
+			}
+			if !auth.ValidScope(scope) {
+				return nil, fmt.Errorf("api key %q: %w: %q", name, ErrInvalidScope, scope)
+			}
+			scopes = append(scopes, scope)
+		}
+		tier := ""
+		if len(parts) == 4 {
+			tier = strings.TrimSpace(parts[3])
+		}
+		if err := validTier(tiers, tier); err != nil {
+			return nil, fmt.Errorf("api key %q: %w", name, err)
+		}
+		repository.keys = append(repository.keys, &Key{
+			ID:     int64(len(repository.keys) + 1),
+			Name:   name,
+			Prefix: secret[:prefixLength],
+			Hash:   hash(secret),
+			Scopes: scopes,
+			Tier:   tier,
+		})
+	}
+	return repository, nil
+}
+
+func (r *StaticRepository) Create(context.Context, *Key) (*Key, error) {
+	return nil, ErrReadOnly
+}
+
+func (r *StaticRepository) List(context.Context) ([]*Key, error) {
+	return r.keys, nil
+}
+
+func (r *StaticRepository) GetByHash(ctx context.Context, hash string) (*Key, error) {
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *StaticRepository) Rotate(context.Context, int64, string, string) (*Key, error) {
+	return nil, ErrReadOnly
+}
+
+func (r *StaticRepository) Revoke(context.Context, int64) error {
+	return ErrReadOnly
+}
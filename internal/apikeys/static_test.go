@@ -0,0 +1,79 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseStaticKeys(t *testing.T) {
+	tiers, err := auth.ParseTiers("country:;full:*", "country")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		definitions string
+		keys        []*Key
+		err         string
+	}{
+		{
+			name:        "ok",
+			definitions: "analytics:ipk_analytics_secret:lookup,aggregate:full; admin:ipk_admin_secret:admin",
+			keys: []*Key{
+				{ID: 1, Name: "analytics", Prefix: "ipk_analytic", Hash: hash("ipk_analytics_secret"),
+					Scopes: []string{auth.ScopeLookup, auth.ScopeAggregate}, Tier: "full"},
+				{ID: 2, Name: "admin", Prefix: "ipk_admin_se", Hash: hash("ipk_admin_secret"),
+					Scopes: []string{auth.ScopeAdmin}},
+			},
+		},
+		{name: "empty", definitions: "", keys: []*Key{}},
+		{name: "missing scopes", definitions: "analytics:ipk_analytics_secret",
+			err: "invalid api key definition \"analytics:ipk_analytics_secret\", expected name:secret:scopes[:tier]"},
+		{name: "short secret", definitions: "analytics:ipk_short:lookup",
+			err: "api key \"analytics\": secret must have at least 12 characters"},
+		{name: "invalid scope", definitions: "analytics:ipk_analytics_secret:root",
+			err: "api key \"analytics\": invalid scope: \"root\""},
+		{name: "unknown tier", definitions: "analytics:ipk_analytics_secret:lookup:ful",
+			err: "api key \"analytics\": invalid tier: \"ful\", configured tiers are country, full"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repository, err := ParseStaticKeys(tc.definitions, tiers)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.keys, repository.keys)
+		})
+	}
+}
+
+func TestStaticRepository(t *testing.T) {
+	repository, err := ParseStaticKeys("analytics:ipk_analytics_secret:lookup", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewKeysService(repository, nil)
+	ctx := context.Background()
+
+	principal, err := service.Authenticate(ctx, "ipk_analytics_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, &auth.Principal{ID: "key:1", Name: "analytics", Scopes: []string{auth.ScopeLookup}}, principal)
+
+	_, err = service.Authenticate(ctx, "ipk_other_secret")
+	assert.Equal(t, auth.ErrUnauthenticated, err)
+
+	_, err = repository.GetByHash(ctx, hash("ipk_other_secret"))
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	_, _, err = service.Create(ctx, "other", []string{auth.ScopeLookup}, "")
+	assert.Equal(t, ErrReadOnly, err)
+	_, _, err = service.Rotate(ctx, 1)
+	assert.Equal(t, ErrReadOnly, err)
+	assert.Equal(t, ErrReadOnly, service.Revoke(ctx, 1))
+}
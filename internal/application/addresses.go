@@ -0,0 +1,125 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/ip2location"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/mmdb"
+)
+
+// Data sources of the IP2Location dataset, selected with DATA_SOURCE.
+const (
+	dataSourcePostgres = "postgres"
+	dataSourceMMDB     = "mmdb"
+	dataSourceBIN      = "bin"
+)
+
+// BuildAddressesService connects to the configured database without starting
+// the rest of the engine, for tools that only query the dataset.
+func BuildAddressesService() (*ips.AddressesService, error) {
+	buildConfig()
+	if err := buildLogging(); err != nil {
+		return nil, err
+	}
+	if usesDatabase() {
+		buildDBConnections()
+	}
+	service, _, err := buildAddressesService()
+	return service, err
+}
+
+// buildAddressesService returns the service over the configured data source,
+// joined with the geolocation product when GEOLOCATION_PRODUCT is set, and the
+// function releasing it.
+func buildAddressesService() (*ips.AddressesService, func(context.Context) error, error) {
+	repository, closer, err := buildRepository()
+	if err != nil {
+		return nil, nil, err
+	}
+	geolocation, err := buildGeolocationProduct()
+	if err != nil {
+		return nil, nil, err
+	}
+	if geolocation == nil {
+		return ips.NewAddressesService(repository), closer, nil
+	}
+	merged := ips.NewMergedRepository(repository,
+		ips.NewInstrumentedRepository(geolocation.Table, ips.NewDBRepository(db, geolocation)))
+	return ips.NewAddressesService(merged), closer, nil
+}
+
+func buildRepository() (*ips.InstrumentedRepository, func(context.Context) error, error) {
+	switch dataSource() {
+	case dataSourcePostgres:
+		product, err := buildProduct()
+		if err != nil {
+			return nil, nil, err
+		}
+		repository := ips.NewInstrumentedRepository(product.Table, ips.NewDBRepository(db, product))
+		return repository, func(context.Context) error { return nil }, nil
+	case dataSourceMMDB:
+		repository, err := mmdb.OpenRepository(configs["MMDB_PATH"])
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening MMDB_PATH: %w", err)
+		}
+		return ips.NewInstrumentedRepository("mmdb", repository), func(context.Context) error { return repository.Close() }, nil
+	case dataSourceBIN:
+		mode := ip2location.LoadMode(configs["BIN_LOAD"])
+		if mode == "" {
+			mode = ip2location.MemoryMapped
+		}
+		file, err := ip2location.Open(configs["BIN_PATH"], mode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening BIN_PATH: %w", err)
+		}
+		repository := ips.NewInstrumentedRepository("bin", ip2location.NewRepository(file))
+		return repository, func(context.Context) error { return file.Close() }, nil
+	}
+	return nil, nil, fmt.Errorf("unknown DATA_SOURCE %q, expected postgres, mmdb or bin", configs["DATA_SOURCE"])
+}
+
+// buildProduct reads the IP2Location edition stored in Postgres, PX7 unless
+// DATASET_PRODUCT says otherwise, and its table.
+func buildProduct() (*ips.Product, error) {
+	name := configs["DATASET_PRODUCT"]
+	if name == "" {
+		name = "PX7"
+	}
+	product, err := ips.ParseProduct(name)
+	if err != nil {
+		return nil, err
+	}
+	if table := configs["DATASET_TABLE"]; table != "" {
+		if err := product.SetTable(table); err != nil {
+			return nil, fmt.Errorf("DATASET_TABLE: %w", err)
+		}
+	}
+	return product, nil
+}
+
+// buildGeolocationProduct reads the product joined to lookups from Postgres,
+// nil unless GEOLOCATION_PRODUCT is set.
+func buildGeolocationProduct() (*ips.Product, error) {
+	name := configs["GEOLOCATION_PRODUCT"]
+	if name == "" {
+		return nil, nil
+	}
+	product, err := ips.ParseProduct(name)
+	if err != nil {
+		return nil, fmt.Errorf("GEOLOCATION_PRODUCT: %w", err)
+	}
+	if table := configs["GEOLOCATION_TABLE"]; table != "" {
+		if err := product.SetTable(table); err != nil {
+			return nil, fmt.Errorf("GEOLOCATION_TABLE: %w", err)
+		}
+	}
+	return product, nil
+}
+
+func dataSource() string {
+	if source := configs["DATA_SOURCE"]; source != "" {
+		return source
+	}
+	return dataSourcePostgres
+}
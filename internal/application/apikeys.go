@@ -0,0 +1,35 @@
+package application
+
+import (
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/apikeys"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+)
+
+func BuildKeysService() (*apikeys.KeysService, error) {
+	buildConfig()
+	if err := buildLogging(); err != nil {
+		return nil, err
+	}
+	if usesDatabase() {
+		buildDBConnections()
+	}
+	tiers, err := buildTiers()
+	if err != nil {
+		return nil, err
+	}
+	return buildKeysService(tiers)
+}
+
+// buildKeysService stores keys in Postgres, or reads them from API_KEYS when
+// the API runs without it.
+func buildKeysService(tiers *auth.Tiers) (*apikeys.KeysService, error) {
+	if usesDatabase() {
+		return apikeys.NewKeysService(apikeys.NewDBRepository(db), tiers), nil
+	}
+	repository, err := apikeys.ParseStaticKeys(configs["API_KEYS"], tiers)
+	if err != nil {
+		return nil, fmt.Errorf("API_KEYS: %w", err)
+	}
+	return apikeys.NewKeysService(repository, tiers), nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	_ "github.com/lib/pq"
+	"github.com/mborroni/dreamlab-challenge/internal/metrics"
 )
 
 func buildDBConnections() {
@@ -11,9 +12,17 @@ func buildDBConnections() {
 	if err != nil {
 		panic(err)
 	}
+	metrics.RegisterDB(rdb, configs["DB_NAME"])
 	db = rdb
 }
 
+// usesDatabase reports whether the configuration needs Postgres, to serve the
+// dataset from it or to join the geolocation product. With a file data source
+// and no geolocation product the API runs without it.
+func usesDatabase() bool {
+	return dataSource() == dataSourcePostgres || configs["GEOLOCATION_PRODUCT"] != ""
+}
+
 func dsn() string {
 	return fmt.Sprintf(
 		"postgres://%v:%v@%v:%v/%v?sslmode=disable",
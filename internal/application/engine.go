@@ -1,8 +1,15 @@
 package application
 
 import (
+	"context"
 	"database/sql"
+	"github.com/mborroni/dreamlab-challenge/internal/apikeys"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/mborroni/dreamlab-challenge/internal/health"
 	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/jwtauth"
+	"github.com/mborroni/dreamlab-challenge/internal/ratelimit"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
 )
 
 var (
@@ -12,18 +19,89 @@ var (
 
 type Engine struct {
 	AddressesService *ips.AddressesService
+	KeysService      *apikeys.KeysService
+	// TokenValidator is nil when JWT authentication is disabled.
+	TokenValidator *jwtauth.Validator
+	UsageService   *usage.UsageService
+	// Tiers is nil when every client sees every field.
+	Tiers *auth.Tiers
+	// Limiter is nil when rate limiting is disabled.
+	Limiter *ratelimit.Limiter
+	// Health runs the readiness checks, background subsystems register theirs on it.
+	Health *health.Checker
+
+	closers []func(context.Context) error
 }
 
 func Build() (*Engine, error) {
 	buildConfig()
-	buildDBConnections()
+	if err := buildLogging(); err != nil {
+		return nil, err
+	}
+	closeDB := func(context.Context) error { return nil }
+	if usesDatabase() {
+		buildDBConnections()
+		if err := runMigrations(); err != nil {
+			return nil, err
+		}
+		closeDB = func(context.Context) error { return db.Close() }
+	}
+	shutdownTracing, err := buildTracing()
+	if err != nil {
+		return nil, err
+	}
+	checker, err := buildHealthChecker()
+	if err != nil {
+		return nil, err
+	}
+	tokenValidator, err := buildTokenValidator(checker)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := buildLimiter()
+	if err != nil {
+		return nil, err
+	}
+	tiers, err := buildTiers()
+	if err != nil {
+		return nil, err
+	}
+	usageService, err := buildUsageService()
+	if err != nil {
+		return nil, err
+	}
+	keysService, err := buildKeysService(tiers)
+	if err != nil {
+		return nil, err
+	}
+	addressesService, closeAddresses, err := buildAddressesService()
+	if err != nil {
+		return nil, err
+	}
 
 	return &Engine{
-		AddressesService: buildAddressesService(),
+		AddressesService: addressesService,
+		KeysService:      keysService,
+		TokenValidator:   tokenValidator,
+		UsageService:     usageService,
+		Tiers:            tiers,
+		Health:           checker,
+		Limiter:          limiter,
+		closers: []func(context.Context) error{
+			shutdownTracing,
+			closeAddresses,
+			closeDB,
+		},
 	}, nil
 }
 
-func buildAddressesService() *ips.AddressesService {
-	repository := ips.NewDBRepository(db)
-	return ips.NewAddressesService(repository)
+// Close releases the resources opened by Build, in order.
+func (e *Engine) Close(ctx context.Context) error {
+	var first error
+	for _, closer := range e.closers {
+		if err := closer(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
 }
@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/health"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/migrations"
+	"time"
+)
+
+func buildHealthChecker() (*health.Checker, error) {
+	checker := health.NewChecker(2 * time.Second)
+	if !usesDatabase() {
+		// File data sources are checked when opened, Build fails without them.
+		return checker, nil
+	}
+	checker.Register("database", db.PingContext)
+
+	products := make([]*ips.Product, 0)
+	if dataSource() == dataSourcePostgres {
+		product, err := buildProduct()
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	geolocation, err := buildGeolocationProduct()
+	if err != nil {
+		return nil, err
+	}
+	if geolocation != nil {
+		products = append(products, geolocation)
+	}
+	for _, product := range products {
+		registerPopulated(checker, product)
+	}
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	checker.Register("migrations", func(ctx context.Context) error {
+		current, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if current != migrator.Latest() {
+			return fmt.Errorf("schema at version %d, expected %d", current, migrator.Latest())
+		}
+		return nil
+	})
+	return checker, nil
+}
+
+// registerPopulated checks the table of product has rows.
+func registerPopulated(checker *health.Checker, product *ips.Product) {
+	repository := ips.NewDBRepository(db, product)
+	checker.Register(product.Table, func(ctx context.Context) error {
+		populated, err := repository.Populated(ctx)
+		if err != nil {
+			return err
+		}
+		if !populated {
+			return fmt.Errorf("table %s is empty", product.Table)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/health"
+	"github.com/mborroni/dreamlab-challenge/internal/jwtauth"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// buildTokenValidator returns nil when JWT authentication is disabled.
+func buildTokenValidator(checker *health.Checker) (*jwtauth.Validator, error) {
+	if configs["JWT_ENABLED"] != "true" {
+		return nil, nil
+	}
+	if configs["JWT_JWKS_URL"] == "" {
+		return nil, errors.New("JWT_JWKS_URL is required when JWT_ENABLED is true")
+	}
+	ttl := time.Hour
+	if value := configs["JWT_JWKS_TTL"]; value != "" {
+		var err error
+		if ttl, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("invalid JWT_JWKS_TTL: %w", err)
+		}
+	}
+	mapping, err := parseScopeMapping(configs["JWT_SCOPE_MAPPING"])
+	if err != nil {
+		return nil, err
+	}
+	keys := jwtauth.NewKeySet(configs["JWT_JWKS_URL"], &http.Client{Timeout: 5 * time.Second}, ttl)
+	checker.Register("jwks", keys.Check)
+	return jwtauth.NewValidator(keys, jwtauth.Config{
+		Issuer:       configs["JWT_ISSUER"],
+		Audience:     configs["JWT_AUDIENCE"],
+		ScopeClaim:   configs["JWT_SCOPE_CLAIM"],
+		ScopeMapping: mapping,
+		TierClaim:    configs["JWT_TIER_CLAIM"],
+	}), nil
+}
+
+// parseScopeMapping reads "geo.read=lookup,geo.stats=aggregate".
+func parseScopeMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		claimed, scope, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid JWT_SCOPE_MAPPING entry %q", pair)
+		}
+		mapping[strings.TrimSpace(claimed)] = strings.TrimSpace(scope)
+	}
+	return mapping, nil
+}
@@ -0,0 +1,13 @@
+package application
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/logging"
+)
+
+func buildLogging() error {
+	return logging.Setup(logging.Config{
+		Level:          configs["LOG_LEVEL"],
+		Format:         configs["LOG_FORMAT"],
+		DatasetVersion: configs["DATASET_VERSION"],
+	})
+}
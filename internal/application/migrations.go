@@ -0,0 +1,35 @@
+package application
+
+import (
+	"context"
+	"github.com/mborroni/dreamlab-challenge/internal/migrations"
+	log "github.com/sirupsen/logrus"
+)
+
+func BuildMigrator() (*migrations.Migrator, error) {
+	buildConfig()
+	if err := buildLogging(); err != nil {
+		return nil, err
+	}
+	buildDBConnections()
+	return migrations.NewMigrator(db)
+}
+
+func runMigrations() error {
+	if configs["DB_MIGRATE_ON_STARTUP"] != "true" {
+		return nil
+	}
+	ctx := context.Background()
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.WithContext(ctx).
+		WithFields(log.Fields{"event": "migrations", "applied": applied}).
+		Info("database schema up to date")
+	return nil
+}
@@ -0,0 +1,19 @@
+package application
+
+import (
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/mmdb"
+)
+
+func BuildExporter() (*mmdb.Exporter, error) {
+	buildConfig()
+	if err := buildLogging(); err != nil {
+		return nil, err
+	}
+	buildDBConnections()
+	product, err := buildProduct()
+	if err != nil {
+		return nil, err
+	}
+	return mmdb.NewExporter(ips.NewDBRepository(db, product)), nil
+}
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/ratelimit"
+	"strconv"
+)
+
+// buildLimiter returns nil when rate limiting is disabled.
+func buildLimiter() (*ratelimit.Limiter, error) {
+	if configs["RATE_LIMIT_ENABLED"] != "true" {
+		return nil, nil
+	}
+	rate, err := strconv.ParseFloat(configs["RATE_LIMIT_RATE"], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_RATE: %w", err)
+	}
+	burst, err := strconv.Atoi(configs["RATE_LIMIT_BURST"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_BURST: %w", err)
+	}
+	limit := ratelimit.Limit{Rate: rate, Burst: burst}
+	switch configs["RATE_LIMIT_STORE"] {
+	case "", "memory":
+		return ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limit), nil
+	case "postgres":
+		if !usesDatabase() {
+			return nil, fmt.Errorf("RATE_LIMIT_STORE=postgres needs Postgres, DATA_SOURCE is %s", dataSource())
+		}
+		return ratelimit.NewLimiter(ratelimit.NewDBStore(db), limit), nil
+	}
+	return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q", configs["RATE_LIMIT_STORE"])
+}
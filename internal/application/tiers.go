@@ -0,0 +1,13 @@
+package application
+
+import (
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+)
+
+// buildTiers returns nil when no tiers are configured.
+func buildTiers() (*auth.Tiers, error) {
+	if configs["TIERS"] == "" {
+		return nil, nil
+	}
+	return auth.ParseTiers(configs["TIERS"], configs["TIER_DEFAULT"])
+}
@@ -0,0 +1,14 @@
+package application
+
+import (
+	"context"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+)
+
+func buildTracing() (func(context.Context) error, error) {
+	return tracing.Setup(context.Background(), tracing.Config{
+		Exporter: configs["TRACING_EXPORTER"],
+		Endpoint: configs["TRACING_OTLP_ENDPOINT"],
+		Insecure: configs["TRACING_OTLP_INSECURE"] == "true",
+	})
+}
@@ -0,0 +1,29 @@
+package application
+
+import (
+	"fmt"
+	"github.com/mborroni/dreamlab-challenge/internal/usage"
+	"strconv"
+)
+
+func buildUsageService() (*usage.UsageService, error) {
+	quota := usage.Quota{}
+	if value := configs["USAGE_MONTHLY_REQUESTS"]; value != "" {
+		requests, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid USAGE_MONTHLY_REQUESTS: %w", err)
+		}
+		quota.Requests = requests
+	}
+	if value := configs["USAGE_MONTHLY_ROWS"]; value != "" {
+		rows, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid USAGE_MONTHLY_ROWS: %w", err)
+		}
+		quota.Rows = rows
+	}
+	if !usesDatabase() {
+		return usage.NewUsageService(usage.NewMemoryRepository(), quota), nil
+	}
+	return usage.NewUsageService(usage.NewDBRepository(db), quota), nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	ScopeLookup    = "lookup"
+	ScopeAggregate = "aggregate"
+	ScopeExport    = "export"
+	ScopeAdmin     = "admin"
+)
+
+var Scopes = []string{ScopeLookup, ScopeAggregate, ScopeExport, ScopeAdmin}
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrForbidden       = errors.New("insufficient scope")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// ID identifies the client across requests, e.g. "key:42".
+	ID     string
+	Name   string
+	Scopes []string
+	// Tier names the data tier the client is licensed for, empty for the default one.
+	Tier string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPrincipal_HasScope(t *testing.T) {
+	principal := &Principal{ID: "key:1", Scopes: []string{ScopeLookup, ScopeAggregate}}
+
+	assert.True(t, principal.HasScope(ScopeLookup))
+	assert.True(t, principal.HasScope(ScopeAggregate))
+	assert.False(t, principal.HasScope(ScopeExport))
+}
+
+func TestValidScope(t *testing.T) {
+	assert.True(t, ValidScope(ScopeExport))
+	assert.False(t, ValidScope("root"))
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	principal := &Principal{ID: "key:1"}
+
+	assert.Nil(t, PrincipalFromContext(context.Background()))
+	assert.Equal(t, principal, PrincipalFromContext(WithPrincipal(context.Background(), principal)))
+}
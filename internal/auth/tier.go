@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fields a tier may be licensed for. Country code and name are always visible.
+const (
+	FieldRegion    = "region"
+	FieldCity      = "city"
+	FieldISP       = "isp"
+	FieldDomain    = "domain"
+	FieldUsage     = "usage"
+	FieldASN       = "asn"
+	FieldProxyType = "proxy_type"
+	// FieldLocation covers latitude, longitude, ZIP code and time zone.
+	FieldLocation = "location"
+	// FieldThreat covers the last seen days, threat and provider of proxies.
+	FieldThreat = "threat"
+)
+
+var Fields = []string{FieldRegion, FieldCity, FieldISP, FieldDomain, FieldUsage, FieldASN, FieldProxyType,
+	FieldLocation, FieldThreat}
+
+// Tier is the set of fields a client is licensed to see.
+type Tier struct {
+	Name   string
+	fields map[string]bool
+}
+
+// NewTier validates fields, "*" grants all of them.
+func NewTier(name string, fields []string) (*Tier, error) {
+	tier := &Tier{Name: name, fields: make(map[string]bool)}
+	for _, field := range fields {
+		if field == "*" {
+			for _, f := range Fields {
+				tier.fields[f] = true
+			}
+			continue
+		}
+		if !validField(field) {
+			return nil, fmt.Errorf("tier %q: unknown field %q", name, field)
+		}
+		tier.fields[field] = true
+	}
+	return tier, nil
+}
+
+// Allows reports whether the tier includes field, a nil tier allows everything.
+func (t *Tier) Allows(field string) bool {
+	return t == nil || t.fields[field]
+}
+
+// Tiers holds the configured tiers and the one used for clients without a
+// tier.
+type Tiers struct {
+	byName   map[string]*Tier
+	fallback *Tier
+}
+
+// ParseTiers reads definitions like "country:;city:region,city;full:*",
+// fallback must name one of them.
+func ParseTiers(definitions string, fallback string) (*Tiers, error) {
+	tiers := &Tiers{byName: make(map[string]*Tier)}
+	for _, definition := range strings.Split(definitions, ";") {
+		if strings.TrimSpace(definition) == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(definition, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid tier definition %q", definition)
+		}
+		fields := make([]string, 0)
+		for _, field := range strings.Split(list, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+		tier, err := NewTier(strings.TrimSpace(name), fields)
+		if err != nil {
+			return nil, err
+		}
+		tiers.byName[tier.Name] = tier
+	}
+	fallbackTier, ok := tiers.byName[fallback]
+	if !ok {
+		return nil, fmt.Errorf("default tier %q is not defined", fallback)
+	}
+	tiers.fallback = fallbackTier
+	return tiers, nil
+}
+
+// For returns the tier of principal. A tier that isn't configured, like one
+// removed from the configuration after keys were assigned to it, sees no
+// fields instead of those of the default tier.
+func (t *Tiers) For(principal *Principal) *Tier {
+	if principal == nil || principal.Tier == "" {
+		return t.fallback
+	}
+	if tier, ok := t.byName[principal.Tier]; ok {
+		return tier
+	}
+	return &Tier{Name: principal.Tier, fields: make(map[string]bool)}
+}
+
+// Names lists the configured tiers in alphabetical order.
+func (t *Tiers) Names() []string {
+	names := make([]string, 0, len(t.byName))
+	for name := range t.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validField(field string) bool {
+	for _, f := range Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+type tierKey struct{}
+
+func WithTier(ctx context.Context, tier *Tier) context.Context {
+	return context.WithValue(ctx, tierKey{}, tier)
+}
+
+// TierFromContext returns nil, allowing every field, when no tier was applied.
+func TierFromContext(ctx context.Context) *Tier {
+	tier, _ := ctx.Value(tierKey{}).(*Tier)
+	return tier
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseTiers(t *testing.T) {
+	tiers, err := ParseTiers("country:; city: region, city ;full:*", "country")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"city", "country", "full"}, tiers.Names())
+
+	country := tiers.For(&Principal{ID: "key:1"})
+	assert.Equal(t, "country", country.Name)
+	assert.False(t, country.Allows(FieldCity))
+
+	city := tiers.For(&Principal{ID: "key:1", Tier: "city"})
+	assert.True(t, city.Allows(FieldRegion))
+	assert.True(t, city.Allows(FieldCity))
+	assert.False(t, city.Allows(FieldISP))
+
+	full := tiers.For(&Principal{ID: "key:1", Tier: "full"})
+	for _, field := range Fields {
+		assert.True(t, full.Allows(field))
+	}
+
+	unknown := tiers.For(&Principal{ID: "key:1", Tier: "ful"})
+	assert.Equal(t, "ful", unknown.Name)
+	for _, field := range Fields {
+		assert.False(t, unknown.Allows(field))
+	}
+	assert.Equal(t, "country", tiers.For(nil).Name)
+}
+
+func TestParseTiers_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		definitions string
+		fallback    string
+		err         string
+	}{
+		{name: "unknown field", definitions: "basic:latitude", fallback: "basic",
+			err: "tier \"basic\": unknown field \"latitude\""},
+		{name: "missing colon", definitions: "basic", fallback: "basic",
+			err: "invalid tier definition \"basic\""},
+		{name: "unknown default", definitions: "basic:city", fallback: "full",
+			err: "default tier \"full\" is not defined"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseTiers(tc.definitions, tc.fallback)
+
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestTierFromContext(t *testing.T) {
+	tier, _ := NewTier("city", []string{FieldCity})
+
+	assert.Nil(t, TierFromContext(context.Background()))
+	assert.True(t, TierFromContext(context.Background()).Allows(FieldISP))
+	assert.Equal(t, tier, TierFromContext(WithTier(context.Background(), tier)))
+}
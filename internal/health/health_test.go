@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestChecker_Run(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks map[string]Check
+		want   map[string]string
+		status string
+	}{
+		{
+			name:   "no checks",
+			checks: map[string]Check{},
+			want:   map[string]string{},
+			status: StatusUp,
+		},
+		{
+			name: "all up",
+			checks: map[string]Check{
+				"database": func(context.Context) error { return nil },
+			},
+			want:   map[string]string{"database": StatusUp},
+			status: StatusUp,
+		},
+		{
+			name: "one down",
+			checks: map[string]Check{
+				"database":   func(context.Context) error { return nil },
+				"migrations": func(context.Context) error { return errors.New("pending") },
+			},
+			want:   map[string]string{"database": StatusUp, "migrations": StatusDown},
+			status: StatusDown,
+		},
+		{
+			name: "timeout",
+			checks: map[string]Check{
+				"slow": func(ctx context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				},
+			},
+			want:   map[string]string{"slow": StatusDown},
+			status: StatusDown,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker(50 * time.Millisecond)
+			for name, check := range tc.checks {
+				checker.Register(name, check)
+			}
+
+			report := checker.Run(context.Background())
+
+			got := make(map[string]string)
+			for _, result := range report.Checks {
+				got[result.Name] = result.Status
+			}
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.status, report.Status)
+		})
+	}
+}
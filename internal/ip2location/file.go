@@ -0,0 +1,397 @@
+// Package ip2location reads the .BIN files IP2Location distributes for
+// direct lookups, without importing them into Postgres.
+package ip2location
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+)
+
+const (
+	headerSize = 64
+	// indexSize is the size of the IPv4 and IPv6 indexes, one entry with the
+	// first and last rows for each value of the first 16 bits.
+	indexSize = 65536 * 8
+
+	// productIP2Proxy is the product code of the IP2Proxy (PX) files, files
+	// built before 2021 leave it as zero.
+	productIP2Proxy = 2
+)
+
+var ErrInvalidFile = errors.New("not an IP2Proxy BIN file")
+
+// LoadMode tells Open how to access the file.
+type LoadMode string
+
+const (
+	// MemoryMapped maps the file, pages are read on demand and shared with
+	// other processes opening it.
+	MemoryMapped LoadMode = "mmap"
+	// InMemory reads the whole file on open.
+	InMemory LoadMode = "memory"
+)
+
+// columns holds the 1-based column of each field per PX product, the first
+// column is ip_from and zero means the product lacks the field.
+var columns = map[byte]struct {
+	proxyType, country, region, city, isp, domain, usageType, asn, as, lastSeen, threat, provider byte
+}{
+	1:  {country: 2},
+	2:  {proxyType: 2, country: 3},
+	3:  {proxyType: 2, country: 3, region: 4, city: 5},
+	4:  {proxyType: 2, country: 3, region: 4, city: 5, isp: 6},
+	5:  {proxyType: 2, country: 3, region: 4, city: 5, isp: 6, domain: 7},
+	6:  {proxyType: 2, country: 3, region: 4, city: 5, isp: 6, domain: 7, usageType: 8},
+	7:  {proxyType: 2, country: 3, region: 4, city: 5, isp: 6, domain: 7, usageType: 8, asn: 9, as: 10},
+	8:  {proxyType: 2, country: 3, region: 4, city: 5, isp: 6, domain: 7, usageType: 8, asn: 9, as: 10, lastSeen: 11},
+	9:  {proxyType: 2, country: 3, region: 4, city: 5, isp: 6, domain: 7, usageType: 8, asn: 9, as: 10, lastSeen: 11, threat: 12},
+	10: {proxyType: 2, country: 3, region: 4, city: 5, isp: 6, domain: 7, usageType: 8, asn: 9, as: 10, lastSeen: 11, threat: 12},
+	11: {proxyType: 2, country: 3, region: 4, city: 5, isp: 6, domain: 7, usageType: 8, asn: 9, as: 10, lastSeen: 11, threat: 12,
+		provider: 13},
+}
+
+// Row is a range of the file with the fields its product provides, the
+// rest are empty. Fields without data hold "-" as in the CSV distribution.
+type Row struct {
+	From        netip.Addr
+	To          netip.Addr
+	ProxyType   string
+	CountryCode string
+	CountryName string
+	Region      string
+	City        string
+	ISP         string
+	Domain      string
+	UsageType   string
+	ASN         string
+	AS          string
+	LastSeen    string
+	Threat      string
+	Provider    string
+}
+
+// header is the start of every file, addresses are 1-based offsets.
+type header struct {
+	product   byte
+	columns   byte
+	year      byte
+	month     byte
+	day       byte
+	ipv4Count uint32
+	ipv4Base  uint32
+	ipv6Count uint32
+	ipv6Base  uint32
+	ipv4Index uint32
+	ipv6Index uint32
+}
+
+// File is an IP2Proxy BIN file. Every table holds its ranges sorted by
+// ip_from followed by a row carrying the end of the last one, a range ends
+// where the next one starts. It is safe for concurrent use.
+type File struct {
+	data   []byte
+	close  func() error
+	header header
+}
+
+func Open(path string, mode LoadMode) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() < headerSize || stat.Size() > math.MaxInt32 {
+		return nil, ErrInvalidFile
+	}
+	var data []byte
+	closer := func() error { return nil }
+	switch mode {
+	case MemoryMapped:
+		if data, closer, err = mmap(file, int(stat.Size())); err != nil {
+			return nil, err
+		}
+	case InMemory:
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown load mode %q", mode)
+	}
+	f, err := newFile(data)
+	if err != nil {
+		closer()
+		return nil, err
+	}
+	f.close = closer
+	return f, nil
+}
+
+func newFile(data []byte) (*File, error) {
+	f := &File{data: data, close: func() error { return nil }}
+	if len(data) < headerSize {
+		return nil, ErrInvalidFile
+	}
+	f.header = header{
+		product:   data[0],
+		columns:   data[1],
+		year:      data[2],
+		month:     data[3],
+		day:       data[4],
+		ipv4Count: binary.LittleEndian.Uint32(data[5:]),
+		ipv4Base:  binary.LittleEndian.Uint32(data[9:]),
+		ipv6Count: binary.LittleEndian.Uint32(data[13:]),
+		ipv6Base:  binary.LittleEndian.Uint32(data[17:]),
+		ipv4Index: binary.LittleEndian.Uint32(data[21:]),
+		ipv6Index: binary.LittleEndian.Uint32(data[25:]),
+	}
+	if code := data[29]; code != productIP2Proxy && !(code == 0 && f.header.year < 21) {
+		return nil, ErrInvalidFile
+	}
+	if _, ok := columns[f.header.product]; !ok || f.header.columns < 2 {
+		return nil, ErrInvalidFile
+	}
+	ipv4End := int64(f.header.ipv4Base) - 1 + int64(f.header.ipv4Count+1)*int64(f.ipv4RowSize())
+	ipv6End := int64(f.header.ipv6Base) - 1 + int64(f.header.ipv6Count+1)*int64(f.ipv6RowSize())
+	if ipv4End > int64(len(data)) || (f.header.ipv6Count > 0 && ipv6End > int64(len(data))) {
+		return nil, ErrInvalidFile
+	}
+	return f, nil
+}
+
+func (f *File) Close() error {
+	return f.close()
+}
+
+// Product is the PX product number, 7 for PX7.
+func (f *File) Product() int {
+	return int(f.header.product)
+}
+
+// Version is the release date of the file as YYYY-MM-DD.
+func (f *File) Version() string {
+	return fmt.Sprintf("20%02d-%02d-%02d", f.header.year, f.header.month, f.header.day)
+}
+
+// Lookup returns the range holding addr, IPv4-mapped IPv6 addresses are
+// searched in the IPv4 table. It reports false for addresses outside of
+// every range, such as IPv6 ones in a file without the IPv6 table, and
+// fails with ErrInvalidFile when the IPv6 table points past the end of
+// the file.
+func (f *File) Lookup(addr netip.Addr) (*Row, bool, error) {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		i, ok := f.searchIPv4(binary.BigEndian.Uint32(addr.AsSlice()))
+		if !ok {
+			return nil, false, nil
+		}
+		return f.ipv4Row(i), true, nil
+	}
+	i, ok, err := f.searchIPv6(toUint128(addr))
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	row, err := f.ipv6Row(i)
+	if err != nil {
+		return nil, false, err
+	}
+	return row, true, nil
+}
+
+// WalkIPv4 calls fn with every range of the IPv4 table in ascending order,
+// it stops at the first error returned by fn.
+func (f *File) WalkIPv4(fn func(*Row) error) error {
+	for i := uint32(0); i < f.header.ipv4Count; i++ {
+		if err := fn(f.ipv4Row(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *File) searchIPv4(ip uint32) (uint32, bool) {
+	if f.header.ipv4Count == 0 {
+		return 0, false
+	}
+	// The last address belongs to the last range, its end is exclusive.
+	if ip == math.MaxUint32 {
+		ip--
+	}
+	low, high := uint32(0), f.header.ipv4Count-1
+	if f.header.ipv4Index > 0 {
+		entry := f.header.ipv4Index + (ip>>16)<<3
+		low, high = f.uint32At(entry), min(f.uint32At(entry+4), f.header.ipv4Count-1)
+	}
+	for low <= high {
+		mid := low + (high-low)/2
+		from, to := f.ipv4From(mid), f.ipv4From(mid+1)
+		switch {
+		case ip < from:
+			if mid == 0 {
+				return 0, false
+			}
+			high = mid - 1
+		case ip >= to:
+			low = mid + 1
+		default:
+			return mid, true
+		}
+	}
+	return 0, false
+}
+
+func (f *File) searchIPv6(ip uint128) (uint32, bool, error) {
+	if f.header.ipv6Count == 0 {
+		return 0, false, nil
+	}
+	if ip == maxUint128 {
+		ip = ip.decrement()
+	}
+	low, high := uint32(0), f.header.ipv6Count-1
+	if f.header.ipv6Index > 0 {
+		entry := f.header.ipv6Index + uint32(ip.hi>>48)<<3
+		low, high = f.uint32At(entry), min(f.uint32At(entry+4), f.header.ipv6Count-1)
+	}
+	for low <= high {
+		mid := low + (high-low)/2
+		from, err := f.ipv6From(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		to, err := f.ipv6From(mid + 1)
+		if err != nil {
+			return 0, false, err
+		}
+		switch {
+		case ip.less(from):
+			if mid == 0 {
+				return 0, false, nil
+			}
+			high = mid - 1
+		case !ip.less(to):
+			low = mid + 1
+		default:
+			return mid, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (f *File) ipv4RowSize() uint32 {
+	return uint32(f.header.columns) * 4
+}
+
+func (f *File) ipv6RowSize() uint32 {
+	return 16 + uint32(f.header.columns-1)*4
+}
+
+func (f *File) ipv4From(i uint32) uint32 {
+	return f.uint32At(f.header.ipv4Base + i*f.ipv4RowSize())
+}
+
+func (f *File) ipv6From(i uint32) (uint128, error) {
+	offset := int64(f.header.ipv6Base) - 1 + int64(i)*int64(f.ipv6RowSize())
+	if offset < 0 || offset+16 > int64(len(f.data)) {
+		return uint128{}, fmt.Errorf("%w: IPv6 row %d is past the end of the file", ErrInvalidFile, i)
+	}
+	return uint128{
+		hi: binary.LittleEndian.Uint64(f.data[offset+8:]),
+		lo: binary.LittleEndian.Uint64(f.data[offset:]),
+	}, nil
+}
+
+func (f *File) ipv4Row(i uint32) *Row {
+	offset := f.header.ipv4Base + i*f.ipv4RowSize()
+	row := f.fields(offset + 4)
+	row.From = fromUint32(f.ipv4From(i))
+	row.To = fromUint32(f.ipv4From(i+1) - 1)
+	if i == f.header.ipv4Count-1 && f.ipv4From(i+1) == math.MaxUint32 {
+		row.To = fromUint32(math.MaxUint32)
+	}
+	return row
+}
+
+func (f *File) ipv6Row(i uint32) (*Row, error) {
+	from, err := f.ipv6From(i)
+	if err != nil {
+		return nil, err
+	}
+	next, err := f.ipv6From(i + 1)
+	if err != nil {
+		return nil, err
+	}
+	offset := f.header.ipv6Base + i*f.ipv6RowSize()
+	row := f.fields(offset + 16)
+	row.From = from.addr()
+	row.To = next.decrement().addr()
+	if i == f.header.ipv6Count-1 && next == maxUint128 {
+		row.To = maxUint128.addr()
+	}
+	return row, nil
+}
+
+// fields reads the columns after ip_from of the row whose second column
+// starts at offset.
+func (f *File) fields(offset uint32) *Row {
+	position := columns[f.header.product]
+	read := func(column byte) string {
+		if column == 0 || column > f.header.columns {
+			return ""
+		}
+		return f.stringAt(f.uint32At(offset + uint32(column-2)*4))
+	}
+	row := &Row{
+		ProxyType: read(position.proxyType),
+		Region:    read(position.region),
+		City:      read(position.city),
+		ISP:       read(position.isp),
+		Domain:    read(position.domain),
+		UsageType: read(position.usageType),
+		ASN:       read(position.asn),
+		AS:        read(position.as),
+		LastSeen:  read(position.lastSeen),
+		Threat:    read(position.threat),
+		Provider:  read(position.provider),
+	}
+	// The country column points to the code, followed by the name 3 bytes
+	// later.
+	if position.country != 0 {
+		pointer := f.uint32At(offset + uint32(position.country-2)*4)
+		row.CountryCode = f.stringAt(pointer)
+		row.CountryName = f.stringAt(pointer + 3)
+	}
+	return row
+}
+
+// uint32At reads at a 1-based address.
+func (f *File) uint32At(address uint32) uint32 {
+	if address == 0 || int(address)+3 > len(f.data) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(f.data[address-1:])
+}
+
+// stringAt reads a length-prefixed string at a 0-based offset.
+func (f *File) stringAt(offset uint32) string {
+	if int(offset) >= len(f.data) {
+		return ""
+	}
+	end := int(offset) + 1 + int(f.data[offset])
+	if end > len(f.data) {
+		return ""
+	}
+	return string(f.data[offset+1 : end])
+}
+
+func fromUint32(ip uint32) netip.Addr {
+	var bytes [4]byte
+	binary.BigEndian.PutUint32(bytes[:], ip)
+	return netip.AddrFrom4(bytes)
+}
@@ -0,0 +1,244 @@
+package ip2location
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+var (
+	ipv4Rows = []*Row{
+		emptyRow("0.0.0.0"),
+		{From: netip.MustParseAddr("1.0.0.0"), ProxyType: "DCH", CountryCode: "AU", CountryName: "Australia",
+			Region: "Queensland", City: "Brisbane", ISP: "APNIC", Domain: "apnic.net", UsageType: "CDN",
+			ASN: "13335", AS: "Cloudflare"},
+		emptyRow("1.0.1.0"),
+		{From: netip.MustParseAddr("8.243.138.216"), ProxyType: "PUB", CountryCode: "AR", CountryName: "Argentina",
+			Region: "Buenos Aires", City: "Buenos Aires", ISP: "CTL LATAM", Domain: "centurylink.com",
+			UsageType: "ISP", ASN: "3356", AS: "Level 3 Parent LLC"},
+		emptyRow("8.243.138.227"),
+		{From: netip.MustParseAddr("255.255.255.0"), ProxyType: "VPN", CountryCode: "US", CountryName: "United States",
+			Region: "-", City: "-", ISP: "-", Domain: "-", UsageType: "-", ASN: "-", AS: "-"},
+	}
+	ipv6Rows = []*Row{
+		emptyRow("::"),
+		{From: netip.MustParseAddr("2001:db8::"), ProxyType: "TOR", CountryCode: "DE", CountryName: "Germany",
+			Region: "Berlin", City: "Berlin", ISP: "-", Domain: "-", UsageType: "-", ASN: "-", AS: "-"},
+		emptyRow("2001:db8:0:1::"),
+	}
+)
+
+func emptyRow(from string) *Row {
+	return &Row{From: netip.MustParseAddr(from), ProxyType: "-", CountryCode: "-", CountryName: "-",
+		Region: "-", City: "-", ISP: "-", Domain: "-", UsageType: "-", ASN: "-", AS: "-"}
+}
+
+// writeBIN builds a PX7 file with the given ranges, indexing them when
+// indexed is set.
+func writeBIN(t *testing.T, ipv4 []*Row, ipv6 []*Row, indexed bool) string {
+	const columns = 10
+	ipv4RowSize, ipv6RowSize := columns*4, 16+(columns-1)*4
+	ipv4IndexAt, ipv4At := headerSize, headerSize
+	if indexed {
+		ipv4At += indexSize
+	}
+	ipv6IndexAt := ipv4At + (len(ipv4)+1)*ipv4RowSize
+	ipv6At := ipv6IndexAt
+	if indexed {
+		ipv6At += indexSize
+	}
+	stringsAt := ipv6At + (len(ipv6)+1)*ipv6RowSize
+
+	data := make([]byte, stringsAt)
+	offsets := make(map[string]uint32)
+	addString := func(value string, padTo int) uint32 {
+		if offset, ok := offsets[value]; ok && padTo == 0 {
+			return offset
+		}
+		offset := uint32(len(data))
+		data = append(data, byte(len(value)))
+		data = append(data, value...)
+		for i := len(value); i < padTo; i++ {
+			data = append(data, 0)
+		}
+		if padTo == 0 {
+			offsets[value] = offset
+		}
+		return offset
+	}
+	writeFields := func(at int, row *Row) {
+		country := addString(row.CountryCode, 2)
+		addString(row.CountryName, len(row.CountryName))
+		for i, value := range []string{row.ProxyType, "", row.Region, row.City, row.ISP, row.Domain,
+			row.UsageType, row.ASN, row.AS} {
+			pointer := country
+			if i != 1 {
+				pointer = addString(value, 0)
+			}
+			binary.LittleEndian.PutUint32(data[at+i*4:], pointer)
+		}
+	}
+
+	data[0], data[1], data[2], data[3], data[4] = 7, columns, 24, 5, 1
+	binary.LittleEndian.PutUint32(data[5:], uint32(len(ipv4)))
+	binary.LittleEndian.PutUint32(data[9:], uint32(ipv4At+1))
+	binary.LittleEndian.PutUint32(data[13:], uint32(len(ipv6)))
+	binary.LittleEndian.PutUint32(data[17:], uint32(ipv6At+1))
+	if indexed {
+		binary.LittleEndian.PutUint32(data[21:], uint32(ipv4IndexAt+1))
+		binary.LittleEndian.PutUint32(data[25:], uint32(ipv6IndexAt+1))
+	}
+	data[29] = productIP2Proxy
+
+	ipv4From := make([]uint32, 0)
+	for i, row := range ipv4 {
+		from := binary.BigEndian.Uint32(row.From.AsSlice())
+		ipv4From = append(ipv4From, from)
+		binary.LittleEndian.PutUint32(data[ipv4At+i*ipv4RowSize:], from)
+		writeFields(ipv4At+i*ipv4RowSize+4, row)
+	}
+	binary.LittleEndian.PutUint32(data[ipv4At+len(ipv4)*ipv4RowSize:], math.MaxUint32)
+	ipv6From := make([]uint128, 0)
+	for i, row := range ipv6 {
+		from := toUint128(row.From)
+		ipv6From = append(ipv6From, from)
+		binary.LittleEndian.PutUint64(data[ipv6At+i*ipv6RowSize:], from.lo)
+		binary.LittleEndian.PutUint64(data[ipv6At+i*ipv6RowSize+8:], from.hi)
+		writeFields(ipv6At+i*ipv6RowSize+16, row)
+	}
+	binary.LittleEndian.PutUint64(data[ipv6At+len(ipv6)*ipv6RowSize:], math.MaxUint64)
+	binary.LittleEndian.PutUint64(data[ipv6At+len(ipv6)*ipv6RowSize+8:], math.MaxUint64)
+
+	if indexed {
+		for prefix := 0; prefix < 65536; prefix++ {
+			start, end := uint32(prefix)<<16, uint32(prefix)<<16|0xffff
+			containing := func(ip uint32) int {
+				return sort.Search(len(ipv4From), func(i int) bool { return ipv4From[i] > ip }) - 1
+			}
+			binary.LittleEndian.PutUint32(data[ipv4IndexAt+prefix*8:], uint32(containing(start)))
+			binary.LittleEndian.PutUint32(data[ipv4IndexAt+prefix*8+4:], uint32(containing(end)))
+
+			start6, end6 := uint128{hi: uint64(prefix) << 48}, uint128{hi: uint64(prefix)<<48 | (1<<48 - 1), lo: math.MaxUint64}
+			containing6 := func(ip uint128) int {
+				return sort.Search(len(ipv6From), func(i int) bool { return ip.less(ipv6From[i]) }) - 1
+			}
+			binary.LittleEndian.PutUint32(data[ipv6IndexAt+prefix*8:], uint32(containing6(start6)))
+			binary.LittleEndian.PutUint32(data[ipv6IndexAt+prefix*8+4:], uint32(containing6(end6)))
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "IP2PROXY-PX7.BIN")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestFile_Lookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    LoadMode
+		indexed bool
+	}{
+		{name: "memory mapped with index", mode: MemoryMapped, indexed: true},
+		{name: "in memory without index", mode: InMemory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := Open(writeBIN(t, ipv4Rows, ipv6Rows, tt.indexed), tt.mode)
+			require.NoError(t, err)
+			defer file.Close()
+			assert.Equal(t, 7, file.Product())
+			assert.Equal(t, "2024-05-01", file.Version())
+
+			row, ok, err := file.Lookup(netip.MustParseAddr("8.243.138.220"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, &Row{From: netip.MustParseAddr("8.243.138.216"), To: netip.MustParseAddr("8.243.138.226"),
+				ProxyType: "PUB", CountryCode: "AR", CountryName: "Argentina", Region: "Buenos Aires",
+				City: "Buenos Aires", ISP: "CTL LATAM", Domain: "centurylink.com", UsageType: "ISP",
+				ASN: "3356", AS: "Level 3 Parent LLC"}, row)
+
+			row, ok, err = file.Lookup(netip.MustParseAddr("::ffff:1.0.0.7"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, "Brisbane", row.City)
+
+			row, ok, err = file.Lookup(netip.MustParseAddr("255.255.255.255"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, "US", row.CountryCode)
+			assert.Equal(t, netip.MustParseAddr("255.255.255.255"), row.To)
+
+			row, ok, err = file.Lookup(netip.MustParseAddr("0.0.0.1"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, "-", row.CountryCode)
+
+			row, ok, err = file.Lookup(netip.MustParseAddr("2001:db8::1"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, "Germany", row.CountryName)
+			assert.Equal(t, netip.MustParseAddr("2001:db8::ffff:ffff:ffff:ffff"), row.To)
+
+			row, ok, err = file.Lookup(netip.MustParseAddr("2a00::1"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, "-", row.CountryCode)
+		})
+	}
+}
+
+func TestFile_WithoutIPv6(t *testing.T) {
+	file, err := Open(writeBIN(t, ipv4Rows, nil, true), InMemory)
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, ok, err := file.Lookup(netip.MustParseAddr("2001:db8::1"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "IP2LOCATION-DB11.BIN")
+	data, err := os.ReadFile(writeBIN(t, ipv4Rows, nil, false))
+	require.NoError(t, err)
+	data[29] = 1
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	_, err = Open(path, MemoryMapped)
+	assert.Equal(t, ErrInvalidFile, err)
+
+	_, err = Open(path, "lazy")
+	assert.EqualError(t, err, `unknown load mode "lazy"`)
+}
+
+func TestFile_Truncated(t *testing.T) {
+	data, err := os.ReadFile(writeBIN(t, ipv4Rows, ipv6Rows, true))
+	require.NoError(t, err)
+	ipv6Base := binary.LittleEndian.Uint32(data[17:])
+	ipv6Index := binary.LittleEndian.Uint32(data[25:])
+
+	_, err = newFile(data[:ipv6Base+16])
+	assert.Equal(t, ErrInvalidFile, err, "tables past the end are rejected on open")
+
+	// An index entry pointing past the last row is clamped to it.
+	corrupted := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(corrupted[ipv6Index-1+0x2001<<3+4:], 1000)
+	file, err := newFile(corrupted)
+	require.NoError(t, err)
+	row, ok, err := file.Lookup(netip.MustParseAddr("2001:db8::1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Germany", row.CountryName)
+
+	// Rows past the end of the data fail instead of panicking.
+	file.data = file.data[:ipv6Base+16]
+	_, ok, err = file.Lookup(netip.MustParseAddr("2001:db8::1"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+	assert.False(t, ok)
+}
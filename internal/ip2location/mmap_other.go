@@ -0,0 +1,17 @@
+//go:build !unix
+
+package ip2location
+
+import (
+	"io"
+	"os"
+)
+
+// mmap loads the whole file where memory mapping is not available.
+func mmap(file *os.File, size int) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,130 @@
+package ip2location
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	"net/netip"
+	"strconv"
+)
+
+var tracer = tracing.Tracer("github.com/mborroni/dreamlab-challenge/internal/ip2location")
+
+// Repository answers the ips repository methods from the IPv4 table of a BIN
+// file. The files cover the whole address space, the ranges without data in
+// any column are reported as missing like they are from ip2location_px7.
+//
+// Lookups search the table directly, listing and aggregates walk every range.
+type Repository struct {
+	*ips.Scanner
+	file    *File
+	product *ips.Product
+}
+
+func NewRepository(file *File) *Repository {
+	repository := &Repository{
+		file:    file,
+		product: ips.MustParseProduct(fmt.Sprintf("PX%d", file.Product())),
+	}
+	repository.Scanner = ips.NewScanner(repository)
+	return repository
+}
+
+// Product is the PX edition of the file.
+func (r *Repository) Product() *ips.Product {
+	return r.product
+}
+
+// Get follows the DBRepository contract, it fails with sql.ErrNoRows for
+// addresses without data.
+func (r *Repository) Get(ctx context.Context, decimalIP int64) (ip *ips.IP, err error) {
+	_, span := tracer.Start(ctx, "Repository.Get")
+	defer func() { tracing.End(span, err) }()
+
+	var bytes [4]byte
+	binary.BigEndian.PutUint32(bytes[:], uint32(decimalIP))
+	row, ok, err := r.file.Lookup(netip.AddrFrom4(bytes))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if ip, ok = toIP(row); !ok {
+		return nil, sql.ErrNoRows
+	}
+	return ip, nil
+}
+
+func (r *Repository) GetMany(ctx context.Context, decimalIPs []int64) (_ map[int64]*ips.IP, err error) {
+	ctx, span := tracer.Start(ctx, "Repository.GetMany")
+	defer func() { tracing.End(span, err) }()
+
+	found := make(map[int64]*ips.IP)
+	for _, decimal := range decimalIPs {
+		ip, err := r.Get(ctx, decimal)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found[decimal] = ip
+	}
+	return found, nil
+}
+
+// Walk calls fn with every IPv4 range with data in ascending order.
+func (r *Repository) Walk(ctx context.Context, fn func(*ips.IP) error) error {
+	return r.file.WalkIPv4(func(row *Row) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if ip, ok := toIP(row); ok {
+			return fn(ip)
+		}
+		return nil
+	})
+}
+
+// toIP converts an IPv4 row, reporting false when no column has data.
+func toIP(row *Row) (*ips.IP, bool) {
+	fields := []string{row.ProxyType, row.CountryCode, row.CountryName, row.Region, row.City,
+		row.ISP, row.Domain, row.UsageType, row.ASN, row.AS, row.LastSeen, row.Threat, row.Provider}
+	empty := true
+	for _, field := range fields {
+		if field != "" && field != "-" {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return nil, false
+	}
+	asn, _ := strconv.Atoi(row.ASN)
+	ip := &ips.IP{
+		From:      int64(binary.BigEndian.Uint32(row.From.AsSlice())),
+		To:        int64(binary.BigEndian.Uint32(row.To.AsSlice())),
+		ProxyType: row.ProxyType,
+		Country: ips.Country{
+			Code:   row.CountryCode,
+			Name:   row.CountryName,
+			Region: row.Region,
+			City:   row.City,
+		},
+		ISP:      row.ISP,
+		Domain:   row.Domain,
+		Usage:    row.UsageType,
+		ASN:      asn,
+		AS:       row.AS,
+		Threat:   row.Threat,
+		Provider: row.Provider,
+	}
+	if lastSeen, err := strconv.Atoi(row.LastSeen); err == nil {
+		ip.LastSeen = &lastSeen
+	}
+	return ip, true
+}
@@ -0,0 +1,44 @@
+package ip2location
+
+import (
+	"context"
+	"database/sql"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	file, err := Open(writeBIN(t, ipv4Rows, ipv6Rows, true), InMemory)
+	require.NoError(t, err)
+	defer file.Close()
+	repository := NewRepository(file)
+	ctx := context.Background()
+	assert.Equal(t, "PX7", repository.Product().Name)
+
+	ip, err := repository.Get(ctx, 150178522)
+	require.NoError(t, err)
+	assert.Equal(t, &ips.IP{From: 150178520, To: 150178530, ProxyType: "PUB", ISP: "CTL LATAM",
+		Domain: "centurylink.com", Usage: "ISP", ASN: 3356, AS: "Level 3 Parent LLC",
+		Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"}}, ip)
+
+	_, err = repository.Get(ctx, 134744072)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	found, err := repository.GetMany(ctx, []int64{16777217, 134744072})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Cloudflare", found[16777217].AS)
+
+	walked := make([]int64, 0)
+	require.NoError(t, repository.Walk(ctx, func(ip *ips.IP) error {
+		walked = append(walked, ip.From)
+		return nil
+	}))
+	assert.Equal(t, []int64{16777216, 150178520, 4294967040}, walked)
+
+	quantity, err := repository.GetIPQuantityByCountry(ctx, "Australia")
+	require.NoError(t, err)
+	assert.Equal(t, 256, quantity)
+}
@@ -0,0 +1,41 @@
+package ip2location
+
+import (
+	"encoding/binary"
+	"math"
+	"net/netip"
+)
+
+// uint128 is an IPv6 address as a number, the files store them little-endian.
+type uint128 struct {
+	hi uint64
+	lo uint64
+}
+
+var maxUint128 = uint128{hi: math.MaxUint64, lo: math.MaxUint64}
+
+func toUint128(addr netip.Addr) uint128 {
+	bytes := addr.As16()
+	return uint128{
+		hi: binary.BigEndian.Uint64(bytes[:8]),
+		lo: binary.BigEndian.Uint64(bytes[8:]),
+	}
+}
+
+func (u uint128) addr() netip.Addr {
+	var bytes [16]byte
+	binary.BigEndian.PutUint64(bytes[:8], u.hi)
+	binary.BigEndian.PutUint64(bytes[8:], u.lo)
+	return netip.AddrFrom16(bytes)
+}
+
+func (u uint128) less(other uint128) bool {
+	return u.hi < other.hi || (u.hi == other.hi && u.lo < other.lo)
+}
+
+func (u uint128) decrement() uint128 {
+	if u.lo == 0 {
+		return uint128{hi: u.hi - 1, lo: math.MaxUint64}
+	}
+	return uint128{hi: u.hi, lo: u.lo - 1}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"github.com/mborroni/dreamlab-challenge/internal/conversion"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"strings"
 )
 
 //go:generate mockgen -source=addresses.go -destination=addresses_mock.go -package=ips
@@ -11,8 +14,20 @@ import (
 type repository interface {
 	List(context.Context, int, map[string]interface{}) ([]*IP, error)
 	Get(context.Context, int64) (*IP, error)
+	GetMany(context.Context, []int64) (map[int64]*IP, error)
 	GetIPQuantityByCountry(context.Context, string) (int, error)
 	GetTop10ISPByCountry(context.Context, string) ([]string, error)
+	Near(context.Context, Circle, int) (*Nearby, error)
+	GetByDomain(context.Context, string, int) (*Domain, error)
+	SearchISPs(context.Context, string, string, int) ([]*ISPMatch, error)
+	ListCountries(context.Context) ([]*CountrySummary, error)
+	GetCountry(context.Context, string) (*CountrySummary, error)
+	ListRegions(context.Context, string) ([]*RegionSummary, error)
+	ListCities(context.Context, string, string) ([]*CityTotal, error)
+	GetCity(context.Context, string, string, string, int) (*City, error)
+	Sample(context.Context, int, int64, map[string]interface{}) ([]*IP, error)
+	// Product declares the columns the repository serves.
+	Product() *Product
 }
 
 type AddressesService struct {
@@ -25,7 +40,16 @@ func NewAddressesService(repository repository) *AddressesService {
 	}
 }
 
-func (s *AddressesService) List(ctx context.Context, limit int, filters map[string]interface{}) ([]*IP, error) {
+// Product is the IP2Location edition being served.
+func (s *AddressesService) Product() *Product {
+	return s.repository.Product()
+}
+
+func (s *AddressesService) List(ctx context.Context, limit int, filters map[string]interface{}) (_ []*IP, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.List")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.Int("limit", limit))
+
 	ips, err := s.repository.List(ctx, limit, filters)
 	if err != nil {
 		return nil, err
@@ -33,7 +57,11 @@ func (s *AddressesService) List(ctx context.Context, limit int, filters map[stri
 	return split(ips), nil
 }
 
-func (s *AddressesService) Get(ctx context.Context, inputIP string) (*IP, error) {
+func (s *AddressesService) Get(ctx context.Context, inputIP string) (_ *IP, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.Get")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("ip", inputIP))
+
 	decimal, err := conversion.IPv4ToDecimal(inputIP)
 	if err != nil {
 		return nil, err
@@ -48,7 +76,39 @@ func (s *AddressesService) Get(ctx context.Context, inputIP string) (*IP, error)
 	return ip, err
 }
 
-func (s *AddressesService) GetIPQuantityByCountry(ctx context.Context, country string) (int, error) {
+// GetMany looks up inputIPs with a single query, keyed by input. Addresses
+// not in the dataset are missing from the result.
+func (s *AddressesService) GetMany(ctx context.Context, inputIPs []string) (_ map[string]*IP, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.GetMany")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.Int("addresses", len(inputIPs)))
+
+	decimals := make([]int64, 0, len(inputIPs))
+	for _, input := range inputIPs {
+		decimal, err := conversion.IPv4ToDecimal(input)
+		if err != nil {
+			return nil, err
+		}
+		decimals = append(decimals, decimal)
+	}
+	found, err := s.repository.GetMany(ctx, decimals)
+	if err != nil {
+		return nil, err
+	}
+	ips := make(map[string]*IP)
+	for i, input := range inputIPs {
+		if ip, ok := found[decimals[i]]; ok {
+			ips[input] = ip
+		}
+	}
+	return ips, nil
+}
+
+func (s *AddressesService) GetIPQuantityByCountry(ctx context.Context, country string) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.GetIPQuantityByCountry")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("country", country))
+
 	quantity, err := s.repository.GetIPQuantityByCountry(ctx, country)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -59,10 +119,170 @@ func (s *AddressesService) GetIPQuantityByCountry(ctx context.Context, country s
 	return quantity, nil
 }
 
-func (s *AddressesService) GetTop10ISPByCountry(ctx context.Context, country string) ([]string, error) {
+func (s *AddressesService) GetTop10ISPByCountry(ctx context.Context, country string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.GetTop10ISPByCountry")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("country", country))
+
+	if err := s.repository.Product().Require(ColumnISP); err != nil {
+		return nil, err
+	}
 	return s.repository.GetTop10ISPByCountry(ctx, country)
 }
 
+// Near returns up to limit ranges located inside circle, closest first, and
+// the totals of every city in it.
+func (s *AddressesService) Near(ctx context.Context, circle Circle, limit int) (_ *Nearby, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.Near")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(
+		attribute.Float64("latitude", circle.Latitude),
+		attribute.Float64("longitude", circle.Longitude),
+		attribute.Float64("radius_km", circle.RadiusKm),
+	)
+
+	if err := s.repository.Product().Require(ColumnLatitude, ColumnLongitude); err != nil {
+		return nil, err
+	}
+	return s.repository.Near(ctx, circle, limit)
+}
+
+// GetByDomain returns up to limit ranges registered to domain or its
+// subdomains and the totals of all of them. Domains without ranges are
+// reported as nil.
+func (s *AddressesService) GetByDomain(ctx context.Context, domain string, limit int) (_ *Domain, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.GetByDomain")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("domain", domain))
+
+	if err := s.repository.Product().Require(ColumnDomain, ColumnISP); err != nil {
+		return nil, err
+	}
+	result, err := s.repository.GetByDomain(ctx, strings.ToLower(domain), limit)
+	if err != nil {
+		return nil, err
+	}
+	if result.Addresses == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// SearchISPs returns up to limit ISPs whose name resembles query, best match
+// first, with the addresses they hold in country or in every country when it
+// is empty.
+func (s *AddressesService) SearchISPs(ctx context.Context, query string, country string, limit int) (_ []*ISPMatch, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.SearchISPs")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("query", query), attribute.String("country", country))
+
+	if err := s.repository.Product().Require(ColumnISP); err != nil {
+		return nil, err
+	}
+	return s.repository.SearchISPs(ctx, query, country, limit)
+}
+
+func (s *AddressesService) ListCountries(ctx context.Context) (_ []*CountrySummary, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.ListCountries")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repository.ListCountries(ctx)
+}
+
+// GetCountry summarizes the country with the ISO code given, nil when the
+// dataset has no range in it.
+func (s *AddressesService) GetCountry(ctx context.Context, code string) (_ *CountrySummary, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.GetCountry")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("code", code))
+
+	country, err := s.repository.GetCountry(ctx, strings.ToUpper(code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return country, nil
+}
+
+// Sample draws n distinct addresses uniformly at random from the ranges
+// matching filters, the same seed draws the same addresses while the dataset
+// does not change.
+func (s *AddressesService) Sample(ctx context.Context, n int, seed int64, filters map[string]interface{}) (_ []*IP, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.Sample")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.Int("n", n), attribute.Int64("seed", seed))
+
+	product := s.repository.Product()
+	if sampleFilter(filters, SampleProxyType) != "" {
+		if err := product.Require(ColumnProxyType); err != nil {
+			return nil, err
+		}
+	}
+	if sampleFilter(filters, SampleUsage) != "" {
+		if err := product.Require(ColumnUsage); err != nil {
+			return nil, err
+		}
+	}
+	return s.repository.Sample(ctx, n, seed, filters)
+}
+
+// ListRegions summarizes the regions of the country with the ISO code
+// given. Countries without regions are reported as nil.
+func (s *AddressesService) ListRegions(ctx context.Context, code string) (_ []*RegionSummary, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.ListRegions")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("code", code))
+
+	if err := s.repository.Product().Require(ColumnRegion, ColumnCity); err != nil {
+		return nil, err
+	}
+	regions, err := s.repository.ListRegions(ctx, strings.ToUpper(code))
+	if err != nil || len(regions) == 0 {
+		return nil, err
+	}
+	return regions, nil
+}
+
+// ListCities summarizes the cities of a region. Regions without cities are
+// reported as nil.
+func (s *AddressesService) ListCities(ctx context.Context, code string, region string) (_ []*CityTotal, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.ListCities")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("code", code), attribute.String("region", region))
+
+	if err := s.repository.Product().Require(ColumnRegion, ColumnCity); err != nil {
+		return nil, err
+	}
+	cities, err := s.repository.ListCities(ctx, strings.ToUpper(code), region)
+	if err != nil || len(cities) == 0 {
+		return nil, err
+	}
+	return cities, nil
+}
+
+// GetCity returns the totals of a city and up to limit of its ranges, nil
+// when the dataset has no range in it.
+func (s *AddressesService) GetCity(ctx context.Context, code string, region string, city string, limit int) (_ *City, err error) {
+	ctx, span := tracer.Start(ctx, "AddressesService.GetCity")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("code", code), attribute.String("region", region),
+		attribute.String("city", city))
+
+	if err := s.repository.Product().Require(ColumnRegion, ColumnCity); err != nil {
+		return nil, err
+	}
+	result, err := s.repository.GetCity(ctx, strings.ToUpper(code), region, city, limit)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
 func split(input []*IP) []*IP {
 	ips := make([]*IP, 0)
 	for _, ip := range input {
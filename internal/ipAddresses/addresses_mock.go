@@ -63,6 +63,21 @@ func (mr *MockrepositoryMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockrepository)(nil).Get), arg0, arg1)
 }
 
+// GetMany mocks base method
+func (m *Mockrepository) GetMany(arg0 context.Context, arg1 []int64) (map[int64]*IP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMany", arg0, arg1)
+	ret0, _ := ret[0].(map[int64]*IP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMany indicates an expected call of GetMany
+func (mr *MockrepositoryMockRecorder) GetMany(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*Mockrepository)(nil).GetMany), arg0, arg1)
+}
+
 // GetIPQuantityByCountry mocks base method
 func (m *Mockrepository) GetIPQuantityByCountry(arg0 context.Context, arg1 string) (int, error) {
 	m.ctrl.T.Helper()
@@ -92,3 +107,152 @@ func (mr *MockrepositoryMockRecorder) GetTop10ISPByCountry(arg0, arg1 interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTop10ISPByCountry", reflect.TypeOf((*Mockrepository)(nil).GetTop10ISPByCountry), arg0, arg1)
 }
+
+// Near mocks base method
+func (m *Mockrepository) Near(arg0 context.Context, arg1 Circle, arg2 int) (*Nearby, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Near", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*Nearby)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Near indicates an expected call of Near
+func (mr *MockrepositoryMockRecorder) Near(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Near", reflect.TypeOf((*Mockrepository)(nil).Near), arg0, arg1, arg2)
+}
+
+// GetByDomain mocks base method
+func (m *Mockrepository) GetByDomain(arg0 context.Context, arg1 string, arg2 int) (*Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByDomain", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByDomain indicates an expected call of GetByDomain
+func (mr *MockrepositoryMockRecorder) GetByDomain(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByDomain", reflect.TypeOf((*Mockrepository)(nil).GetByDomain), arg0, arg1, arg2)
+}
+
+// SearchISPs mocks base method
+func (m *Mockrepository) SearchISPs(arg0 context.Context, arg1, arg2 string, arg3 int) ([]*ISPMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchISPs", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*ISPMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchISPs indicates an expected call of SearchISPs
+func (mr *MockrepositoryMockRecorder) SearchISPs(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchISPs", reflect.TypeOf((*Mockrepository)(nil).SearchISPs), arg0, arg1, arg2, arg3)
+}
+
+// ListCountries mocks base method
+func (m *Mockrepository) ListCountries(arg0 context.Context) ([]*CountrySummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCountries", arg0)
+	ret0, _ := ret[0].([]*CountrySummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCountries indicates an expected call of ListCountries
+func (mr *MockrepositoryMockRecorder) ListCountries(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCountries", reflect.TypeOf((*Mockrepository)(nil).ListCountries), arg0)
+}
+
+// GetCountry mocks base method
+func (m *Mockrepository) GetCountry(arg0 context.Context, arg1 string) (*CountrySummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCountry", arg0, arg1)
+	ret0, _ := ret[0].(*CountrySummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCountry indicates an expected call of GetCountry
+func (mr *MockrepositoryMockRecorder) GetCountry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCountry", reflect.TypeOf((*Mockrepository)(nil).GetCountry), arg0, arg1)
+}
+
+// ListRegions mocks base method
+func (m *Mockrepository) ListRegions(arg0 context.Context, arg1 string) ([]*RegionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRegions", arg0, arg1)
+	ret0, _ := ret[0].([]*RegionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRegions indicates an expected call of ListRegions
+func (mr *MockrepositoryMockRecorder) ListRegions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRegions", reflect.TypeOf((*Mockrepository)(nil).ListRegions), arg0, arg1)
+}
+
+// ListCities mocks base method
+func (m *Mockrepository) ListCities(arg0 context.Context, arg1, arg2 string) ([]*CityTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCities", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*CityTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCities indicates an expected call of ListCities
+func (mr *MockrepositoryMockRecorder) ListCities(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCities", reflect.TypeOf((*Mockrepository)(nil).ListCities), arg0, arg1, arg2)
+}
+
+// GetCity mocks base method
+func (m *Mockrepository) GetCity(arg0 context.Context, arg1, arg2, arg3 string, arg4 int) (*City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCity", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCity indicates an expected call of GetCity
+func (mr *MockrepositoryMockRecorder) GetCity(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCity", reflect.TypeOf((*Mockrepository)(nil).GetCity), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Sample mocks base method
+func (m *Mockrepository) Sample(arg0 context.Context, arg1 int, arg2 int64, arg3 map[string]interface{}) ([]*IP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sample", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*IP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sample indicates an expected call of Sample
+func (mr *MockrepositoryMockRecorder) Sample(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sample", reflect.TypeOf((*Mockrepository)(nil).Sample), arg0, arg1, arg2, arg3)
+}
+
+// Product mocks base method
+func (m *Mockrepository) Product() *Product {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Product")
+	ret0, _ := ret[0].(*Product)
+	return ret0
+}
+
+// Product indicates an expected call of Product
+func (mr *MockrepositoryMockRecorder) Product() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Product", reflect.TypeOf((*Mockrepository)(nil).Product))
+}
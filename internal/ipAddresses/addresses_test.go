@@ -132,6 +132,27 @@ func TestAddressesService_Get(t *testing.T) {
 	}
 }
 
+func TestAddressesService_GetMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	buenosAires := &IP{From: 150178520, To: 150178530, Country: Country{Code: "AR", Name: "Argentina"}}
+
+	service.repository.(*Mockrepository).
+		EXPECT().
+		GetMany(gomock.Any(), []int64{150178522, 16778241}).
+		Return(map[int64]*IP{150178522: buenosAires}, nil)
+
+	ips, err := service.GetMany(context.Background(), []string{"8.243.138.218", "1.0.4.1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*IP{"8.243.138.218": buenosAires}, ips)
+
+	_, err = service.GetMany(context.Background(), []string{"8.243"})
+	assert.Error(t, err)
+}
+
 func TestAddressesService_List(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -380,6 +401,10 @@ func TestAddressesService_GetTopNISPByCountry(t *testing.T) {
 				country: "Switzerland",
 			},
 			expectations: func(fields fields) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					Product().
+					Return(MustParseProduct("PX7"))
 				service.repository.(*Mockrepository).
 					EXPECT().
 					GetTop10ISPByCountry(gomock.Any(), gomock.Any()).
@@ -407,6 +432,10 @@ func TestAddressesService_GetTopNISPByCountry(t *testing.T) {
 				country: "Switzerland",
 			},
 			expectations: func(fields fields) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					Product().
+					Return(MustParseProduct("PX7"))
 				service.repository.(*Mockrepository).
 					EXPECT().
 					GetTop10ISPByCountry(gomock.Any(), gomock.Any()).
@@ -417,6 +446,21 @@ func TestAddressesService_GetTopNISPByCountry(t *testing.T) {
 				err:      sql.ErrConnDone,
 			},
 		},
+		{name: "product without isp",
+			fields: fields{
+				country: "Switzerland",
+			},
+			expectations: func(fields fields) {
+				service.repository.(*Mockrepository).
+					EXPECT().
+					Product().
+					Return(MustParseProduct("DB3"))
+			},
+			want: want{
+				quantity: nil,
+				err:      &ColumnError{Product: "DB3", Column: ColumnISP},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -428,3 +472,176 @@ func TestAddressesService_GetTopNISPByCountry(t *testing.T) {
 		})
 	}
 }
+
+func TestAddressesService_Near(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	circle := Circle{Latitude: -34.6, Longitude: -58.4, RadiusKm: 25}
+	expected := &Nearby{Ranges: []*IP{{From: 150178520, To: 150178530}}}
+
+	service.repository.(*Mockrepository).EXPECT().Product().Return(MustParseProduct("DB11"))
+	service.repository.(*Mockrepository).EXPECT().Near(gomock.Any(), circle, 10).Return(expected, nil)
+	nearby, err := service.Near(context.Background(), circle, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, nearby)
+
+	service.repository.(*Mockrepository).EXPECT().Product().Return(MustParseProduct("PX7"))
+	_, err = service.Near(context.Background(), circle, 10)
+	assert.Equal(t, &ColumnError{Product: "PX7", Column: ColumnLatitude}, err)
+}
+
+func TestAddressesService_GetByDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	repository := service.repository.(*Mockrepository)
+	expected := &Domain{Name: "centurylink.com", Addresses: 11, Ranges: []*IP{{From: 150178520, To: 150178530}}}
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX7")).Times(2)
+	repository.EXPECT().GetByDomain(gomock.Any(), "centurylink.com", 10).Return(expected, nil)
+	domain, err := service.GetByDomain(context.Background(), "CenturyLink.com", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, domain)
+
+	repository.EXPECT().GetByDomain(gomock.Any(), "example.com", 10).Return(&Domain{Name: "example.com"}, nil)
+	domain, err = service.GetByDomain(context.Background(), "example.com", 10)
+	assert.NoError(t, err)
+	assert.Nil(t, domain)
+
+	repository.EXPECT().Product().Return(MustParseProduct("DB11"))
+	_, err = service.GetByDomain(context.Background(), "example.com", 10)
+	assert.Equal(t, &ColumnError{Product: "DB11", Column: ColumnDomain}, err)
+}
+
+func TestAddressesService_SearchISPs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	repository := service.repository.(*Mockrepository)
+	expected := []*ISPMatch{{ISP: "Swisscom AG", Score: 1, Ranges: 1, Addresses: 10}}
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX7"))
+	repository.EXPECT().SearchISPs(gomock.Any(), "swisscom", "CH", 10).Return(expected, nil)
+	matches, err := service.SearchISPs(context.Background(), "swisscom", "CH", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, matches)
+
+	repository.EXPECT().Product().Return(MustParseProduct("DB1"))
+	_, err = service.SearchISPs(context.Background(), "swisscom", "CH", 10)
+	assert.Equal(t, &ColumnError{Product: "DB1", Column: ColumnISP}, err)
+}
+
+func TestAddressesService_GetCountry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	repository := service.repository.(*Mockrepository)
+	expected := &CountrySummary{Country: Country{Code: "AR", Name: "Argentina"}, Ranges: 3, Addresses: 16}
+
+	repository.EXPECT().GetCountry(gomock.Any(), "AR").Return(expected, nil)
+	country, err := service.GetCountry(context.Background(), "ar")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, country)
+
+	repository.EXPECT().GetCountry(gomock.Any(), "ZZ").Return(nil, sql.ErrNoRows)
+	country, err = service.GetCountry(context.Background(), "ZZ")
+	assert.NoError(t, err)
+	assert.Nil(t, country)
+
+	repository.EXPECT().GetCountry(gomock.Any(), "AR").Return(nil, errors.New("connection refused"))
+	_, err = service.GetCountry(context.Background(), "AR")
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestAddressesService_ListRegions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	repository := service.repository.(*Mockrepository)
+	expected := []*RegionSummary{{Country: Country{Code: "AR", Name: "Argentina", Region: "Cordoba"}, Ranges: 1}}
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX7")).Times(2)
+	repository.EXPECT().ListRegions(gomock.Any(), "AR").Return(expected, nil)
+	regions, err := service.ListRegions(context.Background(), "ar")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, regions)
+
+	repository.EXPECT().ListRegions(gomock.Any(), "ZZ").Return([]*RegionSummary{}, nil)
+	regions, err = service.ListRegions(context.Background(), "ZZ")
+	assert.NoError(t, err)
+	assert.Nil(t, regions)
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX2"))
+	_, err = service.ListRegions(context.Background(), "AR")
+	assert.Equal(t, &ColumnError{Product: "PX2", Column: ColumnRegion}, err)
+}
+
+func TestAddressesService_ListCities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	repository := service.repository.(*Mockrepository)
+	expected := []*CityTotal{{Country: Country{Code: "AR", Region: "Cordoba", City: "Cordoba"}, Ranges: 1}}
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX7")).Times(2)
+	repository.EXPECT().ListCities(gomock.Any(), "AR", "Cordoba").Return(expected, nil)
+	cities, err := service.ListCities(context.Background(), "ar", "Cordoba")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cities)
+
+	repository.EXPECT().ListCities(gomock.Any(), "AR", "Atlantis").Return([]*CityTotal{}, nil)
+	cities, err = service.ListCities(context.Background(), "AR", "Atlantis")
+	assert.NoError(t, err)
+	assert.Nil(t, cities)
+}
+
+func TestAddressesService_GetCity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	repository := service.repository.(*Mockrepository)
+	expected := &City{Total: CityTotal{Country: Country{Code: "AR", City: "Cordoba"}, Ranges: 1, Addresses: 10}}
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX7")).Times(3)
+	repository.EXPECT().GetCity(gomock.Any(), "AR", "Cordoba", "Cordoba", 10).Return(expected, nil)
+	city, err := service.GetCity(context.Background(), "ar", "Cordoba", "Cordoba", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, city)
+
+	repository.EXPECT().GetCity(gomock.Any(), "AR", "Cordoba", "Atlantis", 10).Return(nil, sql.ErrNoRows)
+	city, err = service.GetCity(context.Background(), "AR", "Cordoba", "Atlantis", 10)
+	assert.NoError(t, err)
+	assert.Nil(t, city)
+
+	repository.EXPECT().GetCity(gomock.Any(), "AR", "Cordoba", "Cordoba", 10).Return(nil, errors.New("connection refused"))
+	_, err = service.GetCity(context.Background(), "AR", "Cordoba", "Cordoba", 10)
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestAddressesService_Sample(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockAddressesService(ctrl)
+	repository := service.repository.(*Mockrepository)
+	filters := map[string]interface{}{SampleCountry: "CH", SampleUsage: "DCH"}
+	expected := []*IP{{From: 12, To: 12, Usage: "DCH", Country: Country{Code: "CH", Name: "Switzerland"}}}
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX7"))
+	repository.EXPECT().Sample(gomock.Any(), 20, int64(42), filters).Return(expected, nil)
+	sample, err := service.Sample(context.Background(), 20, 42, filters)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sample)
+
+	repository.EXPECT().Product().Return(MustParseProduct("PX2"))
+	_, err = service.Sample(context.Background(), 20, 42, filters)
+	assert.Equal(t, &ColumnError{Product: "PX2", Column: ColumnUsage}, err)
+}
@@ -0,0 +1,47 @@
+package ips
+
+// CountrySummary describes a country of the dataset: its ranges, the
+// addresses they hold and how many regions and cities they are located in.
+type CountrySummary struct {
+	Country   Country
+	Ranges    int
+	Addresses int64
+	Regions   int
+	Cities    int
+}
+
+// targets returns where to scan a row of the country queries.
+func (c *CountrySummary) targets() []interface{} {
+	return []interface{}{&c.Country.Code, &c.Country.Name, &c.Ranges, &c.Addresses, &c.Regions, &c.Cities}
+}
+
+// RegionSummary counts the ranges of a region, the addresses they hold and the
+// cities they are located in. Its Country carries the code, name and region.
+type RegionSummary struct {
+	Country   Country
+	Ranges    int
+	Addresses int64
+	Cities    int
+}
+
+func (r *RegionSummary) targets() []interface{} {
+	return []interface{}{&r.Country.Code, &r.Country.Name, &r.Country.Region, &r.Ranges, &r.Addresses, &r.Cities}
+}
+
+func (c *CityTotal) targets() []interface{} {
+	return []interface{}{&c.Country.Code, &c.Country.Name, &c.Country.Region, &c.Country.City, &c.Ranges,
+		&c.Addresses}
+}
+
+// City holds the totals of a city and up to a limit of its ranges, in
+// ascending order.
+type City struct {
+	Total  CityTotal
+	Ranges []*IP
+}
+
+// inCity tells whether ip is located in the city of region in the country
+// with the code given.
+func inCity(ip *IP, code string, region string, city string) bool {
+	return ip.Country.Code == code && ip.Country.Region == region && ip.Country.City == city
+}
@@ -0,0 +1,109 @@
+package ips
+
+import (
+	"sort"
+	"strings"
+)
+
+// Domain holds the ranges registered to a domain or its subdomains and their
+// totals.
+type Domain struct {
+	Name      string
+	Ranges    []*IP
+	Addresses int64
+	Countries []*CountryTotal
+	ISPs      []*ISPTotal
+}
+
+// CountryTotal counts the ranges of a country and the addresses they hold.
+type CountryTotal struct {
+	Country   Country
+	Ranges    int
+	Addresses int64
+}
+
+// ISPTotal counts the ranges of an ISP and the addresses they hold.
+type ISPTotal struct {
+	ISP       string
+	Ranges    int
+	Addresses int64
+}
+
+// domainPattern is the LIKE pattern matching the reversed domain column
+// prefixed with a dot, so example.com matches example.com and
+// www.example.com but not myexample.com.
+func domainPattern(domain string) string {
+	return reverse("."+domain) + "%"
+}
+
+// matchesDomain applies domainPattern to a single value.
+func matchesDomain(value string, domain string) bool {
+	return strings.HasSuffix("."+value, "."+domain)
+}
+
+func reverse(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// domainTotals accumulates the totals of a domain, row by row or range by
+// range.
+type domainTotals struct {
+	domain    *Domain
+	countries map[Country]*CountryTotal
+	isps      map[string]*ISPTotal
+}
+
+func newDomainTotals(domain *Domain) *domainTotals {
+	return &domainTotals{
+		domain:    domain,
+		countries: make(map[Country]*CountryTotal),
+		isps:      make(map[string]*ISPTotal),
+	}
+}
+
+func (t *domainTotals) add(country Country, isp string, ranges int, addresses int64) {
+	t.domain.Addresses += addresses
+	countryTotal, ok := t.countries[country]
+	if !ok {
+		countryTotal = &CountryTotal{Country: country}
+		t.countries[country] = countryTotal
+	}
+	countryTotal.Ranges += ranges
+	countryTotal.Addresses += addresses
+	ispTotal, ok := t.isps[isp]
+	if !ok {
+		ispTotal = &ISPTotal{ISP: isp}
+		t.isps[isp] = ispTotal
+	}
+	ispTotal.Ranges += ranges
+	ispTotal.Addresses += addresses
+}
+
+// finish sorts the totals by addresses, then by name.
+func (t *domainTotals) finish() *Domain {
+	for _, total := range t.countries {
+		t.domain.Countries = append(t.domain.Countries, total)
+	}
+	sort.Slice(t.domain.Countries, func(i, j int) bool {
+		a, b := t.domain.Countries[i], t.domain.Countries[j]
+		if a.Addresses != b.Addresses {
+			return a.Addresses > b.Addresses
+		}
+		return a.Country.Name < b.Country.Name
+	})
+	for _, total := range t.isps {
+		t.domain.ISPs = append(t.domain.ISPs, total)
+	}
+	sort.Slice(t.domain.ISPs, func(i, j int) bool {
+		a, b := t.domain.ISPs[i], t.domain.ISPs[j]
+		if a.Addresses != b.Addresses {
+			return a.Addresses > b.Addresses
+		}
+		return a.ISP < b.ISP
+	})
+	return t.domain
+}
@@ -0,0 +1,123 @@
+package ips
+
+import (
+	"context"
+	"github.com/mborroni/dreamlab-challenge/internal/metrics"
+	"time"
+)
+
+// InstrumentedRepository records latency and errors of every call to the
+// wrapped repository.
+type InstrumentedRepository struct {
+	name       string
+	repository repository
+}
+
+func NewInstrumentedRepository(name string, repository repository) *InstrumentedRepository {
+	return &InstrumentedRepository{
+		name:       name,
+		repository: repository,
+	}
+}
+
+func (r *InstrumentedRepository) List(ctx context.Context, limit int, filters map[string]interface{}) ([]*IP, error) {
+	start := time.Now()
+	ips, err := r.repository.List(ctx, limit, filters)
+	metrics.ObserveQuery(r.name, "List", time.Since(start), err)
+	return ips, err
+}
+
+func (r *InstrumentedRepository) Get(ctx context.Context, decimalIP int64) (*IP, error) {
+	start := time.Now()
+	ip, err := r.repository.Get(ctx, decimalIP)
+	metrics.ObserveQuery(r.name, "Get", time.Since(start), err)
+	return ip, err
+}
+
+func (r *InstrumentedRepository) GetMany(ctx context.Context, decimalIPs []int64) (map[int64]*IP, error) {
+	start := time.Now()
+	ips, err := r.repository.GetMany(ctx, decimalIPs)
+	metrics.ObserveQuery(r.name, "GetMany", time.Since(start), err)
+	return ips, err
+}
+
+func (r *InstrumentedRepository) GetIPQuantityByCountry(ctx context.Context, country string) (int, error) {
+	start := time.Now()
+	quantity, err := r.repository.GetIPQuantityByCountry(ctx, country)
+	metrics.ObserveQuery(r.name, "GetIPQuantityByCountry", time.Since(start), err)
+	return quantity, err
+}
+
+func (r *InstrumentedRepository) GetTop10ISPByCountry(ctx context.Context, country string) ([]string, error) {
+	start := time.Now()
+	isps, err := r.repository.GetTop10ISPByCountry(ctx, country)
+	metrics.ObserveQuery(r.name, "GetTop10ISPByCountry", time.Since(start), err)
+	return isps, err
+}
+
+func (r *InstrumentedRepository) Near(ctx context.Context, circle Circle, limit int) (*Nearby, error) {
+	start := time.Now()
+	nearby, err := r.repository.Near(ctx, circle, limit)
+	metrics.ObserveQuery(r.name, "Near", time.Since(start), err)
+	return nearby, err
+}
+
+func (r *InstrumentedRepository) GetByDomain(ctx context.Context, domain string, limit int) (*Domain, error) {
+	start := time.Now()
+	result, err := r.repository.GetByDomain(ctx, domain, limit)
+	metrics.ObserveQuery(r.name, "GetByDomain", time.Since(start), err)
+	return result, err
+}
+
+func (r *InstrumentedRepository) SearchISPs(ctx context.Context, query string, country string, limit int) ([]*ISPMatch, error) {
+	start := time.Now()
+	matches, err := r.repository.SearchISPs(ctx, query, country, limit)
+	metrics.ObserveQuery(r.name, "SearchISPs", time.Since(start), err)
+	return matches, err
+}
+
+func (r *InstrumentedRepository) ListCountries(ctx context.Context) ([]*CountrySummary, error) {
+	start := time.Now()
+	countries, err := r.repository.ListCountries(ctx)
+	metrics.ObserveQuery(r.name, "ListCountries", time.Since(start), err)
+	return countries, err
+}
+
+func (r *InstrumentedRepository) GetCountry(ctx context.Context, code string) (*CountrySummary, error) {
+	start := time.Now()
+	country, err := r.repository.GetCountry(ctx, code)
+	metrics.ObserveQuery(r.name, "GetCountry", time.Since(start), err)
+	return country, err
+}
+
+func (r *InstrumentedRepository) Sample(ctx context.Context, n int, seed int64, filters map[string]interface{}) ([]*IP, error) {
+	start := time.Now()
+	ips, err := r.repository.Sample(ctx, n, seed, filters)
+	metrics.ObserveQuery(r.name, "Sample", time.Since(start), err)
+	return ips, err
+}
+
+func (r *InstrumentedRepository) ListRegions(ctx context.Context, code string) ([]*RegionSummary, error) {
+	start := time.Now()
+	regions, err := r.repository.ListRegions(ctx, code)
+	metrics.ObserveQuery(r.name, "ListRegions", time.Since(start), err)
+	return regions, err
+}
+
+func (r *InstrumentedRepository) ListCities(ctx context.Context, code string, region string) ([]*CityTotal, error) {
+	start := time.Now()
+	cities, err := r.repository.ListCities(ctx, code, region)
+	metrics.ObserveQuery(r.name, "ListCities", time.Since(start), err)
+	return cities, err
+}
+
+func (r *InstrumentedRepository) GetCity(ctx context.Context, code string, region string, city string, limit int) (*City, error) {
+	start := time.Now()
+	result, err := r.repository.GetCity(ctx, code, region, city, limit)
+	metrics.ObserveQuery(r.name, "GetCity", time.Since(start), err)
+	return result, err
+}
+
+func (r *InstrumentedRepository) Product() *Product {
+	return r.repository.Product()
+}
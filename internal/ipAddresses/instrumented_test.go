@@ -0,0 +1,41 @@
+package ips
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInstrumentedRepository_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockrepository(ctrl)
+	repository := NewInstrumentedRepository("test", mock)
+
+	mock.EXPECT().Get(gomock.Any(), int64(16778497)).Return(nil, sql.ErrNoRows)
+
+	ip, err := repository.Get(context.Background(), 16778497)
+
+	assert.Nil(t, ip)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestInstrumentedRepository_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockrepository(ctrl)
+	repository := NewInstrumentedRepository("test", mock)
+	filters := map[string]interface{}{"country": "Argentina"}
+	expected := []*IP{{From: 1, To: 2}}
+
+	mock.EXPECT().List(gomock.Any(), 10, filters).Return(expected, nil)
+
+	ips, err := repository.List(context.Background(), 10, filters)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, ips)
+}
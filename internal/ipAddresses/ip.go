@@ -1,5 +1,7 @@
 package ips
 
+// IP is a range of the served product, the fields of columns the product
+// lacks are left empty.
 type IP struct {
 	From      int64
 	To        int64
@@ -10,6 +12,14 @@ type IP struct {
 	Usage     string
 	ASN       int
 	AS        string
+	// LastSeen is the number of days since the proxy was last seen.
+	LastSeen  *int
+	Threat    string
+	Provider  string
+	Latitude  *float64
+	Longitude *float64
+	ZipCode   string
+	TimeZone  string
 }
 
 type Country struct {
@@ -18,3 +28,48 @@ type Country struct {
 	Region string
 	City   string
 }
+
+// targets returns where to scan the columns of a row selected as ip_from,
+// ip_to and columns.
+func (ip *IP) targets(columns []string) []interface{} {
+	targets := []interface{}{&ip.From, &ip.To}
+	for _, column := range columns {
+		switch column {
+		case ColumnProxyType:
+			targets = append(targets, &ip.ProxyType)
+		case ColumnCountryCode:
+			targets = append(targets, &ip.Country.Code)
+		case ColumnCountryName:
+			targets = append(targets, &ip.Country.Name)
+		case ColumnRegion:
+			targets = append(targets, &ip.Country.Region)
+		case ColumnCity:
+			targets = append(targets, &ip.Country.City)
+		case ColumnISP:
+			targets = append(targets, &ip.ISP)
+		case ColumnDomain:
+			targets = append(targets, &ip.Domain)
+		case ColumnUsage:
+			targets = append(targets, &ip.Usage)
+		case ColumnASN:
+			targets = append(targets, &ip.ASN)
+		case ColumnAS:
+			targets = append(targets, &ip.AS)
+		case ColumnLastSeen:
+			targets = append(targets, &ip.LastSeen)
+		case ColumnThreat:
+			targets = append(targets, &ip.Threat)
+		case ColumnProvider:
+			targets = append(targets, &ip.Provider)
+		case ColumnLatitude:
+			targets = append(targets, &ip.Latitude)
+		case ColumnLongitude:
+			targets = append(targets, &ip.Longitude)
+		case ColumnZipCode:
+			targets = append(targets, &ip.ZipCode)
+		case ColumnTimeZone:
+			targets = append(targets, &ip.TimeZone)
+		}
+	}
+	return targets
+}
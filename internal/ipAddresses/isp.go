@@ -0,0 +1,124 @@
+package ips
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ispSimilarityThreshold is the default pg_trgm.word_similarity_threshold,
+// the least score the <% operator matches.
+const ispSimilarityThreshold = 0.6
+
+// ISPMatch is an ISP found by a fuzzy search with the addresses it holds.
+type ISPMatch struct {
+	ISP string
+	// Score goes from 0 to 1, 1 when the query is a whole word of the name.
+	Score     float64
+	Ranges    int
+	Addresses int64
+	Countries []*CountryTotal
+}
+
+// ispMatches groups the rows of a search by ISP and ranks them by score and
+// then by addresses.
+type ispMatches map[string]*ispMatchTotals
+
+type ispMatchTotals struct {
+	match     *ISPMatch
+	countries map[Country]*CountryTotal
+}
+
+func (m ispMatches) add(isp string, score float64, country Country, ranges int, addresses int64) {
+	totals, ok := m[isp]
+	if !ok {
+		totals = &ispMatchTotals{
+			match:     &ISPMatch{ISP: isp, Score: score, Countries: make([]*CountryTotal, 0)},
+			countries: make(map[Country]*CountryTotal),
+		}
+		m[isp] = totals
+	}
+	totals.match.Ranges += ranges
+	totals.match.Addresses += addresses
+	countryTotal, ok := totals.countries[country]
+	if !ok {
+		countryTotal = &CountryTotal{Country: country}
+		totals.countries[country] = countryTotal
+		totals.match.Countries = append(totals.match.Countries, countryTotal)
+	}
+	countryTotal.Ranges += ranges
+	countryTotal.Addresses += addresses
+}
+
+func (m ispMatches) ranked(limit int) []*ISPMatch {
+	matches := make([]*ISPMatch, 0, len(m))
+	for _, totals := range m {
+		sort.Slice(totals.match.Countries, func(i, j int) bool {
+			a, b := totals.match.Countries[i], totals.match.Countries[j]
+			if a.Addresses != b.Addresses {
+				return a.Addresses > b.Addresses
+			}
+			return a.Country.Name < b.Country.Name
+		})
+		matches = append(matches, totals.match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Addresses != matches[j].Addresses {
+			return matches[i].Addresses > matches[j].Addresses
+		}
+		return matches[i].ISP < matches[j].ISP
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// wordSimilarity approximates pg_trgm word_similarity for the file
+// repositories: the best similarity between the trigrams of query and those
+// of any run of consecutive words of value.
+func wordSimilarity(query string, value string) float64 {
+	queryTrigrams := trigrams(words(query))
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+	valueWords := words(value)
+	best := 0.0
+	for start := range valueWords {
+		for end := start + 1; end <= len(valueWords); end++ {
+			extent := trigrams(valueWords[start:end])
+			shared := 0
+			for trigram := range queryTrigrams {
+				if extent[trigram] {
+					shared++
+				}
+			}
+			if similarity := float64(shared) / float64(len(queryTrigrams)+len(extent)-shared); similarity > best {
+				best = similarity
+			}
+		}
+	}
+	return best
+}
+
+// words splits value as pg_trgm does, lowercase runs of letters and digits.
+func words(value string) []string {
+	return strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigrams of each word padded with two spaces before and one after.
+func trigrams(words []string) map[string]bool {
+	trigrams := make(map[string]bool)
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			trigrams[string(padded[i:i+3])] = true
+		}
+	}
+	return trigrams
+}
@@ -0,0 +1,29 @@
+package ips
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWordSimilarity(t *testing.T) {
+	tests := []struct {
+		query string
+		value string
+		match bool
+	}{
+		{query: "swisscom", value: "Swisscom AG", match: true},
+		{query: "SWISSCOM", value: "Swisscom (Schweiz) AG", match: true},
+		{query: "swiscom", value: "Swisscom AG", match: true},
+		{query: "rapid seedbox", value: "RapidSeedbox Ltd", match: true},
+		{query: "google", value: "Swisscom AG", match: false},
+		{query: "swisscom", value: "Bluewin is an LIR and ISP in Switzerland.", match: false},
+		{query: "swisscom", value: "-", match: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query+" in "+tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.match, wordSimilarity(tt.query, tt.value) >= ispSimilarityThreshold)
+		})
+	}
+	assert.Equal(t, 1.0, wordSimilarity("swisscom", "Swisscom AG"))
+	assert.Zero(t, wordSimilarity("", "Swisscom AG"))
+}
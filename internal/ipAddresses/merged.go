@@ -0,0 +1,118 @@
+package ips
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+)
+
+// MergedRepository joins a geolocation product, such as DB11, to the ranges
+// of the primary one by address. Lookups return the primary range narrowed
+// to the part the geolocation range also covers, with the columns only the
+// geolocation product has, since the boundaries of both products rarely
+// match. Addresses missing from the primary product are not found even when
+// geolocated, listing and aggregates only read the primary product while the
+// radius search reads the product with coordinates.
+type MergedRepository struct {
+	repository
+	geolocation repository
+	product     *Product
+	columns     []string
+}
+
+func NewMergedRepository(primary repository, geolocation repository) *MergedRepository {
+	product := primary.Product().Merge(geolocation.Product())
+	columns := make([]string, 0)
+	for _, column := range geolocation.Product().Columns() {
+		if !primary.Product().Has(column) {
+			columns = append(columns, column)
+		}
+	}
+	return &MergedRepository{
+		repository:  primary,
+		geolocation: geolocation,
+		product:     product,
+		columns:     columns,
+	}
+}
+
+// Product has the columns of both products.
+func (r *MergedRepository) Product() *Product {
+	return r.product
+}
+
+func (r *MergedRepository) Get(ctx context.Context, decimalIP int64) (_ *IP, err error) {
+	ctx, span := tracer.Start(ctx, "MergedRepository.Get")
+	defer func() { tracing.End(span, err) }()
+
+	ip, err := r.repository.Get(ctx, decimalIP)
+	if err != nil {
+		return nil, err
+	}
+	location, err := r.geolocation.Get(ctx, decimalIP)
+	if err == sql.ErrNoRows {
+		return ip, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.merge(ip, location), nil
+}
+
+func (r *MergedRepository) GetMany(ctx context.Context, decimalIPs []int64) (_ map[int64]*IP, err error) {
+	ctx, span := tracer.Start(ctx, "MergedRepository.GetMany")
+	defer func() { tracing.End(span, err) }()
+
+	ips, err := r.repository.GetMany(ctx, decimalIPs)
+	if err != nil || len(ips) == 0 {
+		return ips, err
+	}
+	found := make([]int64, 0, len(ips))
+	for decimalIP := range ips {
+		found = append(found, decimalIP)
+	}
+	locations, err := r.geolocation.GetMany(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+	for decimalIP, location := range locations {
+		ips[decimalIP] = r.merge(ips[decimalIP], location)
+	}
+	return ips, nil
+}
+
+// Near searches the product with coordinates, the geolocation one unless the
+// primary has them as well. Its ranges are returned as they are.
+func (r *MergedRepository) Near(ctx context.Context, circle Circle, limit int) (*Nearby, error) {
+	if r.repository.Product().Has(ColumnLatitude) {
+		return r.repository.Near(ctx, circle, limit)
+	}
+	return r.geolocation.Near(ctx, circle, limit)
+}
+
+// merge returns a copy of ip limited to the addresses location also covers,
+// both ranges hold the address looked up so they always overlap.
+func (r *MergedRepository) merge(ip *IP, location *IP) *IP {
+	merged := *ip
+	if location.From > merged.From {
+		merged.From = location.From
+	}
+	if location.To < merged.To {
+		merged.To = location.To
+	}
+	// The targets past ip_from and ip_to are the columns to copy.
+	sources := location.targets(r.columns)[2:]
+	for i, target := range merged.targets(r.columns)[2:] {
+		switch target := target.(type) {
+		case *string:
+			*target = *sources[i].(*string)
+		case *int:
+			*target = *sources[i].(*int)
+		case **int:
+			*target = *sources[i].(**int)
+		case **float64:
+			*target = *sources[i].(**float64)
+		}
+	}
+	return &merged
+}
@@ -0,0 +1,129 @@
+package ips
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newMergedRepository(ctrl *gomock.Controller) (*MergedRepository, *Mockrepository, *Mockrepository) {
+	primary, geolocation := NewMockrepository(ctrl), NewMockrepository(ctrl)
+	primary.EXPECT().Product().Return(MustParseProduct("PX7")).AnyTimes()
+	geolocation.EXPECT().Product().Return(MustParseProduct("DB11")).AnyTimes()
+	return NewMergedRepository(primary, geolocation), primary, geolocation
+}
+
+func TestMergedRepository_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	latitude, longitude := -34.61315, -58.37723
+	proxy := &IP{From: 150178520, To: 150178530, ProxyType: "PUB",
+		Country: Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"},
+		ISP:     "CTL LATAM", ASN: 3356}
+	location := func(from, to int64) *IP {
+		return &IP{From: from, To: to,
+			Country:  Country{Code: "AR", Name: "Argentina", Region: "Ciudad Autonoma de Buenos Aires", City: "Buenos Aires"},
+			Latitude: &latitude, Longitude: &longitude, ZipCode: "C1000", TimeZone: "-03:00"}
+	}
+	merged := func(from, to int64) *IP {
+		return &IP{From: from, To: to, ProxyType: "PUB",
+			Country: Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"},
+			ISP:     "CTL LATAM", ASN: 3356,
+			Latitude: &latitude, Longitude: &longitude, ZipCode: "C1000", TimeZone: "-03:00"}
+	}
+
+	tests := []struct {
+		name        string
+		location    *IP
+		locationErr error
+		want        *IP
+	}{
+		{name: "geolocation range holds the proxy range", location: location(150178304, 150178815),
+			want: merged(150178520, 150178530)},
+		{name: "geolocation range ends inside", location: location(150178304, 150178525),
+			want: merged(150178520, 150178525)},
+		{name: "geolocation range starts inside", location: location(150178522, 150178815),
+			want: merged(150178522, 150178530)},
+		{name: "geolocation range inside", location: location(150178522, 150178523),
+			want: merged(150178522, 150178523)},
+		{name: "not geolocated", locationErr: sql.ErrNoRows, want: proxy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository, primary, geolocation := newMergedRepository(ctrl)
+			primary.EXPECT().Get(gomock.Any(), int64(150178522)).Return(proxy, nil)
+			geolocation.EXPECT().Get(gomock.Any(), int64(150178522)).Return(tt.location, tt.locationErr)
+
+			ip, err := repository.Get(context.Background(), 150178522)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, ip)
+			assert.Equal(t, int64(150178520), proxy.From)
+		})
+	}
+}
+
+func TestMergedRepository_GetErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository, primary, geolocation := newMergedRepository(ctrl)
+	primary.EXPECT().Get(gomock.Any(), int64(16778497)).Return(nil, sql.ErrNoRows)
+
+	_, err := repository.Get(context.Background(), 16778497)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	primary.EXPECT().Get(gomock.Any(), int64(16778497)).Return(&IP{From: 16778496, To: 16778500}, nil)
+	geolocation.EXPECT().Get(gomock.Any(), int64(16778497)).Return(nil, sql.ErrConnDone)
+
+	_, err = repository.Get(context.Background(), 16778497)
+	assert.Equal(t, sql.ErrConnDone, err)
+}
+
+func TestMergedRepository_GetMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository, primary, geolocation := newMergedRepository(ctrl)
+	primary.EXPECT().GetMany(gomock.Any(), []int64{150178522, 16778497, 1}).
+		Return(map[int64]*IP{150178522: {From: 150178520, To: 150178530}, 16778497: {From: 16778496, To: 16778500}}, nil)
+	geolocation.EXPECT().GetMany(gomock.Any(), gomock.InAnyOrder([]int64{150178522, 16778497})).
+		Return(map[int64]*IP{150178522: {From: 150178304, To: 150178525, TimeZone: "-03:00"}}, nil)
+
+	ips, err := repository.GetMany(context.Background(), []int64{150178522, 16778497, 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]*IP{
+		150178522: {From: 150178520, To: 150178525, TimeZone: "-03:00"},
+		16778497:  {From: 16778496, To: 16778500},
+	}, ips)
+}
+
+func TestMergedRepository_Product(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository, _, _ := newMergedRepository(ctrl)
+
+	assert.Equal(t, "PX7+DB11", repository.Product().Name)
+	assert.Equal(t, "ip2location_px7", repository.Product().Table)
+	assert.NoError(t, repository.Product().Require(ColumnISP, ColumnTimeZone))
+}
+
+func TestMergedRepository_Near(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository, _, geolocation := newMergedRepository(ctrl)
+	circle := Circle{Latitude: -34.6, Longitude: -58.4, RadiusKm: 25}
+	expected := &Nearby{Ranges: []*IP{{From: 150178304, To: 150178815}}}
+	geolocation.EXPECT().Near(gomock.Any(), circle, 10).Return(expected, nil)
+
+	nearby, err := repository.Near(context.Background(), circle, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, nearby)
+}
@@ -0,0 +1,41 @@
+package ips
+
+import (
+	"math"
+)
+
+// earthRadiusKm is the radius the earthdistance extension uses, so both the
+// database and the file repositories agree on what falls in a circle.
+const earthRadiusKm = 6378.168
+
+// Circle is the area of a radius search.
+type Circle struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+}
+
+// Nearby is the result of a radius search, the ranges closest to the center
+// and the totals of every city in the circle.
+type Nearby struct {
+	Ranges []*IP
+	Cities []*CityTotal
+}
+
+// CityTotal counts the ranges located in a city and the addresses they hold.
+type CityTotal struct {
+	Country   Country
+	Ranges    int
+	Addresses int64
+}
+
+// distanceKm is the great-circle distance from the center of c to the given
+// coordinates.
+func (c Circle) distanceKm(latitude, longitude float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLatitude := toRadians(latitude - c.Latitude)
+	dLongitude := toRadians(longitude - c.Longitude)
+	a := math.Sin(dLatitude/2)*math.Sin(dLatitude/2) +
+		math.Cos(toRadians(c.Latitude))*math.Cos(toRadians(latitude))*math.Sin(dLongitude/2)*math.Sin(dLongitude/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
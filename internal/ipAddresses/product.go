@@ -0,0 +1,177 @@
+package ips
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Columns of the IP2Location tables besides ip_from and ip_to.
+const (
+	ColumnProxyType   = "proxy_type"
+	ColumnCountryCode = "country_code"
+	ColumnCountryName = "country_name"
+	ColumnRegion      = "region_name"
+	ColumnCity        = "city_name"
+	ColumnISP         = "isp"
+	ColumnDomain      = "domain"
+	ColumnUsage       = "usage_type"
+	ColumnASN         = "asn"
+	ColumnAS          = "as"
+	ColumnLastSeen    = "last_seen"
+	ColumnThreat      = "threat"
+	ColumnProvider    = "provider"
+	ColumnLatitude    = "latitude"
+	ColumnLongitude   = "longitude"
+	ColumnZipCode     = "zip_code"
+	ColumnTimeZone    = "time_zone"
+)
+
+// columnOrder is the order the columns are selected in.
+var columnOrder = []string{ColumnProxyType, ColumnCountryCode, ColumnCountryName, ColumnRegion, ColumnCity,
+	ColumnISP, ColumnDomain, ColumnUsage, ColumnASN, ColumnAS, ColumnLastSeen, ColumnThreat, ColumnProvider,
+	ColumnLatitude, ColumnLongitude, ColumnZipCode, ColumnTimeZone}
+
+// The groups are spelled out rather than appended to each other, so none of
+// them shares a backing array with another.
+var (
+	country  = []string{ColumnCountryCode, ColumnCountryName}
+	city     = []string{ColumnCountryCode, ColumnCountryName, ColumnRegion, ColumnCity}
+	location = []string{ColumnLatitude, ColumnLongitude}
+	proxy    = []string{ColumnProxyType, ColumnCountryCode, ColumnCountryName, ColumnRegion, ColumnCity}
+	px6      = []string{ColumnProxyType, ColumnCountryCode, ColumnCountryName, ColumnRegion, ColumnCity,
+		ColumnISP, ColumnDomain, ColumnUsage}
+	px9 = []string{ColumnProxyType, ColumnCountryCode, ColumnCountryName, ColumnRegion, ColumnCity,
+		ColumnISP, ColumnDomain, ColumnUsage, ColumnASN, ColumnAS, ColumnLastSeen, ColumnThreat}
+)
+
+// productColumns declares the columns of each edition as published by
+// IP2Location, DB for geolocation and PX (IP2Proxy) for proxies.
+var productColumns = map[string][][]string{
+	"DB1":  {country},
+	"DB2":  {country, {ColumnISP}},
+	"DB3":  {city},
+	"DB4":  {city, {ColumnISP}},
+	"DB5":  {city, location},
+	"DB6":  {city, location, {ColumnISP}},
+	"DB7":  {city, {ColumnISP, ColumnDomain}},
+	"DB8":  {city, location, {ColumnISP, ColumnDomain}},
+	"DB9":  {city, location, {ColumnZipCode}},
+	"DB10": {city, location, {ColumnZipCode, ColumnISP, ColumnDomain}},
+	"DB11": {city, location, {ColumnZipCode, ColumnTimeZone}},
+	"PX1":  {country},
+	"PX2":  {{ColumnProxyType}, country},
+	"PX3":  {proxy},
+	"PX4":  {proxy, {ColumnISP}},
+	"PX5":  {proxy, {ColumnISP, ColumnDomain}},
+	"PX6":  {px6},
+	"PX7":  {px6, {ColumnASN, ColumnAS}},
+	"PX8":  {px6, {ColumnASN, ColumnAS, ColumnLastSeen}},
+	"PX9":  {px9},
+	"PX10": {px9},
+	"PX11": {px9, {ColumnProvider}},
+}
+
+// Product is an IP2Location edition and the table holding it.
+type Product struct {
+	Name string
+	// Table defaults to ip2location_<name>, e.g. ip2location_px7.
+	Table   string
+	columns map[string]bool
+}
+
+// tableName matches the tables a product may be read from, they are written
+// into queries as is.
+var tableName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// ParseProduct accepts the edition names regardless of case, DB1 to DB11 and
+// PX1 to PX11.
+func ParseProduct(name string) (*Product, error) {
+	name = strings.ToUpper(name)
+	groups, ok := productColumns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown product %q, expected DB1 to DB11 or PX1 to PX11", name)
+	}
+	product := &Product{
+		Name:    name,
+		Table:   "ip2location_" + strings.ToLower(name),
+		columns: make(map[string]bool),
+	}
+	for _, group := range groups {
+		for _, column := range group {
+			product.columns[column] = true
+		}
+	}
+	return product, nil
+}
+
+// MustParseProduct is ParseProduct for names known to be valid.
+func MustParseProduct(name string) *Product {
+	product, err := ParseProduct(name)
+	if err != nil {
+		panic(err)
+	}
+	return product
+}
+
+// SetTable reads the product from table instead of the default one, failing
+// for names that aren't plain lowercase identifiers.
+func (p *Product) SetTable(table string) error {
+	if !tableName.MatchString(table) {
+		return fmt.Errorf("invalid table name %q, expected lowercase letters, digits and underscores", table)
+	}
+	p.Table = table
+	return nil
+}
+
+func (p *Product) Has(column string) bool {
+	return p.columns[column]
+}
+
+// Columns returns the columns of the product in select order.
+func (p *Product) Columns() []string {
+	columns := make([]string, 0, len(p.columns))
+	for _, column := range columnOrder {
+		if p.columns[column] {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// Merge returns a product named after both, e.g. PX7+DB11, with the columns
+// of either and the table of p.
+func (p *Product) Merge(other *Product) *Product {
+	merged := &Product{
+		Name:    p.Name + "+" + other.Name,
+		Table:   p.Table,
+		columns: make(map[string]bool),
+	}
+	for _, product := range []*Product{p, other} {
+		for column := range product.columns {
+			merged.columns[column] = true
+		}
+	}
+	return merged
+}
+
+// Require fails with a *ColumnError for the first column the product lacks.
+func (p *Product) Require(columns ...string) error {
+	for _, column := range columns {
+		if !p.Has(column) {
+			return &ColumnError{Product: p.Name, Column: column}
+		}
+	}
+	return nil
+}
+
+// ColumnError reports a query needing a column the served product lacks,
+// such as the ISP ranking on a DB3 table.
+type ColumnError struct {
+	Product string
+	Column  string
+}
+
+func (e *ColumnError) Error() string {
+	return fmt.Sprintf("product %s has no %s column", e.Product, e.Column)
+}
@@ -0,0 +1,60 @@
+package ips
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseProduct(t *testing.T) {
+	product, err := ParseProduct("db11")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "DB11", product.Name)
+	assert.Equal(t, "ip2location_db11", product.Table)
+	assert.Equal(t, []string{ColumnCountryCode, ColumnCountryName, ColumnRegion, ColumnCity, ColumnLatitude,
+		ColumnLongitude, ColumnZipCode, ColumnTimeZone}, product.Columns())
+
+	_, err = ParseProduct("PX12")
+	assert.EqualError(t, err, `unknown product "PX12", expected DB1 to DB11 or PX1 to PX11`)
+}
+
+func TestProduct_SetTable(t *testing.T) {
+	product := MustParseProduct("DB11")
+
+	assert.NoError(t, product.SetTable("geo_db11_2021"))
+	assert.Equal(t, "geo_db11_2021", product.Table)
+
+	for _, table := range []string{"", "DB11", "1db11", "public.db11", "db11; DROP TABLE api_keys", `"db11"`} {
+		assert.Error(t, product.SetTable(table), table)
+	}
+	assert.Equal(t, "geo_db11_2021", product.Table)
+}
+
+func TestProduct_Columns(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+	}{
+		{name: "PX1", columns: []string{ColumnCountryCode, ColumnCountryName}},
+		{name: "PX7", columns: []string{ColumnProxyType, ColumnCountryCode, ColumnCountryName, ColumnRegion,
+			ColumnCity, ColumnISP, ColumnDomain, ColumnUsage, ColumnASN, ColumnAS}},
+		{name: "PX11", columns: []string{ColumnProxyType, ColumnCountryCode, ColumnCountryName, ColumnRegion,
+			ColumnCity, ColumnISP, ColumnDomain, ColumnUsage, ColumnASN, ColumnAS, ColumnLastSeen, ColumnThreat,
+			ColumnProvider}},
+		{name: "DB2", columns: []string{ColumnCountryCode, ColumnCountryName, ColumnISP}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.columns, MustParseProduct(tt.name).Columns())
+		})
+	}
+}
+
+func TestProduct_Require(t *testing.T) {
+	product := MustParseProduct("DB5")
+
+	assert.NoError(t, product.Require(ColumnCity, ColumnLatitude))
+	err := product.Require(ColumnCity, ColumnISP, ColumnTimeZone)
+	assert.Equal(t, &ColumnError{Product: "DB5", Column: ColumnISP}, err)
+	assert.EqualError(t, err, "product DB5 has no isp column")
+}
@@ -3,42 +3,231 @@ package ips
 import (
 	"context"
 	"database/sql"
+	"github.com/lib/pq"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
 )
 
+// queries holds the statements of a product, built once from its columns.
+type queries struct {
+	get               string
+	getMany           string
+	list              string
+	listColumns       []string
+	quantityByCountry string
+	top10ISPByCountry string
+	populated         string
+	walk              string
+	nearRanges        string
+	nearCities        string
+	domainRanges      string
+	domainTotals      string
+	searchISPs        string
+	countries         string
+	country           string
+	regions           string
+	cities            string
+	cityTotal         string
+	cityRanges        string
+	sampleTotal       string
+	sample            string
+}
+
+func buildQueries(product *Product) queries {
+	columns := product.Columns()
+	selected := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column == ColumnAS {
+			column = `"as"`
+		}
+		selected = append(selected, column)
+	}
+	selection := strings.Join(selected, ", ")
+	listColumns := []string{ColumnCountryName}
+	if product.Has(ColumnCity) {
+		listColumns = append(listColumns, ColumnCity)
+	}
+	listSelection := strings.Join(listColumns, ", ")
+	table := product.Table
+	// Products without cities count none, "-" stands for no data.
+	regions, cities := "0", "0"
+	if product.Has(ColumnCity) {
+		regions = "count(DISTINCT NULLIF(region_name, '-'))"
+		cities = "count(DISTINCT (region_name, city_name)) FILTER (WHERE city_name <> '-')"
+	}
+	countrySummary := "SELECT country_code, country_name, count(*), sum(ip_to - ip_from + 1), " + regions + ", " +
+		cities + " FROM " + table + " WHERE country_code <> '-'"
+	// Filters of a sample, products lacking a column take no filter on it.
+	proxyTypeFilter, usageFilter := "$2 = ''", "$3 = ''"
+	if product.Has(ColumnProxyType) {
+		proxyTypeFilter = "($2 = '' OR proxy_type = $2)"
+	}
+	if product.Has(ColumnUsage) {
+		usageFilter = "($3 = '' OR usage_type = $3)"
+	}
+	sampleFilters := "($1 = '' OR country_code = $1 OR country_name = $1) AND " + proxyTypeFilter + " AND " +
+		usageFilter
+	// Within $3 meters of ($1, $2), the box lets the GiST index on
+	// ll_to_earth(latitude, longitude) narrow the rows before measuring.
+	distance := "earth_distance(ll_to_earth($1, $2), ll_to_earth(latitude, longitude))"
+	within := "earth_box(ll_to_earth($1, $2), $3) @> ll_to_earth(latitude, longitude) AND " + distance + " <= $3"
+
+	return queries{
+		get: "SELECT ip_from, ip_to, " + selection + " " +
+			"FROM (SELECT * FROM " + table + " WHERE ip_to >= $1 ORDER BY ip_to LIMIT 1) AS candidate " +
+			"WHERE ip_from <= $1",
+
+		getMany: "SELECT address, ip_from, ip_to, " + selection + " " +
+			"FROM unnest($1::bigint[]) AS address CROSS JOIN LATERAL " +
+			"(SELECT * FROM " + table + " WHERE ip_to >= address ORDER BY ip_to LIMIT 1) AS candidate " +
+			"WHERE ip_from <= address",
+
+		list: "SELECT ip_from, ip_to, " + listSelection + " " +
+			"FROM (SELECT ip_from, ip_to, " + listSelection + ", sum(ip_to - ip_from + 1) " +
+			"OVER (ORDER BY ip_to, ip_from) AS cumulativeIpSum " +
+			"FROM " + table + " WHERE country_name = $1) AS cumulativeSum " +
+			"WHERE cumulativeIpSum <= $2  " +
+			"GROUP BY ip_from, ip_to, " + listSelection,
+		listColumns: listColumns,
+
+		quantityByCountry: "SELECT COALESCE(SUM(ip_to - ip_from + 1),0) AS quantity FROM " + table +
+			" WHERE country_name = $1",
+
+		top10ISPByCountry: "SELECT isp, count(isp) + sum(ip_to - ip_from) as total FROM " + table + " " +
+			"WHERE country_name = $1 GROUP BY isp ORDER BY total DESC LIMIT 10",
+
+		populated: "SELECT EXISTS (SELECT 1 FROM " + table + ")",
+
+		walk: "SELECT ip_from, ip_to, " + selection + " " +
+			"FROM " + table + " ORDER BY ip_from",
+
+		nearRanges: "SELECT ip_from, ip_to, " + selection + " " +
+			"FROM " + table + " WHERE " + within + " ORDER BY " + distance + ", ip_from LIMIT $4",
+
+		domainRanges: "SELECT ip_from, ip_to, " + selection + " " +
+			"FROM " + table + " WHERE reverse('.' || domain) LIKE $1 ORDER BY ip_from LIMIT $2",
+
+		domainTotals: "SELECT country_code, country_name, isp, count(*), sum(ip_to - ip_from + 1) " +
+			"FROM " + table + " WHERE reverse('.' || domain) LIKE $1 GROUP BY country_code, country_name, isp",
+
+		searchISPs: "SELECT isp, word_similarity($1, isp), country_code, country_name, count(*), " +
+			"sum(ip_to - ip_from + 1) FROM " + table + " " +
+			"WHERE $1 <% isp AND ($2 = '' OR country_code = $2 OR country_name = $2) " +
+			"GROUP BY isp, country_code, country_name",
+
+		countries: countrySummary + " GROUP BY country_code, country_name ORDER BY country_name",
+
+		country: countrySummary + " AND country_code = $1 GROUP BY country_code, country_name",
+
+		regions: "SELECT country_code, country_name, region_name, count(*), sum(ip_to - ip_from + 1), " +
+			"count(DISTINCT city_name) FILTER (WHERE city_name <> '-') FROM " + table + " " +
+			"WHERE country_code = $1 AND region_name <> '-' " +
+			"GROUP BY country_code, country_name, region_name ORDER BY region_name",
+
+		cities: "SELECT country_code, country_name, region_name, city_name, count(*), sum(ip_to - ip_from + 1) " +
+			"FROM " + table + " WHERE country_code = $1 AND region_name = $2 AND city_name <> '-' " +
+			"GROUP BY country_code, country_name, region_name, city_name ORDER BY city_name",
+
+		cityTotal: "SELECT country_code, country_name, region_name, city_name, count(*), sum(ip_to - ip_from + 1) " +
+			"FROM " + table + " WHERE country_code = $1 AND region_name = $2 AND city_name = $3 " +
+			"GROUP BY country_code, country_name, region_name, city_name",
+
+		cityRanges: "SELECT ip_from, ip_to, " + selection + " " +
+			"FROM " + table + " WHERE country_code = $1 AND region_name = $2 AND city_name = $3 " +
+			"ORDER BY ip_from LIMIT $4",
+
+		sampleTotal: "SELECT COALESCE(sum(ip_to - ip_from + 1), 0) FROM " + table + " WHERE " + sampleFilters,
+
+		// Each range covers the offsets in [cumulative - size, cumulative), the
+		// address drawn is as far from ip_from as the offset from the start.
+		// The positions are merged with the ranges in a single sort: walking
+		// down from the end, the smallest cumulative seen so far is the one of
+		// the range covering each position.
+		sample: "WITH matching AS (SELECT *, sum(ip_to - ip_from + 1) OVER (ORDER BY ip_from) AS cumulative " +
+			"FROM " + table + " WHERE " + sampleFilters + "), " +
+			"merged AS (SELECT position, ordinal, min(cumulative) " +
+			"OVER (ORDER BY point DESC, ordinal IS NULL ROWS UNBOUNDED PRECEDING) AS covering " +
+			"FROM (SELECT position AS point, position, ordinal, NULL::numeric AS cumulative " +
+			"FROM unnest($4::bigint[]) WITH ORDINALITY AS drawn(position, ordinal) " +
+			"UNION ALL SELECT cumulative, NULL, NULL, cumulative FROM matching) AS points) " +
+			"SELECT position - cumulative + ip_to + 1, ip_from, ip_to, " + selection + " " +
+			"FROM merged JOIN matching ON cumulative = covering WHERE ordinal IS NOT NULL ORDER BY ordinal",
+
+		nearCities: "SELECT country_code, country_name, region_name, city_name, count(*) AS ranges, " +
+			"sum(ip_to - ip_from + 1) AS addresses FROM " + table + " WHERE " + within + " " +
+			"GROUP BY country_code, country_name, region_name, city_name ORDER BY addresses DESC, city_name",
+	}
+}
+
+var tracer = tracing.Tracer("github.com/mborroni/dreamlab-challenge/internal/ipAddresses")
+
 type DBRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	product *Product
+	queries queries
 }
 
-func NewDBRepository(db *sql.DB) *DBRepository {
+func NewDBRepository(db *sql.DB, product *Product) *DBRepository {
 	return &DBRepository{
-		db: db,
+		db:      db,
+		product: product,
+		queries: buildQueries(product),
 	}
 }
 
-func (r *DBRepository) Get(ctx context.Context, decimalIP int64) (*IP, error) {
-	ip := &IP{}
-	row := r.db.QueryRow("SELECT ip_from, ip_to, proxy_type, country_code, "+
-		"country_name, region_name, city_name, isp, domain, usage_type, asn, \"as\" "+
-		"FROM ip2location_px7 WHERE ip_from <= $1 AND ip_to >= $1", decimalIP)
-	err := row.Scan(&ip.From, &ip.To, &ip.ProxyType, &ip.Country.Code, &ip.Country.Name,
-		&ip.Country.Region, &ip.Country.City, &ip.ISP, &ip.Domain, &ip.Usage, &ip.ASN, &ip.AS)
+func (r *DBRepository) Product() *Product {
+	return r.product
+}
+
+func (r *DBRepository) Get(ctx context.Context, decimalIP int64) (ip *IP, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.Get", r.queries.get)
+	defer func() { tracing.End(span, err) }()
+
+	ip = &IP{}
+	row := r.db.QueryRowContext(ctx, r.queries.get, decimalIP)
+	err = row.Scan(ip.targets(r.product.Columns())...)
 	return ip, err
 }
 
-func (r *DBRepository) List(ctx context.Context, limit int, filters map[string]interface{}) ([]*IP, error) {
+// GetMany resolves several addresses in one round trip, the ones not in the
+// dataset are missing from the result.
+func (r *DBRepository) GetMany(ctx context.Context, decimalIPs []int64) (_ map[int64]*IP, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.GetMany", r.queries.getMany)
+	defer func() { tracing.End(span, err) }()
+
+	columns := r.product.Columns()
+	ips := make(map[int64]*IP)
+	rows, err := r.db.QueryContext(ctx, r.queries.getMany, pq.Array(decimalIPs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var address int64
+		ip := &IP{}
+		if err := rows.Scan(append([]interface{}{&address}, ip.targets(columns)...)...); err != nil {
+			return nil, err
+		}
+		ips[address] = ip
+	}
+	return ips, rows.Err()
+}
+
+func (r *DBRepository) List(ctx context.Context, limit int, filters map[string]interface{}) (_ []*IP, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.List", r.queries.list)
+	defer func() { tracing.End(span, err) }()
+
 	ips := make([]*IP, 0)
-	rows, err := r.db.Query("SELECT ip_from, ip_to, country_name, city_name "+
-		"FROM (SELECT ip_from, ip_to, country_name, city_name, sum(ip_to - ip_from + 1) "+
-		"OVER (ORDER BY ip_to, ip_from) AS cumulativeIpSum "+
-		"FROM ip2location_px7 WHERE country_name = $1) AS cumulativeSum "+
-		"WHERE cumulativeIpSum <= $2  "+
-		"GROUP BY ip_from, ip_to, country_name, city_name", filters["country"], limit)
+	rows, err := r.db.QueryContext(ctx, r.queries.list, filters["country"], limit)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		ip := &IP{}
-		if err := rows.Scan(&ip.From, &ip.To, &ip.Country.Name, &ip.Country.City); err != nil {
+		if err := rows.Scan(ip.targets(r.queries.listColumns)...); err != nil {
 			return nil, err
 		}
 		ips = append(ips, ip)
@@ -46,24 +235,32 @@ func (r *DBRepository) List(ctx context.Context, limit int, filters map[string]i
 	return ips, nil
 }
 
-func (r *DBRepository) GetIPQuantityByCountry(ctx context.Context, country string) (int, error) {
+func (r *DBRepository) GetIPQuantityByCountry(ctx context.Context, country string) (_ int, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.GetIPQuantityByCountry", r.queries.quantityByCountry)
+	defer func() { tracing.End(span, err) }()
+
 	var quantity int
-	row := r.db.QueryRow("SELECT COALESCE(SUM(ip_to - ip_from + 1),0) AS quantity FROM ip2location_px7"+
-		" WHERE country_name = $1", country)
-	err := row.Scan(&quantity)
+	row := r.db.QueryRowContext(ctx, r.queries.quantityByCountry, country)
+	err = row.Scan(&quantity)
 	if err != nil {
 		return 0, err
 	}
 	return quantity, nil
 }
 
-func (r *DBRepository) GetTop10ISPByCountry(ctx context.Context, country string) ([]string, error) {
+func (r *DBRepository) GetTop10ISPByCountry(ctx context.Context, country string) (_ []string, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.GetTop10ISPByCountry", r.queries.top10ISPByCountry)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.product.Require(ColumnISP); err != nil {
+		return nil, err
+	}
 	isps := make([]string, 0)
-	rows, err := r.db.Query("SELECT isp, count(isp) + sum(ip_to - ip_from) as total FROM ip2location_px7 "+
-		"WHERE country_name = $1 GROUP BY isp ORDER BY total DESC LIMIT 10", country)
+	rows, err := r.db.QueryContext(ctx, r.queries.top10ISPByCountry, country)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var isp string
 		var total int
@@ -74,3 +271,328 @@ func (r *DBRepository) GetTop10ISPByCountry(ctx context.Context, country string)
 	}
 	return isps, nil
 }
+
+// Near returns the limit ranges closest to the center of circle and the
+// totals of every city inside it, products without coordinates fail with a
+// *ColumnError.
+func (r *DBRepository) Near(ctx context.Context, circle Circle, limit int) (_ *Nearby, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.Near", r.queries.nearRanges)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.product.Require(ColumnLatitude, ColumnLongitude); err != nil {
+		return nil, err
+	}
+	radius := circle.RadiusKm * 1000
+	columns := r.product.Columns()
+	nearby := &Nearby{Ranges: make([]*IP, 0), Cities: make([]*CityTotal, 0)}
+	rows, err := r.db.QueryContext(ctx, r.queries.nearRanges, circle.Latitude, circle.Longitude, radius, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ip := &IP{}
+		if err := rows.Scan(ip.targets(columns)...); err != nil {
+			return nil, err
+		}
+		nearby.Ranges = append(nearby.Ranges, ip)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cities, err := r.db.QueryContext(ctx, r.queries.nearCities, circle.Latitude, circle.Longitude, radius)
+	if err != nil {
+		return nil, err
+	}
+	defer cities.Close()
+	for cities.Next() {
+		city := &CityTotal{}
+		if err := cities.Scan(&city.Country.Code, &city.Country.Name, &city.Country.Region, &city.Country.City,
+			&city.Ranges, &city.Addresses); err != nil {
+			return nil, err
+		}
+		nearby.Cities = append(nearby.Cities, city)
+	}
+	return nearby, cities.Err()
+}
+
+// GetByDomain returns the first limit ranges of domain and its subdomains in
+// ascending order and the totals of all of them. The reversed domain column
+// is indexed, which turns the suffix match into a prefix one.
+func (r *DBRepository) GetByDomain(ctx context.Context, domain string, limit int) (_ *Domain, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.GetByDomain", r.queries.domainRanges)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.product.Require(ColumnDomain, ColumnISP); err != nil {
+		return nil, err
+	}
+	pattern := domainPattern(domain)
+	columns := r.product.Columns()
+	result := &Domain{Name: domain, Ranges: make([]*IP, 0), Countries: make([]*CountryTotal, 0),
+		ISPs: make([]*ISPTotal, 0)}
+	rows, err := r.db.QueryContext(ctx, r.queries.domainRanges, pattern, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ip := &IP{}
+		if err := rows.Scan(ip.targets(columns)...); err != nil {
+			return nil, err
+		}
+		result.Ranges = append(result.Ranges, ip)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	totals := newDomainTotals(result)
+	groups, err := r.db.QueryContext(ctx, r.queries.domainTotals, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer groups.Close()
+	for groups.Next() {
+		var country Country
+		var isp string
+		var ranges int
+		var addresses int64
+		if err := groups.Scan(&country.Code, &country.Name, &isp, &ranges, &addresses); err != nil {
+			return nil, err
+		}
+		totals.add(country, isp, ranges, addresses)
+	}
+	if err := groups.Err(); err != nil {
+		return nil, err
+	}
+	return totals.finish(), nil
+}
+
+// SearchISPs ranks the ISPs whose name has a word like query, the match is
+// pg_trgm's word similarity over the trigram index of the isp column. An
+// empty country searches them all, otherwise it is either a code or a name.
+func (r *DBRepository) SearchISPs(ctx context.Context, query string, country string, limit int) (_ []*ISPMatch, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.SearchISPs", r.queries.searchISPs)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.product.Require(ColumnISP); err != nil {
+		return nil, err
+	}
+	matches := make(ispMatches)
+	rows, err := r.db.QueryContext(ctx, r.queries.searchISPs, query, country)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var isp string
+		var score float64
+		var country Country
+		var ranges int
+		var addresses int64
+		if err := rows.Scan(&isp, &score, &country.Code, &country.Name, &ranges, &addresses); err != nil {
+			return nil, err
+		}
+		matches.add(isp, score, country, ranges, addresses)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return matches.ranked(limit), nil
+}
+
+// Sample draws n distinct addresses at random from the ranges matching
+// filters, each one as a copy of its range narrowed down to it. The offsets
+// are drawn from the total of addresses and resolved against the running
+// sum of the range sizes.
+func (r *DBRepository) Sample(ctx context.Context, n int, seed int64, filters map[string]interface{}) (_ []*IP, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.Sample", r.queries.sample)
+	defer func() { tracing.End(span, err) }()
+
+	country := sampleFilter(filters, SampleCountry)
+	proxyType := sampleFilter(filters, SampleProxyType)
+	usage := sampleFilter(filters, SampleUsage)
+	var total int64
+	err = r.db.QueryRowContext(ctx, r.queries.sampleTotal, country, proxyType, usage).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]*IP, 0, n)
+	if total == 0 {
+		return ips, nil
+	}
+	columns := r.product.Columns()
+	rows, err := r.db.QueryContext(ctx, r.queries.sample, country, proxyType, usage,
+		pq.Array(draw(total, n, seed)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var address int64
+		ip := &IP{}
+		if err := rows.Scan(append([]interface{}{&address}, ip.targets(columns)...)...); err != nil {
+			return nil, err
+		}
+		ips = append(ips, sampled(ip, address))
+	}
+	return ips, rows.Err()
+}
+
+// ListCountries summarizes every country of the product by name.
+func (r *DBRepository) ListCountries(ctx context.Context) (_ []*CountrySummary, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.ListCountries", r.queries.countries)
+	defer func() { tracing.End(span, err) }()
+
+	countries := make([]*CountrySummary, 0)
+	rows, err := r.db.QueryContext(ctx, r.queries.countries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		country := &CountrySummary{}
+		if err := rows.Scan(country.targets()...); err != nil {
+			return nil, err
+		}
+		countries = append(countries, country)
+	}
+	return countries, rows.Err()
+}
+
+// GetCountry summarizes the country with the ISO code given, it fails with
+// sql.ErrNoRows when the product has no range in it.
+func (r *DBRepository) GetCountry(ctx context.Context, code string) (_ *CountrySummary, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.GetCountry", r.queries.country)
+	defer func() { tracing.End(span, err) }()
+
+	country := &CountrySummary{}
+	err = r.db.QueryRowContext(ctx, r.queries.country, code).Scan(country.targets()...)
+	return country, err
+}
+
+// ListRegions counts the ranges, addresses and cities of every region of the
+// country with the code given, by name. Ranges without a region are left out.
+func (r *DBRepository) ListRegions(ctx context.Context, code string) (_ []*RegionSummary, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.ListRegions", r.queries.regions)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.product.Require(ColumnRegion, ColumnCity); err != nil {
+		return nil, err
+	}
+	regions := make([]*RegionSummary, 0)
+	rows, err := r.db.QueryContext(ctx, r.queries.regions, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		region := &RegionSummary{}
+		if err := rows.Scan(region.targets()...); err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+	}
+	return regions, rows.Err()
+}
+
+// ListCities counts the ranges and addresses of every city of a region, by
+// name.
+func (r *DBRepository) ListCities(ctx context.Context, code string, region string) (_ []*CityTotal, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.ListCities", r.queries.cities)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.product.Require(ColumnRegion, ColumnCity); err != nil {
+		return nil, err
+	}
+	cities := make([]*CityTotal, 0)
+	rows, err := r.db.QueryContext(ctx, r.queries.cities, code, region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		city := &CityTotal{}
+		if err := rows.Scan(city.targets()...); err != nil {
+			return nil, err
+		}
+		cities = append(cities, city)
+	}
+	return cities, rows.Err()
+}
+
+// GetCity returns the totals of a city and up to limit of its ranges, it
+// fails with sql.ErrNoRows when the city has none.
+func (r *DBRepository) GetCity(ctx context.Context, code string, region string, city string, limit int) (_ *City, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.GetCity", r.queries.cityRanges)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.product.Require(ColumnRegion, ColumnCity); err != nil {
+		return nil, err
+	}
+	result := &City{Ranges: make([]*IP, 0)}
+	err = r.db.QueryRowContext(ctx, r.queries.cityTotal, code, region, city).Scan(result.Total.targets()...)
+	if err != nil {
+		return nil, err
+	}
+	columns := r.product.Columns()
+	rows, err := r.db.QueryContext(ctx, r.queries.cityRanges, code, region, city, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ip := &IP{}
+		if err := rows.Scan(ip.targets(columns)...); err != nil {
+			return nil, err
+		}
+		result.Ranges = append(result.Ranges, ip)
+	}
+	return result, rows.Err()
+}
+
+// Populated reports whether the product table holds any range, an empty
+// table means the dataset has not been imported yet.
+func (r *DBRepository) Populated(ctx context.Context) (_ bool, err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.Populated", r.queries.populated)
+	defer func() { tracing.End(span, err) }()
+
+	var populated bool
+	err = r.db.QueryRowContext(ctx, r.queries.populated).Scan(&populated)
+	return populated, err
+}
+
+// Walk calls fn with every range of the product table in ascending order,
+// streaming the rows instead of loading the table. It stops at the first
+// error returned by fn.
+func (r *DBRepository) Walk(ctx context.Context, fn func(*IP) error) (err error) {
+	ctx, span := startQuerySpan(ctx, "DBRepository.Walk", r.queries.walk)
+	defer func() { tracing.End(span, err) }()
+
+	columns := r.product.Columns()
+	rows, err := r.db.QueryContext(ctx, r.queries.walk)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ip := &IP{}
+		if err := rows.Scan(ip.targets(columns)...); err != nil {
+			return err
+		}
+		if err := fn(ip); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func startQuerySpan(ctx context.Context, name string, statement string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatement(statement),
+		))
+}
@@ -3,6 +3,7 @@ package ips
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"reflect"
 	"regexp"
@@ -27,7 +28,7 @@ func getDB(t *testing.T) *sqlMock {
 
 func TestRepository_List(t *testing.T) {
 	db := getDB(t)
-	r := NewDBRepository(db.db)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
 
 	type fields struct {
 		limit   int
@@ -130,7 +131,7 @@ func TestRepository_List(t *testing.T) {
 
 func TestRepository_Get(t *testing.T) {
 	db := getDB(t)
-	r := NewDBRepository(db.db)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
 	type fields struct {
 		decimalIP int64
 	}
@@ -152,7 +153,8 @@ func TestRepository_Get(t *testing.T) {
 			expectations: func(fields fields) {
 				db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, proxy_type, " +
 					"country_code, country_name, region_name, city_name, isp, domain, usage_type, " +
-					"asn, \"as\" FROM ip2location_px7 WHERE ip_from <= $1 AND ip_to >= $1")).
+					"asn, \"as\" FROM (SELECT * FROM ip2location_px7 WHERE ip_to >= $1 ORDER BY ip_to LIMIT 1) " +
+					"AS candidate WHERE ip_from <= $1")).
 					WithArgs(fields.decimalIP).
 					WillReturnRows(sqlmock.NewRows(
 						[]string{"ip_from", "ip_to", "proxy_type", "country_code",
@@ -171,7 +173,8 @@ func TestRepository_Get(t *testing.T) {
 			expectations: func(fields fields) {
 				db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, proxy_type, " +
 					"country_code, country_name, region_name, city_name, isp, domain, usage_type, " +
-					"asn, \"as\" FROM ip2location_px7 WHERE ip_from <= $1 AND ip_to >= $1")).
+					"asn, \"as\" FROM (SELECT * FROM ip2location_px7 WHERE ip_to >= $1 ORDER BY ip_to LIMIT 1) " +
+					"AS candidate WHERE ip_from <= $1")).
 					WithArgs(fields.decimalIP).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -187,7 +190,8 @@ func TestRepository_Get(t *testing.T) {
 			expectations: func(fields fields) {
 				db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, proxy_type, country_code, " +
 					"country_name, region_name, city_name, isp, domain, usage_type, asn, \"as\" " +
-					"FROM ip2location_px7 WHERE ip_from <= $1 AND ip_to >= $1")).
+					"FROM (SELECT * FROM ip2location_px7 WHERE ip_to >= $1 ORDER BY ip_to LIMIT 1) AS candidate " +
+					"WHERE ip_from <= $1")).
 					WithArgs(fields.decimalIP).
 					WillReturnRows(sqlmock.NewRows(
 						[]string{"ip_from", "ip_to", "proxy_type",
@@ -242,9 +246,473 @@ func TestRepository_Get(t *testing.T) {
 	}
 }
 
+func TestRepository_GetGeolocation(t *testing.T) {
+	db := getDB(t)
+	product := MustParseProduct("DB11")
+	product.Table = "geolocation"
+	r := NewDBRepository(db.db, product)
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, country_code, country_name, region_name, " +
+		"city_name, latitude, longitude, zip_code, time_zone " +
+		"FROM (SELECT * FROM geolocation WHERE ip_to >= $1 ORDER BY ip_to LIMIT 1) AS candidate " +
+		"WHERE ip_from <= $1")).
+		WithArgs(int64(16778497)).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"ip_from", "ip_to", "country_code", "country_name", "region_name", "city_name",
+				"latitude", "longitude", "zip_code", "time_zone"}).
+			AddRow(16778496, 16779007, "AU", "Australia", "Victoria", "Melbourne",
+				-37.814, 144.96332, "3000", "+10:00"))
+
+	got, err := r.Get(context.Background(), 16778497)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+	latitude, longitude := -37.814, 144.96332
+	want := &IP{
+		From: 16778496,
+		To:   16779007,
+		Country: Country{
+			Code:   "AU",
+			Name:   "Australia",
+			Region: "Victoria",
+			City:   "Melbourne",
+		},
+		Latitude:  &latitude,
+		Longitude: &longitude,
+		ZipCode:   "3000",
+		TimeZone:  "+10:00",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() got = %v, want = %v", got, want)
+	}
+}
+
+func TestRepository_Near(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("DB5"))
+	circle := Circle{Latitude: -34.6, Longitude: -58.4, RadiusKm: 25}
+	within := "WHERE earth_box(ll_to_earth($1, $2), $3) @> ll_to_earth(latitude, longitude) AND " +
+		"earth_distance(ll_to_earth($1, $2), ll_to_earth(latitude, longitude)) <= $3"
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, country_code, country_name, region_name, city_name, "+
+		"latitude, longitude FROM ip2location_db5 "+within+" "+
+		"ORDER BY earth_distance(ll_to_earth($1, $2), ll_to_earth(latitude, longitude)), ip_from LIMIT $4")).
+		WithArgs(-34.6, -58.4, 25000.0, 10).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"ip_from", "ip_to", "country_code", "country_name", "region_name", "city_name", "latitude",
+				"longitude"}).
+			AddRow(150178520, 150178530, "AR", "Argentina", "Buenos Aires", "Buenos Aires", -34.61315, -58.37723))
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT country_code, country_name, region_name, city_name, "+
+		"count(*) AS ranges, sum(ip_to - ip_from + 1) AS addresses FROM ip2location_db5 "+within+" "+
+		"GROUP BY country_code, country_name, region_name, city_name ORDER BY addresses DESC, city_name")).
+		WithArgs(-34.6, -58.4, 25000.0).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"country_code", "country_name", "region_name", "city_name", "ranges", "addresses"}).
+			AddRow("AR", "Argentina", "Buenos Aires", "Buenos Aires", 42, 130000).
+			AddRow("AR", "Argentina", "Buenos Aires", "Quilmes", 3, 768))
+
+	got, err := r.Near(context.Background(), circle, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+	latitude, longitude := -34.61315, -58.37723
+	want := &Nearby{
+		Ranges: []*IP{{
+			From:      150178520,
+			To:        150178530,
+			Country:   Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"},
+			Latitude:  &latitude,
+			Longitude: &longitude,
+		}},
+		Cities: []*CityTotal{
+			{Country: Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"},
+				Ranges: 42, Addresses: 130000},
+			{Country: Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Quilmes"},
+				Ranges: 3, Addresses: 768},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Near() got = %v, want = %v", got, want)
+	}
+
+	_, err = NewDBRepository(db.db, MustParseProduct("PX7")).Near(context.Background(), circle, 10)
+	if !errors.As(err, new(*ColumnError)) {
+		t.Errorf("Near() error = %v, want a *ColumnError", err)
+	}
+}
+
+func TestRepository_GetByDomain(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, proxy_type, country_code, country_name, "+
+		"region_name, city_name, isp, domain, usage_type, asn, \"as\" FROM ip2location_px7 "+
+		"WHERE reverse('.' || domain) LIKE $1 ORDER BY ip_from LIMIT $2")).
+		WithArgs("moc.knilyrutnec.%", 1).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"ip_from", "ip_to", "proxy_type", "country_code", "country_name", "region_name",
+				"city_name", "isp", "domain", "usage_type", "asn", "as"}).
+			AddRow(150178520, 150178530, "PUB", "AR", "Argentina", "Buenos Aires", "Buenos Aires",
+				"CTL LATAM", "centurylink.com", "ISP", 3356, "Level 3 Parent LLC"))
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT country_code, country_name, isp, count(*), " +
+		"sum(ip_to - ip_from + 1) FROM ip2location_px7 WHERE reverse('.' || domain) LIKE $1 " +
+		"GROUP BY country_code, country_name, isp")).
+		WithArgs("moc.knilyrutnec.%").
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "isp", "count", "sum"}).
+			AddRow("AR", "Argentina", "CTL LATAM", 2, 20).
+			AddRow("US", "United States", "CenturyLink", 5, 1000).
+			AddRow("US", "United States", "CTL LATAM", 1, 4))
+
+	got, err := r.GetByDomain(context.Background(), "centurylink.com", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+	want := &Domain{
+		Name: "centurylink.com",
+		Ranges: []*IP{{
+			From:      150178520,
+			To:        150178530,
+			ProxyType: "PUB",
+			Country:   Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"},
+			ISP:       "CTL LATAM",
+			Domain:    "centurylink.com",
+			Usage:     "ISP",
+			ASN:       3356,
+			AS:        "Level 3 Parent LLC",
+		}},
+		Addresses: 1024,
+		Countries: []*CountryTotal{
+			{Country: Country{Code: "US", Name: "United States"}, Ranges: 6, Addresses: 1004},
+			{Country: Country{Code: "AR", Name: "Argentina"}, Ranges: 2, Addresses: 20},
+		},
+		ISPs: []*ISPTotal{
+			{ISP: "CenturyLink", Ranges: 5, Addresses: 1000},
+			{ISP: "CTL LATAM", Ranges: 3, Addresses: 24},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetByDomain() got = %v, want = %v", got, want)
+	}
+
+	_, err = NewDBRepository(db.db, MustParseProduct("DB11")).GetByDomain(context.Background(), "centurylink.com", 1)
+	if !errors.As(err, new(*ColumnError)) {
+		t.Errorf("GetByDomain() error = %v, want a *ColumnError", err)
+	}
+}
+
+func TestRepository_SearchISPs(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT isp, word_similarity($1, isp), country_code, country_name, "+
+		"count(*), sum(ip_to - ip_from + 1) FROM ip2location_px7 "+
+		"WHERE $1 <% isp AND ($2 = '' OR country_code = $2 OR country_name = $2) "+
+		"GROUP BY isp, country_code, country_name")).
+		WithArgs("swisscom", "").
+		WillReturnRows(sqlmock.NewRows([]string{"isp", "word_similarity", "country_code", "country_name",
+			"count", "sum"}).
+			AddRow("Swisscom AG", 1.0, "CH", "Switzerland", 40, 1200).
+			AddRow("Swisscom (Schweiz) AG", 1.0, "CH", "Switzerland", 2, 8).
+			AddRow("Swisscom AG", 1.0, "DE", "Germany", 1, 1).
+			AddRow("Swiscom Ltd", 0.7, "GB", "United Kingdom", 9, 5000))
+
+	got, err := r.SearchISPs(context.Background(), "swisscom", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+	want := []*ISPMatch{
+		{ISP: "Swisscom AG", Score: 1, Ranges: 41, Addresses: 1201, Countries: []*CountryTotal{
+			{Country: Country{Code: "CH", Name: "Switzerland"}, Ranges: 40, Addresses: 1200},
+			{Country: Country{Code: "DE", Name: "Germany"}, Ranges: 1, Addresses: 1},
+		}},
+		{ISP: "Swisscom (Schweiz) AG", Score: 1, Ranges: 2, Addresses: 8, Countries: []*CountryTotal{
+			{Country: Country{Code: "CH", Name: "Switzerland"}, Ranges: 2, Addresses: 8},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchISPs() got = %v, want = %v", got, want)
+	}
+
+	_, err = NewDBRepository(db.db, MustParseProduct("DB3")).SearchISPs(context.Background(), "swisscom", "", 2)
+	if !errors.As(err, new(*ColumnError)) {
+		t.Errorf("SearchISPs() error = %v, want a *ColumnError", err)
+	}
+}
+
+func TestRepository_ListCountries(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT country_code, country_name, count(*), sum(ip_to - ip_from + 1), " +
+		"count(DISTINCT NULLIF(region_name, '-')), " +
+		"count(DISTINCT (region_name, city_name)) FILTER (WHERE city_name <> '-') " +
+		"FROM ip2location_px7 WHERE country_code <> '-' GROUP BY country_code, country_name ORDER BY country_name")).
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "count", "sum", "regions", "cities"}).
+			AddRow("AR", "Argentina", 1200, 4000, 24, 310).
+			AddRow("CH", "Switzerland", 800, 9000, 26, 140))
+
+	got, err := r.ListCountries(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+	want := []*CountrySummary{
+		{Country: Country{Code: "AR", Name: "Argentina"}, Ranges: 1200, Addresses: 4000, Regions: 24, Cities: 310},
+		{Country: Country{Code: "CH", Name: "Switzerland"}, Ranges: 800, Addresses: 9000, Regions: 26, Cities: 140},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListCountries() got = %v, want = %v", got, want)
+	}
+}
+
+func TestRepository_GetCountry(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX2"))
+	query := regexp.QuoteMeta("SELECT country_code, country_name, count(*), sum(ip_to - ip_from + 1), 0, 0 " +
+		"FROM ip2location_px2 WHERE country_code <> '-' AND country_code = $1 GROUP BY country_code, country_name")
+
+	db.mock.ExpectQuery(query).
+		WithArgs("AR").
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "count", "sum", "regions", "cities"}).
+			AddRow("AR", "Argentina", 1200, 4000, 0, 0))
+	db.mock.ExpectQuery(query).
+		WithArgs("ZZ").
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "count", "sum", "regions", "cities"}))
+
+	got, err := r.GetCountry(context.Background(), "AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CountrySummary{Country: Country{Code: "AR", Name: "Argentina"}, Ranges: 1200, Addresses: 4000}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCountry() got = %v, want = %v", got, want)
+	}
+	if _, err := r.GetCountry(context.Background(), "ZZ"); err != sql.ErrNoRows {
+		t.Errorf("GetCountry() error = %v, want = %v", err, sql.ErrNoRows)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestRepository_ListRegions(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT country_code, country_name, region_name, count(*), " +
+		"sum(ip_to - ip_from + 1), count(DISTINCT city_name) FILTER (WHERE city_name <> '-') " +
+		"FROM ip2location_px7 WHERE country_code = $1 AND region_name <> '-' " +
+		"GROUP BY country_code, country_name, region_name ORDER BY region_name")).
+		WithArgs("AR").
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "region_name", "count", "sum",
+			"cities"}).
+			AddRow("AR", "Argentina", "Buenos Aires", 300, 2000, 40).
+			AddRow("AR", "Argentina", "Cordoba", 80, 500, 12))
+
+	got, err := r.ListRegions(context.Background(), "AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+	want := []*RegionSummary{
+		{Country: Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires"}, Ranges: 300, Addresses: 2000, Cities: 40},
+		{Country: Country{Code: "AR", Name: "Argentina", Region: "Cordoba"}, Ranges: 80, Addresses: 500, Cities: 12},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListRegions() got = %v, want = %v", got, want)
+	}
+
+	_, err = NewDBRepository(db.db, MustParseProduct("PX2")).ListRegions(context.Background(), "AR")
+	if !errors.As(err, new(*ColumnError)) {
+		t.Errorf("ListRegions() error = %v, want a *ColumnError", err)
+	}
+}
+
+func TestRepository_ListCities(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT country_code, country_name, region_name, city_name, count(*), "+
+		"sum(ip_to - ip_from + 1) FROM ip2location_px7 "+
+		"WHERE country_code = $1 AND region_name = $2 AND city_name <> '-' "+
+		"GROUP BY country_code, country_name, region_name, city_name ORDER BY city_name")).
+		WithArgs("AR", "Cordoba").
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "region_name", "city_name",
+			"count", "sum"}).
+			AddRow("AR", "Argentina", "Cordoba", "Cordoba", 70, 450).
+			AddRow("AR", "Argentina", "Cordoba", "Villa Maria", 10, 50))
+
+	got, err := r.ListCities(context.Background(), "AR", "Cordoba")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+	want := []*CityTotal{
+		{Country: Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"}, Ranges: 70, Addresses: 450},
+		{Country: Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Villa Maria"}, Ranges: 10, Addresses: 50},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListCities() got = %v, want = %v", got, want)
+	}
+}
+
+func TestRepository_GetCity(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+	total := regexp.QuoteMeta("SELECT country_code, country_name, region_name, city_name, count(*), " +
+		"sum(ip_to - ip_from + 1) FROM ip2location_px7 " +
+		"WHERE country_code = $1 AND region_name = $2 AND city_name = $3 " +
+		"GROUP BY country_code, country_name, region_name, city_name")
+
+	db.mock.ExpectQuery(total).
+		WithArgs("AR", "Cordoba", "Villa Maria").
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "region_name", "city_name",
+			"count", "sum"}).
+			AddRow("AR", "Argentina", "Cordoba", "Villa Maria", 2, 20))
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, proxy_type, country_code, country_name, "+
+		"region_name, city_name, isp, domain, usage_type, asn, \"as\" FROM ip2location_px7 "+
+		"WHERE country_code = $1 AND region_name = $2 AND city_name = $3 ORDER BY ip_from LIMIT $4")).
+		WithArgs("AR", "Cordoba", "Villa Maria", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type", "country_code",
+			"country_name", "region_name", "city_name", "isp", "domain", "usage_type", "asn", "as"}).
+			AddRow(3000, 3009, "PUB", "AR", "Argentina", "Cordoba", "Villa Maria", "Telecom Argentina S.A.",
+				"telecom.com.ar", "ISP", 7303, "Telecom Argentina S.A."))
+	db.mock.ExpectQuery(total).
+		WithArgs("AR", "Cordoba", "Atlantis").
+		WillReturnRows(sqlmock.NewRows([]string{"country_code", "country_name", "region_name", "city_name",
+			"count", "sum"}))
+
+	got, err := r.GetCity(context.Background(), "AR", "Cordoba", "Villa Maria", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location := Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Villa Maria"}
+	want := &City{
+		Total: CityTotal{Country: location, Ranges: 2, Addresses: 20},
+		Ranges: []*IP{{From: 3000, To: 3009, ProxyType: "PUB", Country: location, ISP: "Telecom Argentina S.A.",
+			Domain: "telecom.com.ar", Usage: "ISP", ASN: 7303, AS: "Telecom Argentina S.A."}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCity() got = %v, want = %v", got, want)
+	}
+	if _, err := r.GetCity(context.Background(), "AR", "Cordoba", "Atlantis", 1); err != sql.ErrNoRows {
+		t.Errorf("GetCity() error = %v, want = %v", err, sql.ErrNoRows)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestRepository_Sample(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+	filters := "($1 = '' OR country_code = $1 OR country_name = $1) AND ($2 = '' OR proxy_type = $2) AND " +
+		"($3 = '' OR usage_type = $3)"
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(sum(ip_to - ip_from + 1), 0) FROM ip2location_px7 "+
+		"WHERE "+filters)).
+		WithArgs("CH", "", "DCH").
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(15))
+	db.mock.ExpectQuery(regexp.QuoteMeta("WITH matching AS (SELECT *, sum(ip_to - ip_from + 1) "+
+		"OVER (ORDER BY ip_from) AS cumulative FROM ip2location_px7 WHERE "+filters+"), "+
+		"merged AS (SELECT position, ordinal, min(cumulative) "+
+		"OVER (ORDER BY point DESC, ordinal IS NULL ROWS UNBOUNDED PRECEDING) AS covering "+
+		"FROM (SELECT position AS point, position, ordinal, NULL::numeric AS cumulative "+
+		"FROM unnest($4::bigint[]) WITH ORDINALITY AS drawn(position, ordinal) "+
+		"UNION ALL SELECT cumulative, NULL, NULL, cumulative FROM matching) AS points) "+
+		"SELECT position - cumulative + ip_to + 1, ip_from, ip_to, proxy_type, "+
+		"country_code, country_name, region_name, city_name, isp, domain, usage_type, asn, \"as\" "+
+		"FROM merged JOIN matching ON cumulative = covering WHERE ordinal IS NOT NULL ORDER BY ordinal")).
+		WithArgs("CH", "", "DCH", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"address", "ip_from", "ip_to", "proxy_type", "country_code",
+			"country_name", "region_name", "city_name", "isp", "domain", "usage_type", "asn", "as"}).
+			AddRow(12, 10, 19, "DCH", "CH", "Switzerland", "Zurich", "Zurich", "Swisscom AG", "swisscom.ch",
+				"DCH", 3303, "Swisscom AG"))
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(sum(ip_to - ip_from + 1), 0) FROM ip2location_px7 "+
+		"WHERE "+filters)).
+		WithArgs("CL", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(0))
+
+	got, err := r.Sample(context.Background(), 1, 42, map[string]interface{}{SampleCountry: "CH", SampleUsage: "DCH"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*IP{{From: 12, To: 12, ProxyType: "DCH", ISP: "Swisscom AG", Domain: "swisscom.ch", Usage: "DCH",
+		ASN: 3303, AS: "Swisscom AG",
+		Country: Country{Code: "CH", Name: "Switzerland", Region: "Zurich", City: "Zurich"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sample() got = %v, want = %v", got, want)
+	}
+	got, err = r.Sample(context.Background(), 1, 42, map[string]interface{}{SampleCountry: "CL"})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Sample() got = %v, %v, want no addresses", got, err)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestBuildQueries_SampleWithoutColumns(t *testing.T) {
+	got := buildQueries(MustParseProduct("DB1")).sampleTotal
+	want := "SELECT COALESCE(sum(ip_to - ip_from + 1), 0) FROM ip2location_db1 " +
+		"WHERE ($1 = '' OR country_code = $1 OR country_name = $1) AND $2 = '' AND $3 = ''"
+	if got != want {
+		t.Errorf("sampleTotal got = %q, want = %q", got, want)
+	}
+}
+
+func TestRepository_GetMany(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT address, ip_from, ip_to, proxy_type, country_code, " +
+		"country_name, region_name, city_name, isp, domain, usage_type, asn, \"as\" " +
+		"FROM unnest($1::bigint[]) AS address CROSS JOIN LATERAL " +
+		"(SELECT * FROM ip2location_px7 WHERE ip_to >= address ORDER BY ip_to LIMIT 1) AS candidate " +
+		"WHERE ip_from <= address")).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"address", "ip_from", "ip_to", "proxy_type", "country_code",
+				"country_name", "region_name", "city_name", "isp", "domain",
+				"usage_type", "asn", "as"}).
+			AddRow(150178522, 150178520, 150178530, "PUB", "AR", "Argentina",
+				"Ciudad Autonoma de Buenos Aires", "Buenos Aires", "CTL LATAM", "centurylink.com",
+				"ISP", 3356, "Level 3 Parent LLC"))
+
+	ips, err := r.GetMany(context.Background(), []int64{150178522, 16778497})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || ips[150178522].Country.City != "Buenos Aires" {
+		t.Errorf("GetMany() = %v", ips)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestRepository_GetIPQuantityByCountry(t *testing.T) {
 	db := getDB(t)
-	r := NewDBRepository(db.db)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
 	type fields struct {
 		country string
 	}
@@ -330,7 +798,7 @@ func TestRepository_GetIPQuantityByCountry(t *testing.T) {
 
 func TestRepository_GetTop10ISPByCountry(t *testing.T) {
 	db := getDB(t)
-	r := NewDBRepository(db.db)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
 	type fields struct {
 		country string
 		limit   int
@@ -408,3 +876,94 @@ func TestRepository_GetTop10ISPByCountry(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_Populated(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+
+	type want struct {
+		result bool
+		err    error
+	}
+	tests := []struct {
+		name         string
+		expectations func()
+		want         want
+	}{
+		{name: "populated",
+			expectations: func() {
+				db.mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM ip2location_px7)")).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want: want{result: true},
+		},
+		{name: "empty",
+			expectations: func() {
+				db.mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM ip2location_px7)")).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want: want{result: false},
+		},
+		{name: "error",
+			expectations: func() {
+				db.mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM ip2location_px7)")).
+					WillReturnError(sql.ErrConnDone)
+			},
+			want: want{result: false, err: sql.ErrConnDone},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expectations()
+			got, err := r.Populated(context.Background())
+
+			if err := db.mock.ExpectationsWereMet(); err != nil {
+				t.Error(err.Error())
+			}
+			if err != tt.want.err {
+				t.Errorf("Populated() error = %v, wantErr %v", err, tt.want.err)
+				return
+			}
+			if got != tt.want.result {
+				t.Errorf("Populated() got = %v, want = %v", got, tt.want.result)
+			}
+		})
+	}
+}
+
+func TestRepository_Walk(t *testing.T) {
+	db := getDB(t)
+	r := NewDBRepository(db.db, MustParseProduct("PX7"))
+	columns := []string{"ip_from", "ip_to", "proxy_type", "country_code", "country_name", "region_name",
+		"city_name", "isp", "domain", "usage_type", "asn", "as"}
+	stop := errors.New("stop")
+
+	db.mock.ExpectQuery(regexp.QuoteMeta("SELECT ip_from, ip_to, proxy_type, country_code, " +
+		"country_name, region_name, city_name, isp, domain, usage_type, asn, \"as\" " +
+		"FROM ip2location_px7 ORDER BY ip_from")).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(16777216, 16777471, "DCH", "AU", "Australia", "Queensland", "Brisbane",
+				"APNIC", "apnic.net", "CDN", 13335, "Cloudflare").
+			AddRow(150178520, 150178530, "PUB", "AR", "Argentina", "Ciudad Autonoma de Buenos Aires",
+				"Buenos Aires", "CTL LATAM", "centurylink.com", "ISP", 3356, "Level 3 Parent LLC").
+			AddRow(167772160, 184549375, "-", "-", "-", "-", "-", "-", "-", "-", 0, "-"))
+
+	var walked []int64
+	err := r.Walk(context.Background(), func(ip *IP) error {
+		walked = append(walked, ip.From)
+		if ip.Country.Code == "AR" {
+			return stop
+		}
+		return nil
+	})
+
+	if err != stop {
+		t.Errorf("Walk() error = %v, want %v", err, stop)
+	}
+	if len(walked) != 2 || walked[0] != 16777216 || walked[1] != 150178520 {
+		t.Errorf("Walk() visited %v", walked)
+	}
+	if err := db.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,71 @@
+package ips
+
+import (
+	"math/rand"
+)
+
+// Keys of the filters a sample is drawn with, empty values match every range.
+// The country is either a code or a name.
+const (
+	SampleCountry   = "country"
+	SampleProxyType = "proxy_type"
+	SampleUsage     = "usage_type"
+)
+
+// sampleFilter reads the filter under key, missing filters are empty.
+func sampleFilter(filters map[string]interface{}, key string) string {
+	value, _ := filters[key].(string)
+	return value
+}
+
+// matchesSample applies the filters of a sample to a single range.
+func matchesSample(ip *IP, filters map[string]interface{}) bool {
+	country := sampleFilter(filters, SampleCountry)
+	if country != "" && ip.Country.Code != country && ip.Country.Name != country {
+		return false
+	}
+	if proxyType := sampleFilter(filters, SampleProxyType); proxyType != "" && ip.ProxyType != proxyType {
+		return false
+	}
+	if usage := sampleFilter(filters, SampleUsage); usage != "" && ip.Usage != usage {
+		return false
+	}
+	return true
+}
+
+// draw picks n distinct offsets below total in random order, every one of
+// them when there are no more than n. Offsets index the addresses of the
+// matching ranges one after the other, so each address is as likely as any
+// other regardless of the size of its range. The same seed draws the same
+// offsets.
+func draw(total int64, n int, seed int64) []int64 {
+	random := rand.New(rand.NewSource(seed))
+	if total <= int64(n) {
+		offsets := make([]int64, total)
+		for i := range offsets {
+			offsets[i] = int64(i)
+		}
+		random.Shuffle(len(offsets), func(i, j int) { offsets[i], offsets[j] = offsets[j], offsets[i] })
+		return offsets
+	}
+	drawn := make(map[int64]bool, n)
+	offsets := make([]int64, 0, n)
+	for len(offsets) < n {
+		offset := random.Int63n(total)
+		if drawn[offset] {
+			continue
+		}
+		drawn[offset] = true
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
+
+// sampled copies the range holding a drawn address narrowed down to it, as
+// List splits ranges.
+func sampled(ip *IP, address int64) *IP {
+	output := *ip
+	output.From = address
+	output.To = address
+	return &output
+}
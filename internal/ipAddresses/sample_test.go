@@ -0,0 +1,42 @@
+package ips
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDraw(t *testing.T) {
+	offsets := draw(1000000, 50, 42)
+	assert.Len(t, offsets, 50)
+	distinct := make(map[int64]bool)
+	for _, offset := range offsets {
+		assert.True(t, offset >= 0 && offset < 1000000, "offset %d out of range", offset)
+		distinct[offset] = true
+	}
+	assert.Len(t, distinct, 50)
+	assert.Equal(t, offsets, draw(1000000, 50, 42), "the same seed draws the same offsets")
+	assert.NotEqual(t, offsets, draw(1000000, 50, 43))
+
+	assert.ElementsMatch(t, []int64{0, 1, 2}, draw(3, 10, 42), "small totals are drawn whole")
+}
+
+func TestMatchesSample(t *testing.T) {
+	ip := &IP{ProxyType: "DCH", Usage: "DCH", Country: Country{Code: "CH", Name: "Switzerland"}}
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		match   bool
+	}{
+		{name: "no filters", filters: map[string]interface{}{}, match: true},
+		{name: "country code", filters: map[string]interface{}{SampleCountry: "CH"}, match: true},
+		{name: "country name", filters: map[string]interface{}{SampleCountry: "Switzerland"}, match: true},
+		{name: "other country", filters: map[string]interface{}{SampleCountry: "AR"}, match: false},
+		{name: "usage", filters: map[string]interface{}{SampleCountry: "CH", SampleUsage: "DCH"}, match: true},
+		{name: "other proxy type", filters: map[string]interface{}{SampleProxyType: "VPN"}, match: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, matchesSample(ip, tt.filters))
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package ips
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	"sort"
+	"sync"
+)
+
+// errStop ends a walk early without being reported.
+var errStop = errors.New("stop")
+
+type walker interface {
+	// Walk calls the function with every range in ascending order.
+	Walk(context.Context, func(*IP) error) error
+}
+
+// Scanner answers the listing and aggregate queries by walking every range,
+// for file repositories that have no index by country. The aggregates are
+// computed in one pass on first use and kept in memory, the files are not
+// expected to change while open.
+type Scanner struct {
+	walker walker
+
+	mu        sync.Mutex
+	countries map[string]*countryTotals
+}
+
+type countryTotals struct {
+	code     string
+	ranges   int
+	quantity int
+	isps     map[string]int
+	regions  map[string]bool
+	cities   map[[2]string]bool
+}
+
+func NewScanner(walker walker) *Scanner {
+	return &Scanner{
+		walker: walker,
+	}
+}
+
+// List returns the first ranges of the country in ascending order whose sizes
+// add up to at most limit addresses, as the listing query of DBRepository.
+func (s *Scanner) List(ctx context.Context, limit int, filters map[string]interface{}) (_ []*IP, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.List")
+	defer func() { tracing.End(span, err) }()
+
+	country, _ := filters["country"].(string)
+	list := make([]*IP, 0)
+	total := int64(0)
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		if ip.Country.Name != country {
+			return nil
+		}
+		if total += ip.To - ip.From + 1; total > int64(limit) {
+			return errStop
+		}
+		list = append(list, &IP{
+			From:    ip.From,
+			To:      ip.To,
+			Country: Country{Name: ip.Country.Name, City: ip.Country.City},
+		})
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *Scanner) GetIPQuantityByCountry(ctx context.Context, country string) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.GetIPQuantityByCountry")
+	defer func() { tracing.End(span, err) }()
+
+	countries, err := s.aggregates(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if totals, ok := countries[country]; ok {
+		return totals.quantity, nil
+	}
+	return 0, nil
+}
+
+// GetTop10ISPByCountry ranks the ISPs by the addresses they hold in the
+// country, the same total the SQL query computes.
+func (s *Scanner) GetTop10ISPByCountry(ctx context.Context, country string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.GetTop10ISPByCountry")
+	defer func() { tracing.End(span, err) }()
+
+	countries, err := s.aggregates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isps := make([]string, 0)
+	totals, ok := countries[country]
+	if !ok {
+		return isps, nil
+	}
+	for isp := range totals.isps {
+		isps = append(isps, isp)
+	}
+	sort.Slice(isps, func(i, j int) bool {
+		if totals.isps[isps[i]] != totals.isps[isps[j]] {
+			return totals.isps[isps[i]] > totals.isps[isps[j]]
+		}
+		return isps[i] < isps[j]
+	})
+	if len(isps) > 10 {
+		isps = isps[:10]
+	}
+	return isps, nil
+}
+
+// Near walks every range measuring its distance to the center of circle,
+// ranges without coordinates are skipped. The order and totals match
+// DBRepository.Near.
+func (s *Scanner) Near(ctx context.Context, circle Circle, limit int) (_ *Nearby, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.Near")
+	defer func() { tracing.End(span, err) }()
+
+	type match struct {
+		ip       *IP
+		distance float64
+	}
+	matches := make([]match, 0)
+	cities := make(map[Country]*CityTotal)
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		if ip.Latitude == nil || ip.Longitude == nil {
+			return nil
+		}
+		distance := circle.distanceKm(*ip.Latitude, *ip.Longitude)
+		if distance > circle.RadiusKm {
+			return nil
+		}
+		matches = append(matches, match{ip: ip, distance: distance})
+		city, ok := cities[ip.Country]
+		if !ok {
+			city = &CityTotal{Country: ip.Country}
+			cities[ip.Country] = city
+		}
+		city.Ranges++
+		city.Addresses += ip.To - ip.From + 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+	nearby := &Nearby{Ranges: make([]*IP, 0), Cities: make([]*CityTotal, 0, len(cities))}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		nearby.Ranges = append(nearby.Ranges, matches[i].ip)
+	}
+	for _, city := range cities {
+		nearby.Cities = append(nearby.Cities, city)
+	}
+	sort.Slice(nearby.Cities, func(i, j int) bool {
+		if nearby.Cities[i].Addresses != nearby.Cities[j].Addresses {
+			return nearby.Cities[i].Addresses > nearby.Cities[j].Addresses
+		}
+		return nearby.Cities[i].Country.City < nearby.Cities[j].Country.City
+	})
+	return nearby, nil
+}
+
+// GetByDomain walks every range matching the domain column as
+// DBRepository.GetByDomain does.
+func (s *Scanner) GetByDomain(ctx context.Context, domain string, limit int) (_ *Domain, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.GetByDomain")
+	defer func() { tracing.End(span, err) }()
+
+	result := &Domain{Name: domain, Ranges: make([]*IP, 0), Countries: make([]*CountryTotal, 0),
+		ISPs: make([]*ISPTotal, 0)}
+	totals := newDomainTotals(result)
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		if !matchesDomain(ip.Domain, domain) {
+			return nil
+		}
+		if len(result.Ranges) < limit {
+			result.Ranges = append(result.Ranges, ip)
+		}
+		totals.add(Country{Code: ip.Country.Code, Name: ip.Country.Name}, ip.ISP, 1, ip.To-ip.From+1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return totals.finish(), nil
+}
+
+// SearchISPs walks every range scoring its ISP with wordSimilarity, an
+// approximation of the ranking of DBRepository.SearchISPs.
+func (s *Scanner) SearchISPs(ctx context.Context, query string, country string, limit int) (_ []*ISPMatch, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.SearchISPs")
+	defer func() { tracing.End(span, err) }()
+
+	scores := make(map[string]float64)
+	matches := make(ispMatches)
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		if country != "" && ip.Country.Code != country && ip.Country.Name != country {
+			return nil
+		}
+		score, ok := scores[ip.ISP]
+		if !ok {
+			score = wordSimilarity(query, ip.ISP)
+			scores[ip.ISP] = score
+		}
+		if score < ispSimilarityThreshold {
+			return nil
+		}
+		matches.add(ip.ISP, score, Country{Code: ip.Country.Code, Name: ip.Country.Name}, 1, ip.To-ip.From+1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches.ranked(limit), nil
+}
+
+// ListCountries summarizes the countries from the aggregates, by name as
+// DBRepository.ListCountries.
+func (s *Scanner) ListCountries(ctx context.Context) (_ []*CountrySummary, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.ListCountries")
+	defer func() { tracing.End(span, err) }()
+
+	countries, err := s.aggregates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]*CountrySummary, 0, len(countries))
+	for name, totals := range countries {
+		if totals.code == "" || totals.code == "-" {
+			continue
+		}
+		summaries = append(summaries, totals.summary(name))
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Country.Name < summaries[j].Country.Name })
+	return summaries, nil
+}
+
+// GetCountry fails with sql.ErrNoRows for codes without ranges, following
+// the DBRepository contract.
+func (s *Scanner) GetCountry(ctx context.Context, code string) (_ *CountrySummary, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.GetCountry")
+	defer func() { tracing.End(span, err) }()
+
+	countries, err := s.aggregates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name, totals := range countries {
+		if totals.code == code {
+			return totals.summary(name), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// Sample keeps the ranges matching filters and the running sum of their
+// sizes, then finds the range of every offset drawn by binary search.
+func (s *Scanner) Sample(ctx context.Context, n int, seed int64, filters map[string]interface{}) (_ []*IP, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.Sample")
+	defer func() { tracing.End(span, err) }()
+
+	matching := make([]*IP, 0)
+	cumulative := make([]int64, 0)
+	total := int64(0)
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		if !matchesSample(ip, filters) {
+			return nil
+		}
+		total += ip.To - ip.From + 1
+		matching = append(matching, ip)
+		cumulative = append(cumulative, total)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]*IP, 0, n)
+	if total == 0 {
+		return ips, nil
+	}
+	for _, offset := range draw(total, n, seed) {
+		i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > offset })
+		ip := matching[i]
+		ips = append(ips, sampled(ip, offset-cumulative[i]+ip.To+1))
+	}
+	return ips, nil
+}
+
+// ListRegions walks every range of the country with the code given, leaving
+// out the ones without a region like DBRepository.ListRegions.
+func (s *Scanner) ListRegions(ctx context.Context, code string) (_ []*RegionSummary, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.ListRegions")
+	defer func() { tracing.End(span, err) }()
+
+	regions := make(map[string]*RegionSummary)
+	cities := make(map[[2]string]bool)
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		name := ip.Country.Region
+		if ip.Country.Code != code || name == "" || name == "-" {
+			return nil
+		}
+		region, ok := regions[name]
+		if !ok {
+			region = &RegionSummary{Country: Country{Code: code, Name: ip.Country.Name, Region: name}}
+			regions[name] = region
+		}
+		region.Ranges++
+		region.Addresses += ip.To - ip.From + 1
+		if city := ip.Country.City; city != "" && city != "-" && !cities[[2]string{name, city}] {
+			cities[[2]string{name, city}] = true
+			region.Cities++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	output := make([]*RegionSummary, 0, len(regions))
+	for _, region := range regions {
+		output = append(output, region)
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i].Country.Region < output[j].Country.Region })
+	return output, nil
+}
+
+func (s *Scanner) ListCities(ctx context.Context, code string, region string) (_ []*CityTotal, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.ListCities")
+	defer func() { tracing.End(span, err) }()
+
+	cities := make(map[string]*CityTotal)
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		name := ip.Country.City
+		if ip.Country.Code != code || ip.Country.Region != region || name == "" || name == "-" {
+			return nil
+		}
+		city, ok := cities[name]
+		if !ok {
+			city = &CityTotal{Country: Country{Code: code, Name: ip.Country.Name, Region: region, City: name}}
+			cities[name] = city
+		}
+		city.Ranges++
+		city.Addresses += ip.To - ip.From + 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	output := make([]*CityTotal, 0, len(cities))
+	for _, city := range cities {
+		output = append(output, city)
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i].Country.City < output[j].Country.City })
+	return output, nil
+}
+
+// GetCity fails with sql.ErrNoRows for cities without ranges, following the
+// DBRepository contract.
+func (s *Scanner) GetCity(ctx context.Context, code string, region string, city string, limit int) (_ *City, err error) {
+	ctx, span := tracer.Start(ctx, "Scanner.GetCity")
+	defer func() { tracing.End(span, err) }()
+
+	result := &City{Ranges: make([]*IP, 0)}
+	err = s.walker.Walk(ctx, func(ip *IP) error {
+		if !inCity(ip, code, region, city) {
+			return nil
+		}
+		if result.Total.Ranges == 0 {
+			result.Total.Country = Country{Code: code, Name: ip.Country.Name, Region: region, City: city}
+		}
+		if len(result.Ranges) < limit {
+			result.Ranges = append(result.Ranges, ip)
+		}
+		result.Total.Ranges++
+		result.Total.Addresses += ip.To - ip.From + 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Total.Ranges == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return result, nil
+}
+
+func (t *countryTotals) summary(name string) *CountrySummary {
+	return &CountrySummary{
+		Country:   Country{Code: t.code, Name: name},
+		Ranges:    t.ranges,
+		Addresses: int64(t.quantity),
+		Regions:   len(t.regions),
+		Cities:    len(t.cities),
+	}
+}
+
+func (s *Scanner) aggregates(ctx context.Context) (map[string]*countryTotals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.countries != nil {
+		return s.countries, nil
+	}
+	countries := make(map[string]*countryTotals)
+	err := s.walker.Walk(ctx, func(ip *IP) error {
+		totals, ok := countries[ip.Country.Name]
+		if !ok {
+			totals = &countryTotals{code: ip.Country.Code, isps: make(map[string]int),
+				regions: make(map[string]bool), cities: make(map[[2]string]bool)}
+			countries[ip.Country.Name] = totals
+		}
+		size := int(ip.To - ip.From + 1)
+		totals.ranges++
+		totals.quantity += size
+		totals.isps[ip.ISP] += size
+		if region := ip.Country.Region; region != "" && region != "-" {
+			totals.regions[region] = true
+		}
+		if city := ip.Country.City; city != "" && city != "-" {
+			totals.cities[[2]string{ip.Country.Region, city}] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.countries = countries
+	return countries, nil
+}
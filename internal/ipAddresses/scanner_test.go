@@ -0,0 +1,248 @@
+package ips
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type fakeWalker struct {
+	ranges []*IP
+	walks  int
+	err    error
+}
+
+func (w *fakeWalker) Walk(_ context.Context, fn func(*IP) error) error {
+	w.walks++
+	if w.err != nil {
+		return w.err
+	}
+	for _, ip := range w.ranges {
+		if err := fn(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestScanner(t *testing.T) {
+	walker := &fakeWalker{ranges: []*IP{
+		{From: 10, To: 19, ISP: "Telecom", Country: Country{Name: "Argentina", City: "Cordoba"}},
+		{From: 20, To: 20, ISP: "APNIC", Country: Country{Name: "Australia", City: "Brisbane"}},
+		{From: 30, To: 34, ISP: "Claro", Country: Country{Name: "Argentina", City: "Rosario"}},
+		{From: 40, To: 49, ISP: "Claro", Country: Country{Name: "Argentina", City: "Rosario"}},
+	}}
+	scanner := NewScanner(walker)
+	ctx := context.Background()
+
+	list, err := scanner.List(ctx, 16, map[string]interface{}{"country": "Argentina"})
+	require.NoError(t, err)
+	assert.Equal(t, []*IP{
+		{From: 10, To: 19, Country: Country{Name: "Argentina", City: "Cordoba"}},
+		{From: 30, To: 34, Country: Country{Name: "Argentina", City: "Rosario"}},
+	}, list)
+
+	quantity, err := scanner.GetIPQuantityByCountry(ctx, "Argentina")
+	require.NoError(t, err)
+	assert.Equal(t, 25, quantity)
+
+	isps, err := scanner.GetTop10ISPByCountry(ctx, "Argentina")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Claro", "Telecom"}, isps)
+
+	isps, err = scanner.GetTop10ISPByCountry(ctx, "Chile")
+	require.NoError(t, err)
+	assert.Empty(t, isps)
+	assert.Equal(t, 2, walker.walks, "aggregates are computed once")
+}
+
+func TestScanner_WalkError(t *testing.T) {
+	walker := &fakeWalker{err: errors.New("corrupt file")}
+	scanner := NewScanner(walker)
+
+	_, err := scanner.GetIPQuantityByCountry(context.Background(), "Argentina")
+	assert.EqualError(t, err, "corrupt file")
+	_, err = scanner.GetIPQuantityByCountry(context.Background(), "Argentina")
+	assert.EqualError(t, err, "corrupt file")
+	assert.Equal(t, 2, walker.walks, "errors are not cached")
+}
+
+func TestScanner_Near(t *testing.T) {
+	located := func(from, to int64, city string, latitude, longitude float64) *IP {
+		return &IP{From: from, To: to, Country: Country{Code: "AR", Name: "Argentina", City: city},
+			Latitude: &latitude, Longitude: &longitude}
+	}
+	walker := &fakeWalker{ranges: []*IP{
+		located(10, 19, "La Plata", -34.92145, -57.95453),
+		located(20, 20, "Buenos Aires", -34.61315, -58.37723),
+		located(30, 39, "Cordoba", -31.4135, -64.18105),
+		located(40, 49, "Buenos Aires", -34.61315, -58.37723),
+		{From: 50, To: 50, Country: Country{Code: "AR", Name: "Argentina", City: "Buenos Aires"}},
+	}}
+	scanner := NewScanner(walker)
+
+	nearby, err := scanner.Near(context.Background(), Circle{Latitude: -34.6, Longitude: -58.4, RadiusKm: 100}, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []*IP{walker.ranges[1], walker.ranges[3]}, nearby.Ranges)
+	assert.Equal(t, []*CityTotal{
+		{Country: Country{Code: "AR", Name: "Argentina", City: "Buenos Aires"}, Ranges: 2, Addresses: 11},
+		{Country: Country{Code: "AR", Name: "Argentina", City: "La Plata"}, Ranges: 1, Addresses: 10},
+	}, nearby.Cities)
+}
+
+func TestCircle_DistanceKm(t *testing.T) {
+	buenosAires := Circle{Latitude: -34.61315, Longitude: -58.37723}
+
+	assert.Zero(t, buenosAires.distanceKm(-34.61315, -58.37723))
+	assert.InDelta(t, 648, buenosAires.distanceKm(-31.4135, -64.18105), 1)
+}
+
+func TestScanner_GetByDomain(t *testing.T) {
+	walker := &fakeWalker{ranges: []*IP{
+		{From: 10, To: 19, ISP: "CTL LATAM", Domain: "centurylink.com", Country: Country{Code: "AR", Name: "Argentina"}},
+		{From: 20, To: 20, ISP: "Lumen", Domain: "mycenturylink.com", Country: Country{Code: "US", Name: "United States"}},
+		{From: 30, To: 34, ISP: "Lumen", Domain: "cdn.centurylink.com", Country: Country{Code: "US", Name: "United States"}},
+		{From: 40, To: 49, ISP: "CTL LATAM", Domain: "centurylink.com", Country: Country{Code: "AR", Name: "Argentina"}},
+	}}
+	scanner := NewScanner(walker)
+
+	domain, err := scanner.GetByDomain(context.Background(), "centurylink.com", 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, &Domain{
+		Name:      "centurylink.com",
+		Ranges:    []*IP{walker.ranges[0], walker.ranges[2]},
+		Addresses: 25,
+		Countries: []*CountryTotal{
+			{Country: Country{Code: "AR", Name: "Argentina"}, Ranges: 2, Addresses: 20},
+			{Country: Country{Code: "US", Name: "United States"}, Ranges: 1, Addresses: 5},
+		},
+		ISPs: []*ISPTotal{
+			{ISP: "CTL LATAM", Ranges: 2, Addresses: 20},
+			{ISP: "Lumen", Ranges: 1, Addresses: 5},
+		},
+	}, domain)
+}
+
+func TestScanner_SearchISPs(t *testing.T) {
+	walker := &fakeWalker{ranges: []*IP{
+		{From: 10, To: 19, ISP: "Swisscom AG", Country: Country{Code: "CH", Name: "Switzerland"}},
+		{From: 20, To: 20, ISP: "Swisscom AG", Country: Country{Code: "DE", Name: "Germany"}},
+		{From: 30, To: 34, ISP: "Sunrise UPC GmbH", Country: Country{Code: "CH", Name: "Switzerland"}},
+		{From: 40, To: 49, ISP: "Swiscom Ltd", Country: Country{Code: "CH", Name: "Switzerland"}},
+	}}
+	scanner := NewScanner(walker)
+
+	matches, err := scanner.SearchISPs(context.Background(), "swisscom", "CH", 10)
+
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, &ISPMatch{ISP: "Swisscom AG", Score: 1, Ranges: 1, Addresses: 10, Countries: []*CountryTotal{
+		{Country: Country{Code: "CH", Name: "Switzerland"}, Ranges: 1, Addresses: 10},
+	}}, matches[0])
+	assert.Equal(t, "Swiscom Ltd", matches[1].ISP)
+	assert.Less(t, matches[1].Score, 1.0)
+}
+
+func TestScanner_Countries(t *testing.T) {
+	walker := &fakeWalker{ranges: []*IP{
+		{From: 10, To: 19, Country: Country{Code: "CH", Name: "Switzerland", Region: "Zurich", City: "Zurich"}},
+		{From: 20, To: 20, Country: Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"}},
+		{From: 30, To: 34, Country: Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"}},
+		{From: 40, To: 49, Country: Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"}},
+		{From: 50, To: 59, Country: Country{Code: "-", Name: "-", Region: "-", City: "-"}},
+	}}
+	scanner := NewScanner(walker)
+	ctx := context.Background()
+	argentina := &CountrySummary{Country: Country{Code: "AR", Name: "Argentina"}, Ranges: 3, Addresses: 16, Regions: 2, Cities: 2}
+
+	countries, err := scanner.ListCountries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*CountrySummary{
+		argentina,
+		{Country: Country{Code: "CH", Name: "Switzerland"}, Ranges: 1, Addresses: 10, Regions: 1, Cities: 1},
+	}, countries)
+
+	country, err := scanner.GetCountry(ctx, "AR")
+	require.NoError(t, err)
+	assert.Equal(t, argentina, country)
+
+	_, err = scanner.GetCountry(ctx, "CL")
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestScanner_Hierarchy(t *testing.T) {
+	walker := &fakeWalker{ranges: []*IP{
+		{From: 10, To: 19, Country: Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"}},
+		{From: 20, To: 20, Country: Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"}},
+		{From: 30, To: 34, Country: Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rafaela"}},
+		{From: 40, To: 49, Country: Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"}},
+		{From: 50, To: 59, Country: Country{Code: "AR", Name: "Argentina", Region: "-", City: "-"}},
+		{From: 60, To: 69, Country: Country{Code: "CH", Name: "Switzerland", Region: "Zurich", City: "Zurich"}},
+	}}
+	scanner := NewScanner(walker)
+	ctx := context.Background()
+	santaFe := Country{Code: "AR", Name: "Argentina", Region: "Santa Fe"}
+	rosario := Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rosario"}
+
+	regions, err := scanner.ListRegions(ctx, "AR")
+	require.NoError(t, err)
+	assert.Equal(t, []*RegionSummary{
+		{Country: Country{Code: "AR", Name: "Argentina", Region: "Cordoba"}, Ranges: 1, Addresses: 1, Cities: 1},
+		{Country: santaFe, Ranges: 3, Addresses: 25, Cities: 2},
+	}, regions)
+
+	cities, err := scanner.ListCities(ctx, "AR", "Santa Fe")
+	require.NoError(t, err)
+	assert.Equal(t, []*CityTotal{
+		{Country: Country{Code: "AR", Name: "Argentina", Region: "Santa Fe", City: "Rafaela"}, Ranges: 1, Addresses: 5},
+		{Country: rosario, Ranges: 2, Addresses: 20},
+	}, cities)
+
+	city, err := scanner.GetCity(ctx, "AR", "Santa Fe", "Rosario", 1)
+	require.NoError(t, err)
+	assert.Equal(t, &City{
+		Total:  CityTotal{Country: rosario, Ranges: 2, Addresses: 20},
+		Ranges: []*IP{walker.ranges[0]},
+	}, city)
+
+	_, err = scanner.GetCity(ctx, "AR", "Santa Fe", "Atlantis", 1)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestScanner_Sample(t *testing.T) {
+	walker := &fakeWalker{ranges: []*IP{
+		{From: 10, To: 19, Usage: "DCH", Country: Country{Code: "CH", Name: "Switzerland"}},
+		{From: 20, To: 20, Usage: "DCH", Country: Country{Code: "AR", Name: "Argentina"}},
+		{From: 30, To: 34, Usage: "ISP", Country: Country{Code: "CH", Name: "Switzerland"}},
+		{From: 40, To: 44, Usage: "DCH", Country: Country{Code: "CH", Name: "Switzerland"}},
+	}}
+	scanner := NewScanner(walker)
+	ctx := context.Background()
+	filters := map[string]interface{}{SampleCountry: "CH", SampleUsage: "DCH"}
+
+	sample, err := scanner.Sample(ctx, 20, 42, filters)
+	require.NoError(t, err)
+	addresses := make([]int64, 0)
+	for _, ip := range sample {
+		assert.Equal(t, ip.From, ip.To)
+		assert.Equal(t, "DCH", ip.Usage)
+		addresses = append(addresses, ip.From)
+	}
+	assert.ElementsMatch(t, []int64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 40, 41, 42, 43, 44}, addresses)
+
+	first, err := scanner.Sample(ctx, 3, 7, filters)
+	require.NoError(t, err)
+	again, err := scanner.Sample(ctx, 3, 7, filters)
+	require.NoError(t, err)
+	assert.Len(t, first, 3)
+	assert.Equal(t, first, again)
+
+	sample, err = scanner.Sample(ctx, 3, 7, map[string]interface{}{SampleCountry: "CL"})
+	require.NoError(t, err)
+	assert.Empty(t, sample)
+}
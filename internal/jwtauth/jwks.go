@@ -0,0 +1,233 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// fetchTimeout bounds a JWKS fetch, which runs detached from the request
+// that triggered it.
+const fetchTimeout = 10 * time.Second
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet caches the public keys published at a JWKS URL. Keys are fetched
+// again once the cache expires, or earlier when a token is signed with an
+// unknown kid, which is how issuers rotate keys. Refetches triggered by
+// unknown kids are throttled so forged tokens can't flood the issuer. A
+// single fetch runs at a time, outside the lock, and the cached keys are
+// served while it does.
+type KeySet struct {
+	url        string
+	client     *http.Client
+	ttl        time.Duration
+	minRefetch time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	lastErr   error
+	// inflight is the running fetch, nil when there is none.
+	inflight *fetchCall
+	now      func() time.Time
+}
+
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+func NewKeySet(url string, client *http.Client, ttl time.Duration) *KeySet {
+	return &KeySet{
+		url:        url,
+		client:     client,
+		ttl:        ttl,
+		minRefetch: 30 * time.Second,
+		keys:       make(map[string]crypto.PublicKey),
+		now:        time.Now,
+	}
+}
+
+// Key returns the key kid names. Cached keys are returned right away, also
+// while an expired cache is fetched again; unknown kids wait for the running
+// fetch or the one they trigger, or for ctx to be done.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	now := s.now()
+	expired := now.Sub(s.fetchedAt) >= s.ttl
+	key, ok := s.keys[kid]
+	if ok && !expired {
+		s.mu.Unlock()
+		return key, nil
+	}
+	var call *fetchCall
+	if s.inflight != nil || expired || now.Sub(s.fetchedAt) >= s.minRefetch {
+		call = s.fetch()
+	}
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if call == nil {
+		return nil, ErrUnknownKey
+	}
+	if err := call.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if call.err != nil && len(s.keys) == 0 {
+		return nil, call.err
+	}
+	return nil, ErrUnknownKey
+}
+
+// Check reports whether the last fetch succeeded, used as a health check.
+func (s *KeySet) Check(ctx context.Context) error {
+	s.mu.Lock()
+	if s.fetchedAt.IsZero() {
+		call := s.fetch()
+		s.mu.Unlock()
+		if err := call.wait(ctx); err != nil {
+			return err
+		}
+		return call.err
+	}
+	defer s.mu.Unlock()
+	return s.lastErr
+}
+
+// fetch starts replacing the cached keys, or joins the fetch already running.
+// The previous keys are kept if it fails so an issuer outage doesn't reject
+// tokens signed with known keys. It must be called holding s.mu.
+func (s *KeySet) fetch() *fetchCall {
+	if s.inflight != nil {
+		return s.inflight
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	s.inflight = call
+	s.fetchedAt = s.now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		keys, err := s.load(ctx)
+
+		s.mu.Lock()
+		if err == nil {
+			s.keys = keys
+		}
+		s.lastErr = err
+		s.inflight = nil
+		s.mu.Unlock()
+		call.err = err
+		close(call.done)
+	}()
+	return call
+}
+
+// wait blocks until the fetch finishes, failing only if ctx is done first.
+func (c *fetchCall) wait(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *KeySet) load(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching jwks: unexpected status %d", response.StatusCode)
+	}
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("decoding jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range document.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Keys of unsupported types are skipped, the rest of the set is still usable.
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
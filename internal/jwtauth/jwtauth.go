@@ -0,0 +1,111 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"strings"
+)
+
+type keySource interface {
+	Key(context.Context, string) (crypto.PublicKey, error)
+}
+
+type Config struct {
+	Issuer   string
+	Audience string
+	// ScopeClaim names the claim holding the permissions, either a space
+	// separated string (OAuth2 "scope") or an array of strings.
+	ScopeClaim string
+	// ScopeMapping translates the issuer's scope names to API scopes, e.g.
+	// "geo.read" to "lookup". When empty claimed scopes are used as they are.
+	ScopeMapping map[string]string
+	// TierClaim names the string claim holding the client's data tier.
+	TierClaim string
+}
+
+// Validator authenticates RS256 and ES256 bearer tokens signed by keys of a
+// JWKS, mapping their scopes to the API scopes.
+type Validator struct {
+	keys   keySource
+	config Config
+	parser *jwt.Parser
+}
+
+func NewValidator(keys keySource, config Config) *Validator {
+	if config.ScopeClaim == "" {
+		config.ScopeClaim = "scope"
+	}
+	if config.TierClaim == "" {
+		config.TierClaim = "tier"
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	return &Validator{
+		keys:   keys,
+		config: config,
+		parser: jwt.NewParser(options...),
+	}
+}
+
+// Authenticate fails with auth.ErrUnauthenticated for any invalid token, the
+// cause is not disclosed to the caller.
+func (v *Validator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, auth.ErrUnauthenticated
+	}
+	name, _ := claims["name"].(string)
+	tier, _ := claims[v.config.TierClaim].(string)
+	if name == "" {
+		name = subject
+	}
+	return &auth.Principal{
+		ID:     "jwt:" + subject,
+		Name:   name,
+		Scopes: v.scopes(claims),
+		Tier:   tier,
+	}, nil
+}
+
+// scopes maps the claimed scopes and keeps only the ones the API knows about.
+func (v *Validator) scopes(claims jwt.MapClaims) []string {
+	var claimed []string
+	switch value := claims[v.config.ScopeClaim].(type) {
+	case string:
+		claimed = strings.Fields(value)
+	case []interface{}:
+		for _, item := range value {
+			if scope, ok := item.(string); ok {
+				claimed = append(claimed, scope)
+			}
+		}
+	}
+	scopes := make([]string, 0)
+	for _, scope := range claimed {
+		if len(v.config.ScopeMapping) > 0 {
+			scope = v.config.ScopeMapping[scope]
+		}
+		if auth.ValidScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
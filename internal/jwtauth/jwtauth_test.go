@@ -0,0 +1,251 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mborroni/dreamlab-challenge/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type issuer struct {
+	rsaKey  *rsa.PrivateKey
+	ecKey   *ecdsa.PrivateKey
+	rsaKid  string
+	server  *httptest.Server
+	fetches int32
+}
+
+func encode(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+// newIssuer serves a JWKS with one RSA and one P-256 key.
+func newIssuer(t *testing.T) *issuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &issuer{rsaKey: rsaKey, ecKey: ecKey, rsaKid: "rsa-1"}
+	i.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&i.fetches, 1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{"kty": "RSA", "kid": i.rsaKid, "use": "sig", "n": encode(i.rsaKey.N), "e": encode(big.NewInt(int64(i.rsaKey.E)))},
+				{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(i.ecKey.X), "y": encode(i.ecKey.Y)},
+			},
+		})
+	}))
+	t.Cleanup(i.server.Close)
+	return i
+}
+
+func (i *issuer) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	var key interface{} = i.rsaKey
+	if method == jwt.SigningMethodES256 {
+		key = i.ecKey
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func claims(overrides jwt.MapClaims) jwt.MapClaims {
+	c := jwt.MapClaims{
+		"iss":   "https://issuer.example.com",
+		"aud":   "ip2location-api",
+		"sub":   "analytics-service",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "lookup aggregate unknown",
+	}
+	for k, v := range overrides {
+		c[k] = v
+	}
+	return c
+}
+
+func TestValidator_Authenticate(t *testing.T) {
+	i := newIssuer(t)
+	validator := NewValidator(NewKeySet(i.server.URL, i.server.Client(), time.Hour), Config{
+		Issuer:   "https://issuer.example.com",
+		Audience: "ip2location-api",
+	})
+
+	tests := []struct {
+		name      string
+		token     string
+		principal *auth.Principal
+		err       error
+	}{
+		{
+			name:  "RS256",
+			token: i.sign(t, jwt.SigningMethodRS256, "rsa-1", claims(nil)),
+			principal: &auth.Principal{ID: "jwt:analytics-service", Name: "analytics-service",
+				Scopes: []string{auth.ScopeLookup, auth.ScopeAggregate}},
+		},
+		{
+			name:  "ES256 with array scopes",
+			token: i.sign(t, jwt.SigningMethodES256, "ec-1", claims(jwt.MapClaims{"scope": []string{"export"}, "tier": "premium"})),
+			principal: &auth.Principal{ID: "jwt:analytics-service", Name: "analytics-service",
+				Scopes: []string{auth.ScopeExport}, Tier: "premium"},
+		},
+		{
+			name:  "expired",
+			token: i.sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			name:  "wrong audience",
+			token: i.sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"aud": "other-api"})),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			name:  "wrong issuer",
+			token: i.sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			name:  "unknown kid",
+			token: i.sign(t, jwt.SigningMethodRS256, "rsa-2", claims(nil)),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			name:  "HS256 not allowed",
+			token: hs256(t, claims(nil)),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			name:  "malformed",
+			token: "not-a-token",
+			err:   auth.ErrUnauthenticated,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := validator.Authenticate(context.Background(), tc.token)
+
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.principal, principal)
+		})
+	}
+}
+
+func hs256(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = "rsa-1"
+	signed, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestValidator_ScopeMapping(t *testing.T) {
+	i := newIssuer(t)
+	validator := NewValidator(NewKeySet(i.server.URL, i.server.Client(), time.Hour), Config{
+		ScopeClaim:   "permissions",
+		ScopeMapping: map[string]string{"geo.read": auth.ScopeLookup, "geo.bulk": auth.ScopeExport},
+	})
+	token := i.sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{
+		"permissions": []string{"geo.read", "lookup", "geo.bulk"},
+	}))
+
+	principal, err := validator.Authenticate(context.Background(), token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{auth.ScopeLookup, auth.ScopeExport}, principal.Scopes)
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	i := newIssuer(t)
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	keys := NewKeySet(i.server.URL, i.server.Client(), time.Hour)
+	keys.now = func() time.Time { return now }
+
+	_, err := keys.Key(context.Background(), "rsa-1")
+	assert.NoError(t, err)
+	_, err = keys.Key(context.Background(), "ec-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&i.fetches), "cached keys are not fetched again")
+
+	i.rsaKid = "rsa-2"
+	_, err = keys.Key(context.Background(), "rsa-2")
+	assert.Equal(t, ErrUnknownKey, err, "unknown kids refetch at most every minRefetch")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&i.fetches))
+
+	now = now.Add(time.Minute)
+	_, err = keys.Key(context.Background(), "rsa-2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&i.fetches))
+
+	now = now.Add(2 * time.Hour)
+	_, err = keys.Key(context.Background(), "ec-1")
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&i.fetches) == 3 }, time.Second, time.Millisecond,
+		"expired cache is fetched again")
+}
+
+func TestKeySet_SlowFetch(t *testing.T) {
+	i := newIssuer(t)
+	release := make(chan struct{})
+	handler := i.server.Config.Handler
+	i.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&i.fetches) > 0 {
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	})
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	keys := NewKeySet(i.server.URL, i.server.Client(), time.Hour)
+	keys.now = func() time.Time { return now }
+	_, err := keys.Key(context.Background(), "ec-1")
+	assert.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+	i.rsaKid = "rsa-2"
+	_, err = keys.Key(context.Background(), "ec-1")
+	assert.NoError(t, err, "cached keys are served while the expired cache is fetched")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = keys.Key(cancelled, "rsa-2")
+	assert.Equal(t, context.Canceled, err)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, err := keys.Key(context.Background(), "rsa-2")
+		return err == nil
+	}, time.Second, time.Millisecond, "a cancelled request doesn't abort the fetch")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&i.fetches))
+}
+
+func TestKeySet_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	keys := NewKeySet(server.URL, server.Client(), time.Hour)
+
+	assert.Error(t, keys.Check(context.Background()))
+	_, err := keys.Key(context.Background(), "rsa-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+type Config struct {
+	// Level is any level understood by logrus, "info" when empty.
+	Level string
+	// Format is "json" (default) or "text".
+	Format string
+	// DatasetVersion identifies the IP2Location release being served.
+	DatasetVersion string
+}
+
+// Request holds what is known about the request being served, attached to the
+// context by the logging middleware.
+type Request struct {
+	ID       string
+	ClientIP string
+	Start    time.Time
+	// Route returns the matched route pattern, only known once the router has
+	// dispatched the request.
+	Route func() string
+}
+
+type requestKey struct{}
+
+// Setup configures the standard logrus logger, which every package logs through.
+func Setup(config Config) error {
+	level := log.InfoLevel
+	if config.Level != "" {
+		parsed, err := log.ParseLevel(config.Level)
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+	switch config.Format {
+	case "", "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	case "text":
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	default:
+		return fmt.Errorf("unknown log format %q", config.Format)
+	}
+	log.SetLevel(level)
+	log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+	log.AddHook(&requestHook{datasetVersion: config.DatasetVersion})
+	return nil
+}
+
+func WithRequest(ctx context.Context, request *Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+func RequestFromContext(ctx context.Context) *Request {
+	if ctx == nil {
+		return nil
+	}
+	request, _ := ctx.Value(requestKey{}).(*Request)
+	return request
+}
+
+// requestHook adds the request fields to every entry logged with
+// log.WithContext(ctx) while serving a request.
+type requestHook struct {
+	datasetVersion string
+}
+
+func (h *requestHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *requestHook) Fire(entry *log.Entry) error {
+	data := make(log.Fields, len(entry.Data)+6)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	if h.datasetVersion != "" {
+		data["dataset_version"] = h.datasetVersion
+	}
+	if request := RequestFromContext(entry.Context); request != nil {
+		data["request_id"] = request.ID
+		data["client_ip"] = request.ClientIP
+		data["latency_ms"] = float64(time.Since(request.Start).Microseconds()) / 1000
+		if request.Route != nil {
+			if route := request.Route(); route != "" {
+				data["route"] = route
+			}
+		}
+	}
+	// entry is a copy made by logrus but its Data map is shared with the
+	// caller's entry, so it is replaced instead of written to.
+	entry.Data = data
+	return nil
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "defaults", config: Config{}},
+		{name: "text debug", config: Config{Level: "debug", Format: "text"}},
+		{name: "invalid level", config: Config{Level: "loud"}, wantErr: true},
+		{name: "invalid format", config: Config{Format: "xml"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Setup(tc.config)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRequestHook(t *testing.T) {
+	assert.NoError(t, Setup(Config{DatasetVersion: "PX7-2021-12"}))
+	buffer := &bytes.Buffer{}
+	log.SetOutput(buffer)
+
+	ctx := WithRequest(context.Background(), &Request{
+		ID:       "host/abc-000001",
+		ClientIP: "10.0.0.1",
+		Start:    time.Now(),
+		Route:    func() string { return "/v1/ips/{IP}/" },
+	})
+	log.WithContext(ctx).WithFields(log.Fields{"event": "get ip address"}).Error("boom")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &line))
+	assert.Equal(t, "boom", line["msg"])
+	assert.Equal(t, "get ip address", line["event"])
+	assert.Equal(t, "host/abc-000001", line["request_id"])
+	assert.Equal(t, "10.0.0.1", line["client_ip"])
+	assert.Equal(t, "/v1/ips/{IP}/", line["route"])
+	assert.Equal(t, "PX7-2021-12", line["dataset_version"])
+	assert.Contains(t, line, "latency_ms")
+}
+
+func TestRequestHook_WithoutRequest(t *testing.T) {
+	assert.NoError(t, Setup(Config{}))
+	buffer := &bytes.Buffer{}
+	log.SetOutput(buffer)
+
+	log.WithContext(context.Background()).Info("starting")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &line))
+	assert.NotContains(t, line, "request_id")
+	assert.NotContains(t, line, "dataset_version")
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route and status class.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Repository query latency, by repository method.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"repository", "method"})
+
+	queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Repository queries that failed, by repository method.",
+	}, []string{"repository", "method"})
+
+	lookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ip_lookups_total",
+		Help: "IP lookups, by result (found or not_found).",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		queryDuration,
+		queryErrors,
+		lookups,
+	)
+}
+
+// Handler exposes every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exports the connection pool stats of db labelled with name.
+func RegisterDB(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+func ObserveRequest(method string, route string, status int, duration time.Duration) {
+	requests.WithLabelValues(method, route, statusClass(status)).Inc()
+	requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func ObserveQuery(repository string, method string, duration time.Duration, err error) {
+	queryDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+	if err != nil && err != sql.ErrNoRows {
+		queryErrors.WithLabelValues(repository, method).Inc()
+	}
+}
+
+func ObserveLookup(found bool) {
+	result := "not_found"
+	if found {
+		result = "found"
+	}
+	lookups.WithLabelValues(result).Inc()
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{status: http.StatusOK, want: "2xx"},
+		{status: http.StatusNoContent, want: "2xx"},
+		{status: http.StatusNotFound, want: "4xx"},
+		{status: http.StatusInternalServerError, want: "5xx"},
+		{status: 0, want: "unknown"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, statusClass(tc.status))
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	ObserveQuery("test", "Get", time.Millisecond, nil)
+	ObserveQuery("test", "Get", time.Millisecond, sql.ErrNoRows)
+	ObserveQuery("test", "Get", time.Millisecond, sql.ErrConnDone)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(queryErrors.WithLabelValues("test", "Get")))
+	assert.Equal(t, 1, testutil.CollectAndCount(queryDuration))
+}
+
+func TestObserveLookup(t *testing.T) {
+	ObserveLookup(true)
+	ObserveLookup(true)
+	ObserveLookup(false)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(lookups.WithLabelValues("found")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(lookups.WithLabelValues("not_found")))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveRequest(http.MethodGet, "/v1/ips/{IP}/", http.StatusOK, time.Millisecond)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(),
+		`http_requests_total{method="GET",route="/v1/ips/{IP}/",status="2xx"} 1`))
+}
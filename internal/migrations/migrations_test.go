@@ -0,0 +1,234 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+func newMockMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return &Migrator{
+		db: db,
+		migrations: []*Migration{
+			{Version: 1, Name: "create", Up: "CREATE TABLE t (id int)", Down: "DROP TABLE t"},
+			{Version: 2, Name: "index", Up: "CREATE INDEX t_idx ON t (id)", Down: "DROP INDEX t_idx"},
+		},
+	}, mock
+}
+
+func expectTable(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectExists(mock sqlmock.Sqlmock, exists bool) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT to_regclass('schema_migrations') IS NOT NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+}
+
+func expectVersion(mock sqlmock.Sqlmock, current int64) {
+	expectExists(mock, true)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version),0) FROM schema_migrations")).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(current))
+}
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(lockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(lockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestMigrator_Up(t *testing.T) {
+	type want struct {
+		applied int
+		err     bool
+	}
+
+	tests := []struct {
+		name         string
+		expectations func(mock sqlmock.Sqlmock)
+		want         want
+	}{
+		{
+			name: "applies pending",
+			expectations: func(mock sqlmock.Sqlmock) {
+				expectLock(mock)
+				expectTable(mock)
+				expectVersion(mock, 1)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX t_idx ON t (id)")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).
+					WithArgs(int64(2), "index").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				expectUnlock(mock)
+			},
+			want: want{applied: 1},
+		},
+		{
+			name: "up to date",
+			expectations: func(mock sqlmock.Sqlmock) {
+				expectLock(mock)
+				expectTable(mock)
+				expectVersion(mock, 2)
+				expectUnlock(mock)
+			},
+			want: want{applied: 0},
+		},
+		{
+			name: "error rolls back",
+			expectations: func(mock sqlmock.Sqlmock) {
+				expectLock(mock)
+				expectTable(mock)
+				expectVersion(mock, 0)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE t (id int)")).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				expectUnlock(mock)
+			},
+			want: want{applied: 0, err: true},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			migrator, mock := newMockMigrator(t)
+			tc.expectations(mock)
+
+			applied, err := migrator.Up(context.Background())
+
+			assert.Equal(t, tc.want.applied, applied)
+			assert.Equal(t, tc.want.err, err != nil)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	migrator, mock := newMockMigrator(t)
+	expectLock(mock)
+	expectTable(mock)
+	expectVersion(mock, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP INDEX t_idx")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).
+		WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	reverted, err := migrator.Down(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Version(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(mock sqlmock.Sqlmock)
+		want         int64
+	}{
+		{
+			name: "applied",
+			expectations: func(mock sqlmock.Sqlmock) {
+				expectVersion(mock, 1)
+			},
+			want: 1,
+		},
+		{
+			name: "no schema_migrations table",
+			expectations: func(mock sqlmock.Sqlmock) {
+				expectExists(mock, false)
+			},
+			want: 0,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			migrator, mock := newMockMigrator(t)
+			tc.expectations(mock)
+
+			current, err := migrator.Version(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, current)
+			assert.Equal(t, int64(2), migrator.Latest())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int64
+		err      error
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"sql/0010_b.up.sql":   {Data: []byte("up b")},
+				"sql/0010_b.down.sql": {Data: []byte("down b")},
+				"sql/0002_a.up.sql":   {Data: []byte("up a")},
+				"sql/0002_a.down.sql": {Data: []byte("down a")},
+			},
+			versions: []int64{2, 10},
+		},
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{
+				"sql/0001_a.up.sql": {Data: []byte("up a")},
+			},
+			err: errors.New("migration 1_a must have both up and down files"),
+		},
+		{
+			name: "invalid name",
+			fsys: fstest.MapFS{
+				"sql/create.sql": {Data: []byte("up a")},
+			},
+			err: errors.New("invalid migration file name \"create.sql\""),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := load(tc.fsys)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			versions := make([]int64, 0)
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+			assert.Equal(t, tc.versions, versions)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+}
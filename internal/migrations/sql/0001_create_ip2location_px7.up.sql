@@ -13,4 +13,4 @@ CREATE TABLE  IF NOT EXISTS ip2location_px7
     asn          character varying(10)  NOT NULL,
     "as"         character varying(256) NOT NULL,
     CONSTRAINT ip2location_db1_pkey PRIMARY KEY (ip_from, ip_to)
-);
\ No newline at end of file
+);
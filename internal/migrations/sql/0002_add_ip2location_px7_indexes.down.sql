@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ip2location_px7_country_isp_idx;
+DROP INDEX IF EXISTS ip2location_px7_country_range_idx;
+DROP INDEX IF EXISTS ip2location_px7_ip_to_idx;
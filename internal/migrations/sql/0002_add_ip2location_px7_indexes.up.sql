@@ -0,0 +1,11 @@
+-- Get: WHERE ip_to >= $1 ORDER BY ip_to LIMIT 1
+CREATE INDEX IF NOT EXISTS ip2location_px7_ip_to_idx
+    ON ip2location_px7 (ip_to);
+
+-- List and GetIPQuantityByCountry: WHERE country_name = $1, window ordered by (ip_to, ip_from)
+CREATE INDEX IF NOT EXISTS ip2location_px7_country_range_idx
+    ON ip2location_px7 (country_name, ip_to, ip_from) INCLUDE (city_name);
+
+-- GetTop10ISPByCountry: WHERE country_name = $1 GROUP BY isp
+CREATE INDEX IF NOT EXISTS ip2location_px7_country_isp_idx
+    ON ip2location_px7 (country_name, isp) INCLUDE (ip_from, ip_to);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id         bigserial                NOT NULL,
+    name       character varying(128)   NOT NULL,
+    prefix     character varying(16)    NOT NULL,
+    hash       character(64)            NOT NULL,
+    scopes     text[]                   NOT NULL DEFAULT '{}',
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    rotated_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    CONSTRAINT api_keys_pkey PRIMARY KEY (id),
+    CONSTRAINT api_keys_hash_key UNIQUE (hash)
+);
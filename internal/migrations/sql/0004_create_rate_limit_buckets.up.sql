@@ -0,0 +1,10 @@
+-- Buckets refill by themselves, losing them on a crash only resets the limits,
+-- so the table skips the WAL.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets
+(
+    key        character varying(128)   NOT NULL,
+    tokens     double precision         NOT NULL,
+    allowed    boolean                  NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT rate_limit_buckets_pkey PRIMARY KEY (key)
+);
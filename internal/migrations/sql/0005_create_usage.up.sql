@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS usage_daily
+(
+    client_id character varying(128) NOT NULL,
+    day       date                   NOT NULL,
+    endpoint  character varying(128) NOT NULL,
+    requests  bigint                 NOT NULL DEFAULT 0,
+    addresses bigint                 NOT NULL DEFAULT 0,
+    "rows"    bigint                 NOT NULL DEFAULT 0,
+    CONSTRAINT usage_daily_pkey PRIMARY KEY (client_id, day, endpoint)
+);
+
+-- Monthly quota overrides, clients without a row get the configured default.
+-- A NULL limit means unlimited.
+CREATE TABLE IF NOT EXISTS usage_quotas
+(
+    client_id character varying(128) NOT NULL,
+    requests  bigint,
+    "rows"    bigint,
+    CONSTRAINT usage_quotas_pkey PRIMARY KEY (client_id)
+);
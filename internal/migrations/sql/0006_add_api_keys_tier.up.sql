@@ -0,0 +1 @@
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tier character varying(32) NOT NULL DEFAULT '';
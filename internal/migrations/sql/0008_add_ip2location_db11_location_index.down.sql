@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ip2location_db11_location_idx;
@@ -0,0 +1,6 @@
+-- Near: earth_box(ll_to_earth($1, $2), $3) @> ll_to_earth(latitude, longitude)
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+CREATE INDEX IF NOT EXISTS ip2location_db11_location_idx
+    ON ip2location_db11 USING gist (ll_to_earth(latitude, longitude));
@@ -0,0 +1,3 @@
+-- GetByDomain: WHERE reverse('.' || domain) LIKE $1, the domain and its subdomains
+CREATE INDEX IF NOT EXISTS ip2location_px7_domain_idx
+    ON ip2location_px7 (reverse('.' || domain) text_pattern_ops);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ip2location_px7_isp_trgm_idx;
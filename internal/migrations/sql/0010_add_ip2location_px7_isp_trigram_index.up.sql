@@ -0,0 +1,5 @@
+-- SearchISPs: WHERE $1 <% isp, word similarity over trigrams
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS ip2location_px7_isp_trgm_idx
+    ON ip2location_px7 USING gin (isp gin_trgm_ops);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ip2location_px7_country_region_city_idx;
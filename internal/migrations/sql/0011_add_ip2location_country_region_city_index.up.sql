@@ -0,0 +1,3 @@
+-- ListRegions, ListCities and GetCity: WHERE country_code = $1 AND region_name = $2 AND city_name = $3 ORDER BY ip_from
+CREATE INDEX IF NOT EXISTS ip2location_px7_country_region_city_idx
+    ON ip2location_px7 (country_code, region_name, city_name, ip_from) INCLUDE (ip_to);
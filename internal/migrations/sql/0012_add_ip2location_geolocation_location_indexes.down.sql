@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS ip2location_db5_location_idx;
+DROP INDEX IF EXISTS ip2location_db6_location_idx;
+DROP INDEX IF EXISTS ip2location_db7_location_idx;
+DROP INDEX IF EXISTS ip2location_db8_location_idx;
+DROP INDEX IF EXISTS ip2location_db9_location_idx;
+DROP INDEX IF EXISTS ip2location_db10_location_idx;
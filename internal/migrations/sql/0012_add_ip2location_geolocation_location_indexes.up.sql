@@ -0,0 +1,20 @@
+-- Near: the index 0008 creates for ip2location_db11, on the DB5 to DB10 tables
+-- already loaded. Tables loaded later or read through GEOLOCATION_TABLE need it
+-- created by hand.
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+DO $$
+DECLARE
+    name text;
+BEGIN
+    FOREACH name IN ARRAY ARRAY ['ip2location_db5', 'ip2location_db6', 'ip2location_db7', 'ip2location_db8',
+        'ip2location_db9', 'ip2location_db10']
+        LOOP
+            IF to_regclass(name) IS NOT NULL THEN
+                EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I USING gist (ll_to_earth(latitude, longitude))',
+                               name || '_location_idx', name);
+            END IF;
+        END LOOP;
+END
+$$;
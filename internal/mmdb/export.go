@@ -0,0 +1,82 @@
+// Package mmdb writes the IP2Location dataset as a MaxMind DB file, the
+// format read by nginx geoip2, Envoy and Logstash among others, and serves
+// lookups from those files when Postgres is not available.
+package mmdb
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/maxmind/mmdbwriter"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"net"
+)
+
+// databaseTypePrefix is followed by the product in the database type stored
+// in the metadata of the exported files, e.g. IP2Location-PX7.
+const databaseTypePrefix = "IP2Location-"
+
+var tracer = tracing.Tracer("github.com/mborroni/dreamlab-challenge/internal/mmdb")
+
+type source interface {
+	Walk(context.Context, func(*ips.IP) error) error
+	Product() *ips.Product
+}
+
+type Exporter struct {
+	source source
+}
+
+func NewExporter(source source) *Exporter {
+	return &Exporter{
+		source: source,
+	}
+}
+
+// Export writes every range of the source to w and returns how many were
+// written. The tree is built in memory before anything is written, so w is
+// left untouched when the source fails.
+func (e *Exporter) Export(ctx context.Context, w io.Writer) (written int, err error) {
+	ctx, span := tracer.Start(ctx, "Exporter.Export")
+	defer func() { tracing.End(span, err) }()
+
+	product := e.source.Product()
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType: databaseTypePrefix + product.Name,
+		Description:  map[string]string{language: fmt.Sprintf("IP2Location %s ranges exported from %s", product.Name, product.Table)},
+		Languages:    []string{language},
+		// IP2Location ranges cover private and reserved networks too.
+		IncludeReservedNetworks: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = e.source.Walk(ctx, func(ip *ips.IP) error {
+		record := toRecord(ip)
+		if len(record) == 0 {
+			return nil
+		}
+		if err := tree.InsertRange(toNetIP(ip.From), toNetIP(ip.To), record); err != nil {
+			return fmt.Errorf("range %d-%d: %w", ip.From, ip.To, err)
+		}
+		written++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	span.SetAttributes(attribute.Int("ranges", written))
+	if _, err := tree.WriteTo(w); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+func toNetIP(decimal int64) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, uint32(decimal))
+	return ip
+}
@@ -0,0 +1,96 @@
+package mmdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+type fakeSource struct {
+	ranges  []*ips.IP
+	product string
+	err     error
+}
+
+func (s *fakeSource) Product() *ips.Product {
+	if s.product == "" {
+		return ips.MustParseProduct("PX7")
+	}
+	return ips.MustParseProduct(s.product)
+}
+
+func (s *fakeSource) Walk(_ context.Context, fn func(*ips.IP) error) error {
+	for _, ip := range s.ranges {
+		if err := fn(ip); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func TestExporter_Export(t *testing.T) {
+	source := &fakeSource{ranges: []*ips.IP{
+		{From: 150178520, To: 150178530, ProxyType: "PUB", ISP: "CTL LATAM", Domain: "centurylink.com",
+			Usage: "ISP", ASN: 3356, AS: "Level 3 Parent LLC",
+			Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Ciudad Autonoma de Buenos Aires", City: "Buenos Aires"}},
+		{From: 167772160, To: 184549375, ProxyType: "-", ISP: "-", Domain: "-", Usage: "-", AS: "-",
+			Country: ips.Country{Code: "-", Name: "-", Region: "-", City: "-"}},
+		{From: 3232235776, To: 3232236031, ProxyType: "VPN", Country: ips.Country{Code: "US"}},
+	}}
+	output := &bytes.Buffer{}
+
+	written, err := NewExporter(source).Export(context.Background(), output)
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+
+	reader, err := maxminddb.FromBytes(output.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "IP2Location-PX7", reader.Metadata.DatabaseType)
+
+	record := &Record{}
+	network, ok, err := reader.LookupNetwork(net.ParseIP("8.243.138.220"), record)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "8.243.138.216/29", network.String())
+	assert.Equal(t, "AR", record.Country.ISOCode)
+	assert.Equal(t, "Argentina", record.Country.Names["en"])
+	assert.Equal(t, "Ciudad Autonoma de Buenos Aires", record.Subdivisions[0].Names["en"])
+	assert.Equal(t, "Buenos Aires", record.City.Names["en"])
+	assert.Equal(t, "CTL LATAM", record.ISP)
+	assert.Equal(t, "centurylink.com", record.Domain)
+	assert.Equal(t, "ISP", record.UsageType)
+	assert.Equal(t, "PUB", record.ProxyType)
+	assert.Equal(t, uint32(3356), record.AutonomousSystemNumber)
+	assert.Equal(t, "Level 3 Parent LLC", record.AutonomousSystemOrganization)
+
+	record = &Record{}
+	_, ok, err = reader.LookupNetwork(net.ParseIP("192.168.1.10"), record)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "US", record.Country.ISOCode)
+	assert.Equal(t, "VPN", record.ProxyType)
+	assert.Empty(t, record.Subdivisions)
+
+	_, ok, err = reader.LookupNetwork(net.ParseIP("10.1.1.1"), &Record{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestExporter_Export_SourceError(t *testing.T) {
+	source := &fakeSource{
+		ranges: []*ips.IP{{From: 1, To: 2, Country: ips.Country{Code: "AU"}}},
+		err:    errors.New("connection reset"),
+	}
+	output := &bytes.Buffer{}
+
+	written, err := NewExporter(source).Export(context.Background(), output)
+	assert.EqualError(t, err, "connection reset")
+	assert.Zero(t, written)
+	assert.Zero(t, output.Len())
+}
@@ -0,0 +1,171 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"net"
+	"reflect"
+)
+
+// Record is the data stored for every range. It follows the layout of the
+// GeoIP2 City and ISP databases so existing consumers (nginx geoip2, Envoy,
+// Logstash) read it with their usual paths:
+//
+//	country.iso_code                  "AR"
+//	country.names.en                  "Argentina"
+//	subdivisions[0].names.en          "Ciudad Autonoma de Buenos Aires"
+//	city.names.en                     "Buenos Aires"
+//	isp                               "CTL LATAM"
+//	domain                            "centurylink.com"
+//	usage_type                        "ISP"
+//	proxy_type                        "PUB"
+//	autonomous_system_number          3356
+//	autonomous_system_organization    "Level 3 Parent LLC"
+//	location.latitude                 -34.6131
+//	location.longitude                -58.3772
+//	location.time_zone                "-03:00"
+//	postal.code                       "1871"
+//	last_seen                         3
+//	threat                            "SPAM"
+//	provider                          "NordVPN"
+//
+// Only the fields of the exported product are written, the ones without
+// data ("-" or empty) are left out.
+type Record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ISP                          string `maxminddb:"isp"`
+	Domain                       string `maxminddb:"domain"`
+	UsageType                    string `maxminddb:"usage_type"`
+	ProxyType                    string `maxminddb:"proxy_type"`
+	AutonomousSystemNumber       uint32 `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+	Location                     struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+		TimeZone  string   `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+	LastSeen *int   `maxminddb:"last_seen"`
+	Threat   string `maxminddb:"threat"`
+	Provider string `maxminddb:"provider"`
+}
+
+// language is the only locale of the names, IP2Location publishes them in
+// English.
+const language = "en"
+
+// toRecord encodes ip with the Record layout, an empty map means the range
+// holds no data at all.
+func toRecord(ip *ips.IP) mmdbtype.Map {
+	record := mmdbtype.Map{}
+	country := mmdbtype.Map{}
+	if present(ip.Country.Code) {
+		country["iso_code"] = mmdbtype.String(ip.Country.Code)
+	}
+	if present(ip.Country.Name) {
+		country["names"] = names(ip.Country.Name)
+	}
+	if len(country) > 0 {
+		record["country"] = country
+	}
+	if present(ip.Country.Region) {
+		record["subdivisions"] = mmdbtype.Slice{mmdbtype.Map{"names": names(ip.Country.Region)}}
+	}
+	if present(ip.Country.City) {
+		record["city"] = mmdbtype.Map{"names": names(ip.Country.City)}
+	}
+	strings := map[mmdbtype.String]string{
+		"isp":                            ip.ISP,
+		"domain":                         ip.Domain,
+		"usage_type":                     ip.Usage,
+		"proxy_type":                     ip.ProxyType,
+		"autonomous_system_organization": ip.AS,
+		"threat":                         ip.Threat,
+		"provider":                       ip.Provider,
+	}
+	for key, value := range strings {
+		if present(value) {
+			record[key] = mmdbtype.String(value)
+		}
+	}
+	if ip.ASN > 0 {
+		record["autonomous_system_number"] = mmdbtype.Uint32(ip.ASN)
+	}
+	if ip.LastSeen != nil {
+		record["last_seen"] = mmdbtype.Uint32(*ip.LastSeen)
+	}
+	location := mmdbtype.Map{}
+	if ip.Latitude != nil && ip.Longitude != nil {
+		location["latitude"] = mmdbtype.Float64(*ip.Latitude)
+		location["longitude"] = mmdbtype.Float64(*ip.Longitude)
+	}
+	if present(ip.TimeZone) {
+		location["time_zone"] = mmdbtype.String(ip.TimeZone)
+	}
+	if len(location) > 0 {
+		record["location"] = location
+	}
+	if present(ip.ZipCode) {
+		record["postal"] = mmdbtype.Map{"code": mmdbtype.String(ip.ZipCode)}
+	}
+	return record
+}
+
+func names(name string) mmdbtype.Map {
+	return mmdbtype.Map{language: mmdbtype.String(name)}
+}
+
+func present(value string) bool {
+	return value != "" && value != "-"
+}
+
+// toIP decodes record as the range spanning network.
+func toIP(network *net.IPNet, record *Record) *ips.IP {
+	from := int64(binary.BigEndian.Uint32(network.IP.To4()))
+	ones, bits := network.Mask.Size()
+	ip := &ips.IP{
+		From:      from,
+		To:        from + int64(1)<<(bits-ones) - 1,
+		ProxyType: record.ProxyType,
+		Country: ips.Country{
+			Code: record.Country.ISOCode,
+			Name: record.Country.Names[language],
+			City: record.City.Names[language],
+		},
+		ISP:       record.ISP,
+		Domain:    record.Domain,
+		Usage:     record.UsageType,
+		ASN:       int(record.AutonomousSystemNumber),
+		AS:        record.AutonomousSystemOrganization,
+		LastSeen:  record.LastSeen,
+		Threat:    record.Threat,
+		Provider:  record.Provider,
+		Latitude:  record.Location.Latitude,
+		Longitude: record.Location.Longitude,
+		ZipCode:   record.Postal.Code,
+		TimeZone:  record.Location.TimeZone,
+	}
+	if len(record.Subdivisions) > 0 {
+		ip.Country.Region = record.Subdivisions[0].Names[language]
+	}
+	return ip
+}
+
+// sameData compares every field of a and b but their bounds.
+func sameData(a *ips.IP, b *ips.IP) bool {
+	x, y := *a, *b
+	x.From, x.To, y.From, y.To = 0, 0, 0, 0
+	return reflect.DeepEqual(x, y)
+}
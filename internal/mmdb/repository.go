@@ -0,0 +1,125 @@
+package mmdb
+
+import (
+	"context"
+	"database/sql"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/mborroni/dreamlab-challenge/internal/tracing"
+	"github.com/oschwald/maxminddb-golang"
+	"net"
+	"strings"
+)
+
+// allIPv4 makes the iteration skip the IPv6 aliases of the IPv4 ranges.
+var allIPv4 = &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+
+// Repository answers the ips repository methods from a MaxMind DB file with
+// the Record layout, such as the ones written by Exporter. The file is
+// memory-mapped and must not be replaced in place while open.
+//
+// Lookups read the tree directly, listing and aggregates walk every range.
+type Repository struct {
+	*ips.Scanner
+	reader  *maxminddb.Reader
+	product *ips.Product
+}
+
+func OpenRepository(path string) (*Repository, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// Files from other sources have no product, their layout is closest to
+	// the one of PX7.
+	product, err := ips.ParseProduct(strings.TrimPrefix(reader.Metadata.DatabaseType, databaseTypePrefix))
+	if err != nil {
+		product = ips.MustParseProduct("PX7")
+	}
+	repository := &Repository{
+		reader:  reader,
+		product: product,
+	}
+	repository.Scanner = ips.NewScanner(repository)
+	return repository, nil
+}
+
+// Product is the one the file was exported from.
+func (r *Repository) Product() *ips.Product {
+	return r.product
+}
+
+func (r *Repository) Close() error {
+	return r.reader.Close()
+}
+
+// Get follows the DBRepository contract, it fails with sql.ErrNoRows for
+// addresses missing from the file. From and To are the bounds of the
+// network holding the address, a part of the original range.
+func (r *Repository) Get(ctx context.Context, decimalIP int64) (ip *ips.IP, err error) {
+	_, span := tracer.Start(ctx, "Repository.Get")
+	defer func() { tracing.End(span, err) }()
+
+	record := &Record{}
+	network, ok, err := r.reader.LookupNetwork(toNetIP(decimalIP), record)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return toIP(network, record), nil
+}
+
+func (r *Repository) GetMany(ctx context.Context, decimalIPs []int64) (_ map[int64]*ips.IP, err error) {
+	ctx, span := tracer.Start(ctx, "Repository.GetMany")
+	defer func() { tracing.End(span, err) }()
+
+	found := make(map[int64]*ips.IP)
+	for _, decimal := range decimalIPs {
+		ip, err := r.Get(ctx, decimal)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found[decimal] = ip
+	}
+	return found, nil
+}
+
+// Walk calls fn with every range of the file in ascending order. Adjacent
+// networks with the same data are merged back into the range they were split
+// from when written.
+func (r *Repository) Walk(ctx context.Context, fn func(*ips.IP) error) error {
+	networks := r.reader.NetworksWithin(allIPv4, maxminddb.SkipAliasedNetworks)
+	var current *ips.IP
+	for networks.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record := &Record{}
+		network, err := networks.Network(record)
+		if err != nil {
+			return err
+		}
+		ip := toIP(network, record)
+		if current != nil && current.To+1 == ip.From && sameData(current, ip) {
+			current.To = ip.To
+			continue
+		}
+		if current != nil {
+			if err := fn(current); err != nil {
+				return err
+			}
+		}
+		current = ip
+	}
+	if err := networks.Err(); err != nil {
+		return err
+	}
+	if current != nil {
+		return fn(current)
+	}
+	return nil
+}
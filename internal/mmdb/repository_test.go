@@ -0,0 +1,126 @@
+package mmdb
+
+import (
+	"context"
+	"database/sql"
+	ips "github.com/mborroni/dreamlab-challenge/internal/ipAddresses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testRanges = []*ips.IP{
+	{From: 16777216, To: 16777471, ProxyType: "DCH", ISP: "APNIC", ASN: 13335, AS: "Cloudflare",
+		Country: ips.Country{Code: "AU", Name: "Australia", Region: "Queensland", City: "Brisbane"}},
+	{From: 150178520, To: 150178530, ProxyType: "PUB", ISP: "CTL LATAM", Domain: "centurylink.com", Usage: "ISP",
+		ASN: 3356, AS: "Level 3 Parent LLC",
+		Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"}},
+	{From: 150178531, To: 150178532, ProxyType: "PUB", ISP: "Telecom",
+		Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"}},
+	{From: 150178600, To: 150178699, ProxyType: "VPN", ISP: "Telecom",
+		Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Cordoba", City: "Cordoba"}},
+}
+
+func openTestRepository(t *testing.T) *Repository {
+	return openRepository(t, &fakeSource{ranges: testRanges})
+}
+
+func openRepository(t *testing.T, source *fakeSource) *Repository {
+	path := filepath.Join(t.TempDir(), "export.mmdb")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = NewExporter(source).Export(context.Background(), file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	repository, err := OpenRepository(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { repository.Close() })
+	return repository
+}
+
+func TestRepository_Get(t *testing.T) {
+	repository := openTestRepository(t)
+	ctx := context.Background()
+
+	ip, err := repository.Get(ctx, 150178522)
+	require.NoError(t, err)
+	assert.Equal(t, &ips.IP{From: 150178520, To: 150178527, ProxyType: "PUB", ISP: "CTL LATAM",
+		Domain: "centurylink.com", Usage: "ISP", ASN: 3356, AS: "Level 3 Parent LLC",
+		Country: ips.Country{Code: "AR", Name: "Argentina", Region: "Buenos Aires", City: "Buenos Aires"}}, ip)
+
+	_, err = repository.Get(ctx, 134744072)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	found, err := repository.GetMany(ctx, []int64{16777217, 134744072})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Brisbane", found[16777217].Country.City)
+}
+
+func TestRepository_Walk(t *testing.T) {
+	repository := openTestRepository(t)
+
+	walked := make([]*ips.IP, 0)
+	err := repository.Walk(context.Background(), func(ip *ips.IP) error {
+		walked = append(walked, ip)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testRanges, walked)
+}
+
+func TestRepository_List(t *testing.T) {
+	repository := openTestRepository(t)
+
+	list, err := repository.List(context.Background(), 13, map[string]interface{}{"country": "Argentina"})
+	require.NoError(t, err)
+	assert.Equal(t, []*ips.IP{
+		{From: 150178520, To: 150178530, Country: ips.Country{Name: "Argentina", City: "Buenos Aires"}},
+		{From: 150178531, To: 150178532, Country: ips.Country{Name: "Argentina", City: "Cordoba"}},
+	}, list)
+}
+
+func TestRepository_Aggregates(t *testing.T) {
+	repository := openTestRepository(t)
+	ctx := context.Background()
+
+	quantity, err := repository.GetIPQuantityByCountry(ctx, "Argentina")
+	require.NoError(t, err)
+	assert.Equal(t, 113, quantity)
+
+	quantity, err = repository.GetIPQuantityByCountry(ctx, "Chile")
+	require.NoError(t, err)
+	assert.Zero(t, quantity)
+
+	isps, err := repository.GetTop10ISPByCountry(ctx, "Argentina")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Telecom", "CTL LATAM"}, isps)
+}
+
+func TestRepository_Location(t *testing.T) {
+	latitude, longitude := -34.6131, -58.3772
+	repository := openRepository(t, &fakeSource{product: "DB11", ranges: []*ips.IP{
+		{From: 150178520, To: 150178530, Latitude: &latitude, Longitude: &longitude, ZipCode: "1871",
+			TimeZone: "-03:00", Country: ips.Country{Code: "AR", Name: "Argentina", City: "Buenos Aires"}},
+	}})
+	assert.Equal(t, "DB11", repository.Product().Name)
+
+	walked := 0
+	require.NoError(t, repository.Walk(context.Background(), func(ip *ips.IP) error {
+		walked++
+		assert.Equal(t, int64(150178530), ip.To)
+		return nil
+	}))
+	assert.Equal(t, 1, walked)
+
+	ip, err := repository.Get(context.Background(), 150178522)
+	require.NoError(t, err)
+	assert.Equal(t, -34.6131, *ip.Latitude)
+	assert.Equal(t, -58.3772, *ip.Longitude)
+	assert.Equal(t, "1871", ip.ZipCode)
+	assert.Equal(t, "-03:00", ip.TimeZone)
+	assert.Nil(t, ip.LastSeen)
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+//go:generate mockgen -source=ratelimit.go -destination=ratelimit_mock.go -package=ratelimit
+
+// Limit configures a token bucket: it holds up to Burst tokens and refills
+// Rate tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result describes the bucket after a request tried to take tokens from it.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+type store interface {
+	// Take refills the bucket of key, then removes cost tokens if it holds
+	// enough, returning the tokens left and whether they were removed.
+	Take(ctx context.Context, key string, cost int, limit Limit) (float64, bool, error)
+}
+
+type Limiter struct {
+	store store
+	limit Limit
+}
+
+func NewLimiter(store store, limit Limit) *Limiter {
+	return &Limiter{
+		store: store,
+		limit: limit,
+	}
+}
+
+func (l *Limiter) Allow(ctx context.Context, key string, cost int) (*Result, error) {
+	tokens, allowed, err := l.store.Take(ctx, key, cost, l.limit)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Allowed:    allowed,
+		Limit:      l.limit.Burst,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: l.wait(float64(l.limit.Burst) - tokens),
+	}
+	if !allowed {
+		result.RetryAfter = l.wait(float64(cost) - tokens)
+	}
+	return result, nil
+}
+
+// wait returns how long the bucket takes to refill missing tokens.
+func (l *Limiter) wait(missing float64) time.Duration {
+	if missing <= 0 || l.limit.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(missing / l.limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type fields struct {
+		cost int
+	}
+
+	type want struct {
+		result *Result
+		err    error
+	}
+
+	tests := []struct {
+		name         string
+		fields       fields
+		expectations func(store *Mockstore, fields fields)
+		want         want
+	}{
+		{name: "allowed",
+			fields: fields{cost: 1},
+			expectations: func(store *Mockstore, fields fields) {
+				store.EXPECT().Take(gomock.Any(), "key:1", fields.cost, gomock.Any()).Return(7.5, true, nil)
+			},
+			want: want{
+				result: &Result{Allowed: true, Limit: 10, Remaining: 7, ResetAfter: 1250 * time.Millisecond},
+			},
+		},
+		{name: "limited",
+			fields: fields{cost: 5},
+			expectations: func(store *Mockstore, fields fields) {
+				store.EXPECT().Take(gomock.Any(), "key:1", fields.cost, gomock.Any()).Return(1.0, false, nil)
+			},
+			want: want{
+				result: &Result{Allowed: false, Limit: 10, Remaining: 1, ResetAfter: 4500 * time.Millisecond,
+					RetryAfter: 2 * time.Second},
+			},
+		},
+		{name: "error",
+			fields: fields{cost: 1},
+			expectations: func(store *Mockstore, fields fields) {
+				store.EXPECT().Take(gomock.Any(), "key:1", fields.cost, gomock.Any()).Return(0.0, false, sql.ErrConnDone)
+			},
+			want: want{err: sql.ErrConnDone},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewMockstore(ctrl)
+			tc.expectations(store, tc.fields)
+			limiter := NewLimiter(store, Limit{Rate: 2, Burst: 10})
+
+			result, err := limiter.Allow(context.Background(), "key:1", tc.fields.cost)
+
+			assert.Equal(t, tc.want.err, err)
+			assert.Equal(t, tc.want.result, result)
+		})
+	}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+
+	steps := []struct {
+		advance time.Duration
+		cost    int
+		tokens  float64
+		allowed bool
+	}{
+		{advance: 0, cost: 2, tokens: 1, allowed: true},
+		{advance: 0, cost: 2, tokens: 1, allowed: false},
+		{advance: time.Second, cost: 2, tokens: 0, allowed: true},
+		{advance: time.Hour, cost: 1, tokens: 2, allowed: true},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		tokens, allowed, err := store.Take(context.Background(), "key:1", step.cost, limit)
+
+		assert.NoError(t, err)
+		assert.Equal(t, step.tokens, tokens)
+		assert.Equal(t, step.allowed, allowed)
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+	limit := Limit{Rate: 1, Burst: 3}
+
+	_, _, _ = store.Take(context.Background(), "key:1", 1, limit)
+	now = now.Add(2 * sweepInterval)
+	_, _, _ = store.Take(context.Background(), "key:2", 1, limit)
+
+	assert.Len(t, store.buckets, 1)
+	assert.Contains(t, store.buckets, "key:2")
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+)
+
+const (
+	// refilled is the bucket content once the elapsed time since its last
+	// update has been added, computed with the database clock so replicas
+	// agree on it.
+	refilled = "LEAST($2::double precision, b.tokens + " +
+		"EXTRACT(EPOCH FROM (now() - b.updated_at)) * $4::double precision)"
+
+	takeQuery = "INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at) " +
+		"VALUES ($1, CASE WHEN $2::double precision >= $3::double precision " +
+		"THEN $2::double precision - $3::double precision ELSE $2::double precision END, " +
+		"$2::double precision >= $3::double precision, now()) " +
+		"ON CONFLICT (key) DO UPDATE SET " +
+		"tokens = CASE WHEN " + refilled + " >= $3::double precision " +
+		"THEN " + refilled + " - $3::double precision ELSE " + refilled + " END, " +
+		"allowed = " + refilled + " >= $3::double precision, " +
+		"updated_at = now() " +
+		"RETURNING tokens, allowed"
+)
+
+// DBStore shares buckets between replicas through Postgres. Every request is
+// a single upsert, so concurrent requests for a key serialize on its row.
+type DBStore struct {
+	db *sql.DB
+}
+
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{
+		db: db,
+	}
+}
+
+func (s *DBStore) Take(ctx context.Context, key string, cost int, limit Limit) (float64, bool, error) {
+	var tokens float64
+	var allowed bool
+	row := s.db.QueryRowContext(ctx, takeQuery, key, float64(limit.Burst), float64(cost), limit.Rate)
+	if err := row.Scan(&tokens, &allowed); err != nil {
+		return 0, false, err
+	}
+	return tokens, allowed, nil
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestDBStore_Take(t *testing.T) {
+	type want struct {
+		tokens  float64
+		allowed bool
+		err     error
+	}
+
+	tests := []struct {
+		name         string
+		expectations func(mock sqlmock.Sqlmock)
+		want         want
+	}{
+		{name: "allowed",
+			expectations: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(takeQuery)).
+					WithArgs("key:1", float64(10), float64(5), float64(2)).
+					WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(3.5, true))
+			},
+			want: want{tokens: 3.5, allowed: true},
+		},
+		{name: "error",
+			expectations: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(takeQuery)).
+					WithArgs("key:1", float64(10), float64(5), float64(2)).
+					WillReturnError(sql.ErrConnDone)
+			},
+			want: want{err: sql.ErrConnDone},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			tc.expectations(mock)
+			store := NewDBStore(db)
+
+			tokens, allowed, err := store.Take(context.Background(), "key:1", 5, Limit{Rate: 2, Burst: 10})
+
+			assert.Equal(t, tc.want.err, err)
+			assert.Equal(t, tc.want.tokens, tokens)
+			assert.Equal(t, tc.want.allowed, allowed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
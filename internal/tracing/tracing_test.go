@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{name: "disabled", exporter: "none"},
+		{name: "stdout", exporter: "stdout"},
+		{name: "unknown", exporter: "zipkin", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), Config{Exporter: tc.exporter})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, shutdown(context.Background()))
+		})
+	}
+}
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{name: "ok", err: nil, want: codes.Unset},
+		{name: "no rows", err: sql.ErrNoRows, want: codes.Unset},
+		{name: "error", err: sql.ErrConnDone, want: codes.Error},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			_, span := provider.Tracer("test").Start(context.Background(), "span")
+
+			End(span, tc.err)
+
+			assert.Len(t, recorder.Ended(), 1)
+			assert.Equal(t, tc.want, recorder.Ended()[0].Status().Code)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package usage
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type Consumption struct {
+	Requests  int64
+	Addresses int64
+	Rows      int64
+}
+
+type Entry struct {
+	Day      time.Time
+	Endpoint string
+	Consumption
+}
+
+// Quota limits the monthly consumption of a client, 0 means unlimited.
+type Quota struct {
+	Requests int64
+	Rows     int64
+}
+
+type QuotaStatus struct {
+	Quota    Quota
+	Used     Consumption
+	ResetsAt time.Time
+}
+
+func (s *QuotaStatus) Exceeded() bool {
+	return (s.Quota.Requests > 0 && s.Used.Requests >= s.Quota.Requests) ||
+		(s.Quota.Rows > 0 && s.Used.Rows >= s.Quota.Rows)
+}
+
+type Report struct {
+	ClientID string
+	From     time.Time
+	To       time.Time
+	Total    Consumption
+	Entries  []*Entry
+}
+
+// counter accumulates what a request consumed while it is served.
+type counter struct {
+	addresses int64
+	rows      int64
+}
+
+type counterKey struct{}
+
+// WithCounter prepares ctx for handlers to report what they served.
+func WithCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, counterKey{}, &counter{})
+}
+
+// AddAddresses counts addresses looked up by the request in ctx.
+func AddAddresses(ctx context.Context, n int) {
+	if c, ok := ctx.Value(counterKey{}).(*counter); ok {
+		atomic.AddInt64(&c.addresses, int64(n))
+	}
+}
+
+// AddRows counts rows exported by the request in ctx.
+func AddRows(ctx context.Context, n int) {
+	if c, ok := ctx.Value(counterKey{}).(*counter); ok {
+		atomic.AddInt64(&c.rows, int64(n))
+	}
+}
+
+// Counted returns the consumption of a single request reported in ctx.
+func Counted(ctx context.Context) Consumption {
+	consumption := Consumption{Requests: 1}
+	if c, ok := ctx.Value(counterKey{}).(*counter); ok {
+		consumption.Addresses = atomic.LoadInt64(&c.addresses)
+		consumption.Rows = atomic.LoadInt64(&c.rows)
+	}
+	return consumption
+}
@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository keeps consumption in the process, for deployments without
+// Postgres. Each replica counts its own requests and counts start over when
+// it restarts. There are no quota overrides, every client gets the default.
+type MemoryRepository struct {
+	mu      sync.Mutex
+	entries map[string][]*Entry
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		entries: make(map[string][]*Entry),
+	}
+}
+
+func (r *MemoryRepository) Record(ctx context.Context, clientID string, day time.Time, endpoint string, consumption Consumption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range r.entries[clientID] {
+		if entry.Day.Equal(day) && entry.Endpoint == endpoint {
+			entry.Requests += consumption.Requests
+			entry.Addresses += consumption.Addresses
+			entry.Rows += consumption.Rows
+			return nil
+		}
+	}
+	r.entries[clientID] = append(r.entries[clientID], &Entry{Day: day, Endpoint: endpoint, Consumption: consumption})
+	return nil
+}
+
+func (r *MemoryRepository) Total(ctx context.Context, clientID string, from time.Time, to time.Time) (*Consumption, error) {
+	entries, err := r.List(ctx, clientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	total := &Consumption{}
+	for _, entry := range entries {
+		total.Requests += entry.Requests
+		total.Addresses += entry.Addresses
+		total.Rows += entry.Rows
+	}
+	return total, nil
+}
+
+func (r *MemoryRepository) List(ctx context.Context, clientID string, from time.Time, to time.Time) ([]*Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*Entry, 0)
+	for _, entry := range r.entries[clientID] {
+		if entry.Day.Before(from) || entry.Day.After(to) {
+			continue
+		}
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Day.Equal(entries[j].Day) {
+			return entries[i].Day.Before(entries[j].Day)
+		}
+		return entries[i].Endpoint < entries[j].Endpoint
+	})
+	return entries, nil
+}
+
+// GetQuota always returns sql.ErrNoRows, quotas can't be overridden.
+func (r *MemoryRepository) GetQuota(ctx context.Context, clientID string) (*Quota, error) {
+	return nil, sql.ErrNoRows
+}
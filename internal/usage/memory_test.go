@@ -0,0 +1,34 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	r := NewMemoryRepository()
+	ctx := context.Background()
+	lastMonth := firstOfMonth.AddDate(0, 0, -1)
+
+	assert.NoError(t, r.Record(ctx, "key:1", firstOfMonth, "GET /v1/ips/", Consumption{Requests: 1, Rows: 50}))
+	assert.NoError(t, r.Record(ctx, "key:1", firstOfMonth, "GET /v1/ips/", Consumption{Requests: 1, Rows: 20}))
+	assert.NoError(t, r.Record(ctx, "key:1", firstOfMonth, "GET /v1/ips/{IP}/", Consumption{Requests: 1, Addresses: 1}))
+	assert.NoError(t, r.Record(ctx, "key:1", lastMonth, "GET /v1/ips/", Consumption{Requests: 1, Rows: 100}))
+	assert.NoError(t, r.Record(ctx, "key:2", firstOfMonth, "GET /v1/ips/", Consumption{Requests: 1, Rows: 10}))
+
+	total, err := r.Total(ctx, "key:1", firstOfMonth, lastOfMonth)
+	assert.NoError(t, err)
+	assert.Equal(t, &Consumption{Requests: 3, Addresses: 1, Rows: 70}, total)
+
+	entries, err := r.List(ctx, "key:1", firstOfMonth, lastOfMonth)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Entry{
+		{Day: firstOfMonth, Endpoint: "GET /v1/ips/", Consumption: Consumption{Requests: 2, Rows: 70}},
+		{Day: firstOfMonth, Endpoint: "GET /v1/ips/{IP}/", Consumption: Consumption{Requests: 1, Addresses: 1}},
+	}, entries)
+
+	_, err = r.GetQuota(ctx, "key:1")
+	assert.Equal(t, sql.ErrNoRows, err)
+}
@@ -0,0 +1,70 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type DBRepository struct {
+	db *sql.DB
+}
+
+func NewDBRepository(db *sql.DB) *DBRepository {
+	return &DBRepository{
+		db: db,
+	}
+}
+
+func (r *DBRepository) Record(ctx context.Context, clientID string, day time.Time, endpoint string, consumption Consumption) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO usage_daily AS u (client_id, day, endpoint, requests, addresses, \"rows\") "+
+		"VALUES ($1, $2, $3, $4, $5, $6) "+
+		"ON CONFLICT (client_id, day, endpoint) DO UPDATE SET "+
+		"requests = u.requests + EXCLUDED.requests, "+
+		"addresses = u.addresses + EXCLUDED.addresses, "+
+		"\"rows\" = u.\"rows\" + EXCLUDED.\"rows\"",
+		clientID, day, endpoint, consumption.Requests, consumption.Addresses, consumption.Rows)
+	return err
+}
+
+func (r *DBRepository) Total(ctx context.Context, clientID string, from time.Time, to time.Time) (*Consumption, error) {
+	consumption := &Consumption{}
+	row := r.db.QueryRowContext(ctx, "SELECT COALESCE(SUM(requests),0), COALESCE(SUM(addresses),0), "+
+		"COALESCE(SUM(\"rows\"),0) FROM usage_daily WHERE client_id = $1 AND day BETWEEN $2 AND $3",
+		clientID, from, to)
+	if err := row.Scan(&consumption.Requests, &consumption.Addresses, &consumption.Rows); err != nil {
+		return nil, err
+	}
+	return consumption, nil
+}
+
+func (r *DBRepository) List(ctx context.Context, clientID string, from time.Time, to time.Time) ([]*Entry, error) {
+	entries := make([]*Entry, 0)
+	rows, err := r.db.QueryContext(ctx, "SELECT day, endpoint, requests, addresses, \"rows\" FROM usage_daily "+
+		"WHERE client_id = $1 AND day BETWEEN $2 AND $3 ORDER BY day, endpoint", clientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entry := &Entry{}
+		if err := rows.Scan(&entry.Day, &entry.Endpoint, &entry.Requests, &entry.Addresses, &entry.Rows); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetQuota returns sql.ErrNoRows when clientID has no quota override.
+func (r *DBRepository) GetQuota(ctx context.Context, clientID string) (*Quota, error) {
+	var requests, rows sql.NullInt64
+	row := r.db.QueryRowContext(ctx, "SELECT requests, \"rows\" FROM usage_quotas WHERE client_id = $1", clientID)
+	if err := row.Scan(&requests, &rows); err != nil {
+		return nil, err
+	}
+	return &Quota{
+		Requests: requests.Int64,
+		Rows:     rows.Int64,
+	}, nil
+}
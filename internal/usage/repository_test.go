@@ -0,0 +1,85 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func getDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return db, mock
+}
+
+func TestRepository_Record(t *testing.T) {
+	db, mock := getDB(t)
+	r := NewDBRepository(db)
+	day := time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO usage_daily AS u (client_id, day, endpoint, requests, addresses, \"rows\") "+
+		"VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (client_id, day, endpoint) DO UPDATE SET")).
+		WithArgs("key:1", day, "GET /v1/ips/", int64(1), int64(0), int64(50)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := r.Record(context.Background(), "key:1", day, "GET /v1/ips/", Consumption{Requests: 1, Rows: 50})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Total(t *testing.T) {
+	db, mock := getDB(t)
+	r := NewDBRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(requests),0), COALESCE(SUM(addresses),0), "+
+		"COALESCE(SUM(\"rows\"),0) FROM usage_daily WHERE client_id = $1 AND day BETWEEN $2 AND $3")).
+		WithArgs("key:1", firstOfMonth, lastOfMonth).
+		WillReturnRows(sqlmock.NewRows([]string{"requests", "addresses", "rows"}).AddRow(10, 4, 200))
+
+	total, err := r.Total(context.Background(), "key:1", firstOfMonth, lastOfMonth)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Consumption{Requests: 10, Addresses: 4, Rows: 200}, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetQuota(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  *sqlmock.Rows
+		quota *Quota
+		err   error
+	}{
+		{
+			name:  "override",
+			rows:  sqlmock.NewRows([]string{"requests", "rows"}).AddRow(1000, nil),
+			quota: &Quota{Requests: 1000},
+		},
+		{
+			name: "no override",
+			rows: sqlmock.NewRows([]string{"requests", "rows"}),
+			err:  sql.ErrNoRows,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := getDB(t)
+			r := NewDBRepository(db)
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT requests, \"rows\" FROM usage_quotas WHERE client_id = $1")).
+				WithArgs("key:1").
+				WillReturnRows(tc.rows)
+
+			quota, err := r.GetQuota(context.Background(), "key:1")
+
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.quota, quota)
+		})
+	}
+}
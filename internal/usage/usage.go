@@ -0,0 +1,96 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+//go:generate mockgen -source=usage.go -destination=usage_mock.go -package=usage
+
+type repository interface {
+	Record(context.Context, string, time.Time, string, Consumption) error
+	Total(context.Context, string, time.Time, time.Time) (*Consumption, error)
+	List(context.Context, string, time.Time, time.Time) ([]*Entry, error)
+	GetQuota(context.Context, string) (*Quota, error)
+}
+
+type UsageService struct {
+	repository   repository
+	defaultQuota Quota
+	now          func() time.Time
+}
+
+func NewUsageService(repository repository, defaultQuota Quota) *UsageService {
+	return &UsageService{
+		repository:   repository,
+		defaultQuota: defaultQuota,
+		now:          time.Now,
+	}
+}
+
+func (s *UsageService) Record(ctx context.Context, clientID string, endpoint string, consumption Consumption) error {
+	return s.repository.Record(ctx, clientID, s.today(), endpoint, consumption)
+}
+
+// CheckQuota returns the consumption of clientID in the current calendar
+// month (UTC) against its quota.
+func (s *UsageService) CheckQuota(ctx context.Context, clientID string) (*QuotaStatus, error) {
+	quota, err := s.repository.GetQuota(ctx, clientID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+		quota = &s.defaultQuota
+	}
+	from, to := s.month()
+	used, err := s.repository.Total(ctx, clientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaStatus{
+		Quota:    *quota,
+		Used:     *used,
+		ResetsAt: to.AddDate(0, 0, 1),
+	}, nil
+}
+
+// Report breaks down the consumption of clientID by day and endpoint between
+// from and to, both inclusive. Zero dates default to the current month.
+func (s *UsageService) Report(ctx context.Context, clientID string, from time.Time, to time.Time) (*Report, error) {
+	monthFrom, monthTo := s.month()
+	if from.IsZero() {
+		from = monthFrom
+	}
+	if to.IsZero() {
+		to = monthTo
+	}
+	entries, err := s.repository.List(ctx, clientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		ClientID: clientID,
+		From:     from,
+		To:       to,
+		Entries:  entries,
+	}
+	for _, entry := range entries {
+		report.Total.Requests += entry.Requests
+		report.Total.Addresses += entry.Addresses
+		report.Total.Rows += entry.Rows
+	}
+	return report, nil
+}
+
+func (s *UsageService) today() time.Time {
+	now := s.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// month returns the first and last day of the current month.
+func (s *UsageService) month() (time.Time, time.Time) {
+	today := s.today()
+	first := today.AddDate(0, 0, 1-today.Day())
+	return first, first.AddDate(0, 1, -1)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usage.go
+
+// Package usage is a generated GoMock package.
+package usage
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// Mockrepository is a mock of repository interface
+type Mockrepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockrepositoryMockRecorder
+}
+
+// MockrepositoryMockRecorder is the mock recorder for Mockrepository
+type MockrepositoryMockRecorder struct {
+	mock *Mockrepository
+}
+
+// NewMockrepository creates a new mock instance
+func NewMockrepository(ctrl *gomock.Controller) *Mockrepository {
+	mock := &Mockrepository{ctrl: ctrl}
+	mock.recorder = &MockrepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *Mockrepository) EXPECT() *MockrepositoryMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method
+func (m *Mockrepository) Record(arg0 context.Context, arg1 string, arg2 time.Time, arg3 string, arg4 Consumption) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record
+func (mr *MockrepositoryMockRecorder) Record(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*Mockrepository)(nil).Record), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Total mocks base method
+func (m *Mockrepository) Total(arg0 context.Context, arg1 string, arg2, arg3 time.Time) (*Consumption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Total", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*Consumption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Total indicates an expected call of Total
+func (mr *MockrepositoryMockRecorder) Total(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Total", reflect.TypeOf((*Mockrepository)(nil).Total), arg0, arg1, arg2, arg3)
+}
+
+// List mocks base method
+func (m *Mockrepository) List(arg0 context.Context, arg1 string, arg2, arg3 time.Time) ([]*Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockrepositoryMockRecorder) List(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*Mockrepository)(nil).List), arg0, arg1, arg2, arg3)
+}
+
+// GetQuota mocks base method
+func (m *Mockrepository) GetQuota(arg0 context.Context, arg1 string) (*Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuota", arg0, arg1)
+	ret0, _ := ret[0].(*Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuota indicates an expected call of GetQuota
+func (mr *MockrepositoryMockRecorder) GetQuota(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuota", reflect.TypeOf((*Mockrepository)(nil).GetQuota), arg0, arg1)
+}
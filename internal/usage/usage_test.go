@@ -0,0 +1,134 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newMockUsageService(ctrl *gomock.Controller) *UsageService {
+	service := NewUsageService(NewMockrepository(ctrl), Quota{Requests: 100})
+	service.now = func() time.Time { return time.Date(2021, 12, 15, 18, 30, 0, 0, time.UTC) }
+	return service
+}
+
+var (
+	firstOfMonth = time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	lastOfMonth  = time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func TestUsageService_CheckQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockUsageService(ctrl)
+
+	type want struct {
+		status   *QuotaStatus
+		exceeded bool
+		err      error
+	}
+
+	tests := []struct {
+		name         string
+		expectations func()
+		want         want
+	}{
+		{name: "default quota",
+			expectations: func() {
+				service.repository.(*Mockrepository).EXPECT().GetQuota(gomock.Any(), "key:1").Return(nil, sql.ErrNoRows)
+				service.repository.(*Mockrepository).EXPECT().
+					Total(gomock.Any(), "key:1", firstOfMonth, lastOfMonth).
+					Return(&Consumption{Requests: 40}, nil)
+			},
+			want: want{
+				status: &QuotaStatus{
+					Quota:    Quota{Requests: 100},
+					Used:     Consumption{Requests: 40},
+					ResetsAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{name: "override exceeded",
+			expectations: func() {
+				service.repository.(*Mockrepository).EXPECT().GetQuota(gomock.Any(), "key:1").Return(&Quota{Rows: 500}, nil)
+				service.repository.(*Mockrepository).EXPECT().
+					Total(gomock.Any(), "key:1", firstOfMonth, lastOfMonth).
+					Return(&Consumption{Requests: 4000, Rows: 500}, nil)
+			},
+			want: want{
+				status: &QuotaStatus{
+					Quota:    Quota{Rows: 500},
+					Used:     Consumption{Requests: 4000, Rows: 500},
+					ResetsAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+				exceeded: true,
+			},
+		},
+		{name: "error",
+			expectations: func() {
+				service.repository.(*Mockrepository).EXPECT().GetQuota(gomock.Any(), "key:1").Return(nil, sql.ErrConnDone)
+			},
+			want: want{err: sql.ErrConnDone},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			status, err := service.CheckQuota(context.Background(), "key:1")
+
+			assert.Equal(t, tc.want.err, err)
+			assert.Equal(t, tc.want.status, status)
+			if status != nil {
+				assert.Equal(t, tc.want.exceeded, status.Exceeded())
+			}
+		})
+	}
+}
+
+func TestUsageService_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockUsageService(ctrl)
+	consumption := Consumption{Requests: 1, Addresses: 1}
+	service.repository.(*Mockrepository).EXPECT().
+		Record(gomock.Any(), "key:1", time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC), "GET /v1/ips/{IP}/", consumption).
+		Return(nil)
+
+	assert.NoError(t, service.Record(context.Background(), "key:1", "GET /v1/ips/{IP}/", consumption))
+}
+
+func TestUsageService_Report(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newMockUsageService(ctrl)
+	entries := []*Entry{
+		{Day: firstOfMonth, Endpoint: "GET /v1/ips/", Consumption: Consumption{Requests: 2, Rows: 100}},
+		{Day: firstOfMonth, Endpoint: "GET /v1/ips/{IP}/", Consumption: Consumption{Requests: 3, Addresses: 3}},
+	}
+	service.repository.(*Mockrepository).EXPECT().
+		List(gomock.Any(), "key:1", firstOfMonth, lastOfMonth).
+		Return(entries, nil)
+
+	report, err := service.Report(context.Background(), "key:1", time.Time{}, time.Time{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Consumption{Requests: 5, Addresses: 3, Rows: 100}, report.Total)
+	assert.Equal(t, firstOfMonth, report.From)
+	assert.Equal(t, lastOfMonth, report.To)
+}
+
+func TestCounter(t *testing.T) {
+	ctx := WithCounter(context.Background())
+	AddAddresses(ctx, 1)
+	AddRows(ctx, 20)
+	AddRows(ctx, 5)
+
+	assert.Equal(t, Consumption{Requests: 1, Addresses: 1, Rows: 25}, Counted(ctx))
+	assert.Equal(t, Consumption{Requests: 1}, Counted(context.Background()))
+}